@@ -15,26 +15,55 @@ var (
 	sharpen     float64
 	gamma       float64
 	temperature int
+	tint        float64
+	vibrance    float64
 	dehaze      float64
+	highlights  float64
+	shadows     float64
+	whites      float64
+	blacks      float64
+	levels      []string
+	curves      []string
+	hsl         []string
+	curveFile   string
+	autoWB      string
+	autoTone    bool
 	output      string
 )
 
 var adjustCmd = &cobra.Command{
-	Use:   "adjust [image]",
+	Use:   "adjust [image...]",
 	Short: "调整图像的亮度、对比度、饱和度等",
 	Long: `对图像进行调色处理，支持：
-  - 亮度调整 (--brightness)
+  - 亮度调整 (--brightness，提亮或压暗中间调，黑色和白色不变)
   - 对比度调整 (--contrast)
   - 饱和度调整 (--saturation)
-  - 曝光调整 (--exposure)
+  - 自然饱和度 (--vibrance)，主要提高低饱和度颜色的饱和度，保护肤色
+  - 曝光调整 (--exposure，单位为档 EV，如 1.5；在线性光下调整并平滑压缩高光)
   - 锐化 (--sharpen)
   - Gamma 调整 (--gamma)
-  - 色温调整 (--temperature)
-  - 去雾 (--dehaze)`,
-	Args: cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		inputPath := args[0]
+  - 白平衡：色温 (--temperature) 和色调 (--tint，正值偏洋红、负值偏绿，可校正荧光灯下的偏绿)
+  - 去雾 (--dehaze，基于暗通道先验估计雾的浓度，负值加雾)
+  - 高光、阴影 (--highlights, --shadows)，按区域亮度调整，边缘处不产生光晕
+  - 白色、黑色 (--whites, --blacks)，调整白场和黑场
+  - 色阶 (--levels "[通道:]黑场,白场[,Gamma[,输出黑场,输出白场]]"，如 "10,245,1.1"、"blue:0,255,1,0,240")
+  - 曲线 (--curve "[通道:]x,y x,y ..."，如 "0,0 64,54 192,205 255,255"，控制点之间单调样条插值)
+  - HSL 调整 (--hsl "范围:h=色相,s=饱和度,l=明度"，如 "blue:s=-20,l=10"、"orange:h=-5,s=-15")
+  - 曲线文件 (--curve-file look.curve，每行一条 "levels ..."、"curve ..." 或 "hsl ..."，便于分享调色风格)
+
+自动调整：
+  - 自动白平衡 (--auto-wb 或 --auto-wb=方法，方法为 percentile（默认）、gray-world、white-patch)
+  - 自动色调 (--auto-tone，根据直方图设置色阶的黑场、白场和 Gamma)
+自动估计出的参数会按 pipeline --step 的格式输出，可直接写入配方复用。
 
+通道可为 rgb（默认）、red、green、blue；--levels、--curve 和 --hsl 可重复指定。
+HSL 的色相范围为 red、orange、yellow、green、aqua、blue、purple、magenta，取值 -100 到 100；
+未指定 --hsl 且曲线文件中没有 HSL 设置时，使用配置文件 [adjust] hsl。
+色阶先于曲线应用，曲线文件中的设置先于命令行参数应用。
+
+支持多个文件、glob 模式和目录，使用 --output-dir 指定输出目录。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		opts := processor.AdjustOptions{
 			Brightness:  brightness,
 			Contrast:    contrast,
@@ -43,40 +72,87 @@ var adjustCmd = &cobra.Command{
 			Sharpen:     sharpen,
 			Gamma:       gamma,
 			Temperature: temperature,
+			Tint:        tint,
+			Vibrance:    vibrance,
+			AutoWB:      autoWB,
+			AutoTone:    autoTone,
 			Dehaze:      dehaze,
+			Highlights:  highlights,
+			Shadows:     shadows,
+			Whites:      whites,
+			Blacks:      blacks,
+		}
+
+		if curveFile != "" {
+			look, err := processor.LoadCurveFile(curveFile)
+			if err != nil {
+				return err
+			}
+			opts.Levels, opts.Curves, opts.HSL = look.Levels, look.Curves, look.HSL
+		}
+		for _, s := range levels {
+			l, err := processor.ParseLevels(s)
+			if err != nil {
+				return err
+			}
+			opts.Levels = append(opts.Levels, l...)
+		}
+		for _, s := range curves {
+			c, err := processor.ParseCurves(s)
+			if err != nil {
+				return err
+			}
+			opts.Curves = append(opts.Curves, c...)
+		}
+		for _, s := range hsl {
+			h, err := processor.ParseHSL(s)
+			if err != nil {
+				return err
+			}
+			opts.HSL = append(opts.HSL, h...)
 		}
 
-		if output == "" {
-			output = addSuffix(inputPath, "_adjusted")
+		if autoWB != "" && (cmd.Flags().Changed("temperature") || cmd.Flags().Changed("tint")) {
+			return fmt.Errorf("--auto-wb 不能与 --temperature、--tint 同时使用")
+		}
+		if err := opts.Validate(); err != nil {
+			return err
 		}
 
-		return processor.Adjust(inputPath, output, opts)
+		op := processor.AdjustOperation{Options: opts, Report: processor.PrintAutoAdjustment}
+		enc, err := encodeOptions(processor.DefaultEncodeOptions())
+		if err != nil {
+			return err
+		}
+		return runBatch(args, "_adjusted", enc, op)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(adjustCmd)
 
-	adjustCmd.Flags().Float64VarP(&brightness, "brightness", "b", 0, "亮度调整 (-100 到 100)")
+	adjustCmd.Flags().Float64VarP(&brightness, "brightness", "b", 0, "中间调亮度 (-100 到 100)")
 	adjustCmd.Flags().Float64VarP(&contrast, "contrast", "t", 0, "对比度调整 (-100 到 100)")
 	adjustCmd.Flags().Float64VarP(&saturation, "saturation", "s", 0, "饱和度调整 (-100 到 100)")
-	adjustCmd.Flags().Float64VarP(&exposure, "exposure", "e", 0, "曝光调整 (-100 到 100)")
+	adjustCmd.Flags().Float64VarP(&exposure, "exposure", "e", 0, "曝光，单位 EV (-5 到 5)")
 	adjustCmd.Flags().Float64Var(&sharpen, "sharpen", 0, "锐化强度 (0 到 100)")
 	adjustCmd.Flags().Float64Var(&gamma, "gamma", 1.0, "Gamma 调整 (0.1 到 3.0)")
 	adjustCmd.Flags().IntVar(&temperature, "temperature", 6500, "色温调整，单位 K (2000-10000，6500 为标准日光)")
-	adjustCmd.Flags().Float64Var(&dehaze, "dehaze", 0, "去雾强度 (0 到 100)")
+	adjustCmd.Flags().Float64Var(&tint, "tint", 0, "色调 (-100 到 100，正值偏洋红，负值偏绿)")
+	adjustCmd.Flags().Float64Var(&vibrance, "vibrance", 0, "自然饱和度 (-100 到 100)")
+	adjustCmd.Flags().Float64Var(&dehaze, "dehaze", 0, "去雾强度 (-100 到 100，负值加雾)")
+	adjustCmd.Flags().Float64Var(&highlights, "highlights", 0, "高光 (-100 到 100)，负值恢复过曝区域的细节")
+	adjustCmd.Flags().Float64Var(&shadows, "shadows", 0, "阴影 (-100 到 100)，正值提亮暗部细节")
+	adjustCmd.Flags().Float64Var(&whites, "whites", 0, "白色 (-100 到 100)")
+	adjustCmd.Flags().Float64Var(&blacks, "blacks", 0, "黑色 (-100 到 100)")
+	adjustCmd.Flags().StringArrayVar(&levels, "levels", nil, "色阶 [通道:]黑场,白场[,Gamma[,输出黑场,输出白场]]，可重复指定")
+	adjustCmd.Flags().StringArrayVar(&curves, "curve", nil, "色调曲线 [通道:]x,y x,y ...，可重复指定")
+	adjustCmd.Flags().StringArrayVar(&hsl, "hsl", nil, "HSL 调整 范围:h=色相,s=饱和度,l=明度，可重复指定")
+	adjustCmd.Flags().StringVar(&curveFile, "curve-file", "", "曲线文件路径")
+	adjustCmd.Flags().StringVar(&autoWB, "auto-wb", "", "自动白平衡: --auto-wb=percentile|gray-world|white-patch（只写 --auto-wb 时为 percentile）")
+	adjustCmd.Flags().Lookup("auto-wb").NoOptDefVal = processor.AutoWBPercentile
+	adjustCmd.Flags().BoolVar(&autoTone, "auto-tone", false, "根据直方图自动设置色阶")
 	adjustCmd.Flags().StringVarP(&output, "output", "o", "", "输出文件路径")
-}
-
-// addSuffix 为文件名添加后缀
-func addSuffix(path, suffix string) string {
-	ext := ""
-	for i := len(path) - 1; i >= 0; i-- {
-		if path[i] == '.' {
-			ext = path[i:]
-			path = path[:i]
-			break
-		}
-	}
-	return fmt.Sprintf("%s%s%s", path, suffix, ext)
+	addBatchFlags(adjustCmd)
+	addEncodeFlags(adjustCmd)
 }
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/xiaoheiwowo/xpix/internal/processor"
+	"github.com/xiaoheiwowo/xpix/internal/recipe"
+)
+
+var applyRecipe string
+
+var applyCmd = &cobra.Command{
+	Use:   "apply [image...]",
+	Short: "使用配方处理图像",
+	Long: `按配方中定义的步骤处理图像，整个过程只解码和编码一次。
+
+--recipe 可以是配方文件路径，也可以是配方名称（在项目目录和用户目录中查找，
+使用 xpix recipe list 查看可用配方）。
+
+示例：
+  xpix apply --recipe instagram photos/ -r --output-dir out/`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if applyRecipe == "" {
+			return fmt.Errorf("请指定配方 (--recipe)")
+		}
+
+		r, err := recipe.Find(applyRecipe)
+		if err != nil {
+			return err
+		}
+
+		// 命令行参数优先于配方中的输出设置
+		if outputDir == "" {
+			outputDir = r.Output.Dir
+		}
+
+		// 编码选项优先级：命令行 > 配方 > 配置文件
+		enc, err := encodeOptions(r.Output.EncodeOptions(processor.DefaultEncodeOptions()))
+		if err != nil {
+			return err
+		}
+		return runBatch(args, r.Output.Suffix, enc, processor.StepsOperation(r.Steps))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringVar(&applyRecipe, "recipe", "", "配方名称或文件路径")
+	applyCmd.Flags().StringVarP(&output, "output", "o", "", "输出文件路径")
+	addBatchFlags(applyCmd)
+	addEncodeFlags(applyCmd)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/xiaoheiwowo/xpix/internal/batch"
+	"github.com/xiaoheiwowo/xpix/internal/processor"
+)
+
+var (
+	outputDir     string
+	recursive     bool
+	jobs          int
+	maxDecoded    int
+	outputQuality int
+	outputFormat  string
+	lossless      bool
+	tiffCompress  string
+	stripMetadata bool
+	noAutoOrient  bool
+)
+
+// addBatchFlags 为命令注册批量处理相关的标志
+func addBatchFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "输出目录（保持输入的目录结构）")
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "递归处理子目录")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "并行处理的任务数")
+	cmd.Flags().IntVar(&maxDecoded, "max-decoded", 0, "同时驻留内存的解码图像数上限，也限制同时处理的文件数 (0 表示与 --jobs 相同)")
+	cmd.Flags().BoolVar(&noAutoOrient, "no-auto-orient", false, "不按 EXIF 方向标签自动摆正图像")
+}
+
+// decodeOptions 用命令行标志覆盖配置文件中的解码选项
+func decodeOptions() processor.DecodeOptions {
+	dec := processor.DefaultDecodeOptions()
+	if noAutoOrient {
+		dec.AutoOrient = false
+	}
+	return dec
+}
+
+// addEncodeFlags 为命令注册输出编码相关的标志
+func addEncodeFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&outputQuality, "quality", "q", 0, "JPEG/WebP 输出质量 1-100（默认取配置文件）")
+	cmd.Flags().StringVarP(&outputFormat, "format", "f", "", "输出格式: auto, jpeg, png, gif, tiff, bmp, webp（默认取配置文件）")
+	cmd.Flags().BoolVar(&lossless, "lossless", false, "WebP 使用无损压缩")
+	cmd.Flags().StringVar(&tiffCompress, "tiff-compression", "", "TIFF 压缩方式: deflate, none（默认取配置文件）")
+	cmd.Flags().BoolVar(&stripMetadata, "strip-metadata", false, "不保留输入文件的 EXIF、XMP 和 ICC 元数据")
+}
+
+// encodeOptions 用命令行标志覆盖基础编码选项
+func encodeOptions(base processor.EncodeOptions) (processor.EncodeOptions, error) {
+	enc := base
+	if outputQuality != 0 {
+		enc.Quality = outputQuality
+	}
+	if outputFormat != "" {
+		enc.Format = outputFormat
+	}
+	if lossless {
+		enc.Lossless = true
+	}
+	if tiffCompress != "" {
+		enc.TIFFCompression = tiffCompress
+	}
+	if stripMetadata {
+		enc.StripMetadata = true
+	}
+	return enc, enc.Validate()
+}
+
+// runBatch 展开输入参数，对每个文件执行操作并按编码选项保存，最后输出汇总
+func runBatch(args []string, suffix string, enc processor.EncodeOptions, op processor.Operation) error {
+	return runBatchPlan(args, batch.Options{Suffix: suffix}, enc, op)
+}
+
+// runBatchPlan 同 runBatch，plan 提供后缀等规划选项，输出位置取自命令行标志
+func runBatchPlan(args []string, plan batch.Options, enc processor.EncodeOptions, op processor.Operation) error {
+	inputs, err := batch.Collect(args, recursive)
+	if err != nil {
+		return err
+	}
+
+	plan.Output = output
+	plan.OutputDir = outputDir
+	plan.Rename = enc.OutputPath
+	tasks, err := batch.Plan(inputs, plan)
+	if err != nil {
+		return err
+	}
+
+	dec := decodeOptions()
+	pool := &batch.Pool{Jobs: jobs, MaxDecoded: maxDecoded}
+	ctx, stop := withInterrupt(pool)
+	defer stop()
+
+	summary := pool.Run(ctx, tasks, func(ctx context.Context, task batch.Task) error {
+		return processor.ProcessFile(ctx, task.Input, task.Output, dec, enc, op)
+	})
+	summary.Print()
+	return summary.Err()
+}
+
+// withInterrupt 返回一个在收到 Ctrl-C 时取消的 context。
+// 第一次中断等待进行中的任务完成，第二次中断删除未完成的输出并立即退出。
+func withInterrupt(pool *batch.Pool) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-sigCh:
+		case <-ctx.Done():
+			return
+		}
+		fmt.Fprintln(os.Stderr, "\n⚠️  收到中断信号，等待进行中的任务完成（再次按 Ctrl-C 立即退出）")
+		cancel()
+
+		<-sigCh
+		pool.Abort()
+		os.Exit(130)
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigCh)
+		cancel()
+	}
+}
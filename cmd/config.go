@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/xiaoheiwowo/xpix/internal/config"
@@ -49,16 +51,75 @@ var configShowCmd = &cobra.Command{
 		fmt.Printf("  position = \"%s\"\n", cfg.Watermark.Position)
 		fmt.Printf("  opacity = %.2f\n", cfg.Watermark.Opacity)
 		fmt.Printf("  color = \"%s\"\n", cfg.Watermark.Color)
-		fmt.Printf("  margin = %d\n", cfg.Watermark.Margin)
+		fmt.Printf("  blend = \"%s\"\n", cfg.Watermark.Blend)
+		if cfg.Watermark.Margin.Percent {
+			fmt.Printf("  margin = \"%s\"\n", cfg.Watermark.Margin)
+		} else {
+			fmt.Printf("  margin = %s\n", cfg.Watermark.Margin)
+		}
+		fmt.Printf("  scale = %g\n", cfg.Watermark.Scale)
+		fmt.Printf("  scale_by = \"%s\"\n", cfg.Watermark.ScaleBy)
+		fmt.Printf("  font = \"%s\"\n", cfg.Watermark.Font)
+		fmt.Printf("  fallback_fonts = [%s]\n", quoteList(cfg.Watermark.FallbackFonts))
+		fmt.Printf("  stroke_width = %d\n", cfg.Watermark.StrokeWidth)
+		fmt.Printf("  stroke_color = \"%s\"\n", cfg.Watermark.StrokeColor)
+		fmt.Printf("  shadow = %t\n", cfg.Watermark.Shadow)
+		fmt.Printf("  shadow_offset_x = %d\n", cfg.Watermark.ShadowOffsetX)
+		fmt.Printf("  shadow_offset_y = %d\n", cfg.Watermark.ShadowOffsetY)
+		fmt.Printf("  shadow_blur = %g\n", cfg.Watermark.ShadowBlur)
+		fmt.Printf("  shadow_color = \"%s\"\n", cfg.Watermark.ShadowColor)
+		fmt.Printf("  background = %t\n", cfg.Watermark.Background)
+		fmt.Printf("  background_color = \"%s\"\n", cfg.Watermark.BackgroundColor)
+		fmt.Printf("  background_opacity = %.2f\n", cfg.Watermark.BackgroundOpacity)
+		fmt.Printf("  background_padding = %d\n", cfg.Watermark.BackgroundPadding)
+		fmt.Printf("  background_radius = %d\n", cfg.Watermark.BackgroundRadius)
+		fmt.Printf("  align = \"%s\"\n", cfg.Watermark.Align)
+		fmt.Printf("  line_spacing = %g\n", cfg.Watermark.LineSpacing)
+		fmt.Printf("  letter_spacing = %g\n", cfg.Watermark.LetterSpacing)
+		fmt.Printf("  tile = %t\n", cfg.Watermark.Tile)
+		fmt.Printf("  tile_angle = %g\n", cfg.Watermark.TileAngle)
+		fmt.Printf("  tile_spacing_x = %d\n", cfg.Watermark.TileSpacingX)
+		fmt.Printf("  tile_spacing_y = %d\n", cfg.Watermark.TileSpacingY)
+		fmt.Printf("  tile_stagger = %g\n", cfg.Watermark.TileStagger)
+		fmt.Println()
+		fmt.Println("[frame]")
+		fmt.Printf("  layout = \"%s\"\n", cfg.Frame.Layout)
+		fmt.Printf("  color = \"%s\"\n", cfg.Frame.Color)
+		fmt.Printf("  text_color = \"%s\"\n", cfg.Frame.TextColor)
+		fmt.Printf("  secondary_color = \"%s\"\n", cfg.Frame.SecondaryColor)
+		fmt.Printf("  border = %g\n", cfg.Frame.Border)
+		fmt.Printf("  bar = %g\n", cfg.Frame.Bar)
+		fmt.Printf("  logo = \"%s\"\n", cfg.Frame.Logo)
+		fmt.Printf("  font = \"%s\"\n", cfg.Frame.Font)
+		fmt.Printf("  title = %q\n", cfg.Frame.Title)
+		fmt.Printf("  subtitle = %q\n", cfg.Frame.Subtitle)
+		fmt.Printf("  info = %q\n", cfg.Frame.Info)
+		fmt.Printf("  date = %q\n", cfg.Frame.Date)
+		fmt.Println()
+		fmt.Println("[adjust]")
+		fmt.Printf("  hsl = %q\n", cfg.Adjust.HSL)
 		fmt.Println()
 		fmt.Println("[output]")
 		fmt.Printf("  quality = %d\n", cfg.Output.Quality)
 		fmt.Printf("  format = \"%s\"\n", cfg.Output.Format)
+		fmt.Printf("  lossless = %t\n", cfg.Output.Lossless)
+		fmt.Printf("  tiff_compression = \"%s\"\n", cfg.Output.TIFFCompression)
+		fmt.Printf("  strip_metadata = %t\n", cfg.Output.StripMetadata)
 		fmt.Println()
-		fmt.Println("注意: 字体文件固定为 ~/Library/Fonts/CaskaydiaMonoNerdFont-Regular.ttf")
+		fmt.Println("[input]")
+		fmt.Printf("  auto_orient = %t\n", cfg.Input.AutoOrient)
 	},
 }
 
+// quoteList 将字符串列表格式化为 TOML 数组的内容
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = strconv.Quote(s)
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(configInitCmd)
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/xiaoheiwowo/xpix/internal/batch"
+	"github.com/xiaoheiwowo/xpix/internal/processor"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert [image...]",
+	Short: "转换图像格式",
+	Long: `将图像转换为其他格式，不做任何处理，支持的输出格式：
+  - jpeg：有损压缩，使用 --quality 控制质量
+  - png、gif、bmp
+  - tiff：使用 --tiff-compression 选择 deflate（默认）或 none
+  - webp：默认有损压缩，使用 --quality 控制质量，--lossless 使用无损压缩
+
+输出格式由 --format 指定，或由 --output 的扩展名推断。未指定 --output 时
+输出到输入文件旁的同名文件（如 photo.jpg -> photo.webp），只有会覆盖输入文件时
+才追加 "_converted" 后缀。
+
+示例：
+  xpix convert photos/ -f webp -q 80 --output-dir web/
+  xpix convert scan.png -f tiff --tiff-compression none
+  xpix convert logo.png -o logo.webp --lossless`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		enc, err := encodeOptions(processor.DefaultEncodeOptions())
+		if err != nil {
+			return err
+		}
+		if outputFormat == "" && output == "" {
+			return fmt.Errorf("请使用 --format 指定输出格式，或使用 --output 指定输出文件")
+		}
+		if outputFormat == "" {
+			// 由 --output 的扩展名决定格式，不受配置文件中 format 的影响
+			enc.Format = processor.FormatAuto
+		}
+		return runBatchPlan(args, batch.Options{Suffix: "_converted", SuffixIfSame: true}, enc, processor.StepsOperation(nil))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+
+	convertCmd.Flags().StringVarP(&output, "output", "o", "", "输出文件路径")
+	addBatchFlags(convertCmd)
+	addEncodeFlags(convertCmd)
+}
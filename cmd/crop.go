@@ -13,13 +13,13 @@ var (
 )
 
 var cropCmd = &cobra.Command{
-	Use:   "crop [image]",
+	Use:   "crop [image...]",
 	Short: "裁剪图像",
-	Long:  `裁剪图像到指定的尺寸和位置`,
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		inputPath := args[0]
+	Long: `裁剪图像到指定的尺寸和位置
 
+支持多个文件、glob 模式和目录，使用 --output-dir 指定输出目录。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		opts := processor.CropOptions{
 			X:      cropX,
 			Y:      cropY,
@@ -27,11 +27,12 @@ var cropCmd = &cobra.Command{
 			Height: cropHeight,
 		}
 
-		if output == "" {
-			output = addSuffix(inputPath, "_cropped")
+		op := processor.CropOperation{Options: opts}
+		enc, err := encodeOptions(processor.DefaultEncodeOptions())
+		if err != nil {
+			return err
 		}
-
-		return processor.Crop(inputPath, output, opts)
+		return runBatch(args, "_cropped", enc, op)
 	},
 }
 
@@ -43,6 +44,10 @@ func init() {
 	cropCmd.Flags().IntVarP(&cropWidth, "width", "w", 0, "裁剪宽度")
 	cropCmd.Flags().IntVarP(&cropHeight, "height", "h", 0, "裁剪高度")
 	cropCmd.Flags().StringVarP(&output, "output", "o", "", "输出文件路径")
+	addBatchFlags(cropCmd)
+	addEncodeFlags(cropCmd)
+	// -h 已用于 --height，帮助标志不使用简写
+	cropCmd.Flags().Bool("help", false, "显示帮助信息")
 
 	cropCmd.MarkFlagRequired("width")
 	cropCmd.MarkFlagRequired("height")
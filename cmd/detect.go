@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/xiaoheiwowo/xpix/internal/batch"
+	"github.com/xiaoheiwowo/xpix/internal/processor"
+)
+
+// detectJobs 检测的并行任务数，与其他命令的 --jobs 分开保存，避免互相覆盖默认值
+var detectJobs int
+
+var detectCmd = &cobra.Command{
+	Use:   "detect [image...]",
+	Short: "检测不可见水印",
+	Long: `检测并输出由 xpix watermark --invisible 嵌入的不可见水印载荷。
+
+载荷带有 CRC 校验，只有校验通过时才会输出；经过 JPEG 重新压缩或等比缩放的图像通常仍能检测到。
+不支持裁剪：裁剪或旋转过的图像无法检测，大幅修改过的图像也可能无法检测。没有检测到水印的文件计为失败。
+
+支持多个文件、glob 模式和目录。
+
+示例：
+  xpix watermark detect photo_watermarked.jpg
+  xpix watermark detect photos/ -r`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputs, err := batch.Collect(args, recursive)
+		if err != nil {
+			return err
+		}
+
+		tasks := make([]batch.Task, 0, len(inputs))
+		for _, in := range inputs {
+			tasks = append(tasks, batch.Task{Input: in.Path, Err: in.Err})
+		}
+
+		pool := &batch.Pool{Jobs: detectJobs}
+		summary := pool.Run(context.Background(), tasks, func(ctx context.Context, task batch.Task) error {
+			payload, err := processor.DetectPayloadFile(task.Input)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✅ %s: %q\n", task.Input, payload)
+			return nil
+		})
+		summary.Print()
+		return summary.Err()
+	},
+}
+
+func init() {
+	watermarkCmd.AddCommand(detectCmd)
+
+	detectCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "递归处理子目录")
+	detectCmd.Flags().IntVarP(&detectJobs, "jobs", "j", 1, "并行处理的任务数")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/xiaoheiwowo/xpix/internal/config"
+	"github.com/xiaoheiwowo/xpix/internal/processor"
+)
+
+var frameOpts processor.FrameOptions
+
+var frameCmd = &cobra.Command{
+	Use:   "frame [image...]",
+	Short: "加上显示相机信息的相框",
+	Long: `为照片加上边框（默认白色）和底部信息栏，信息栏显示相机型号、镜头、
+拍摄参数和日期，可选品牌 Logo。布局 (--layout)：
+  - classic：左侧为相机和镜头，右侧为 Logo、拍摄参数和日期
+  - center：居中排列 Logo、相机和拍摄参数
+  - minimal：居中一行，相机 · 拍摄参数
+
+边框宽度 (--border) 和底栏高度 (--bar) 是相对于图像宽度的比例。
+文字内容 (--title、--subtitle、--info、--date) 是文字模板，支持与 watermark
+相同的 EXIF 占位符；照片缺少相应 EXIF 信息的行不显示。
+
+示例：
+  xpix frame photo.jpg
+  xpix frame photos/ --layout center --logo fujifilm.png --output-dir framed/
+  xpix frame photo.jpg --color "#000000" --text-color "#FFFFFF" --border 0.05`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := frameOpts.ValidateWith(config.Get()); err != nil {
+			return err
+		}
+		op := processor.FrameOperation{Options: frameOpts}
+		enc, err := encodeOptions(processor.DefaultEncodeOptions())
+		if err != nil {
+			return err
+		}
+		return runBatch(args, "_framed", enc, op)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(frameCmd)
+
+	frameCmd.Flags().StringVarP(&frameOpts.Layout, "layout", "l", "", "布局: classic, center, minimal（默认取配置文件）")
+	frameCmd.Flags().StringVar(&frameOpts.Color, "color", "", "边框颜色 #RRGGBB（默认取配置文件）")
+	frameCmd.Flags().StringVar(&frameOpts.TextColor, "text-color", "", "主要文字颜色 #RRGGBB（默认取配置文件）")
+	frameCmd.Flags().StringVar(&frameOpts.SecondaryColor, "secondary-color", "", "次要文字颜色 #RRGGBB（默认取配置文件）")
+	frameCmd.Flags().Float64Var(&frameOpts.Border, "border", 0, "上、左、右边框宽度，相对于图像宽度的比例（默认取配置文件）")
+	frameCmd.Flags().Float64Var(&frameOpts.Bar, "bar", 0, "底栏高度，相对于图像宽度的比例（默认取配置文件）")
+	frameCmd.Flags().StringVar(&frameOpts.Logo, "logo", "", "品牌 Logo 图片路径")
+	frameCmd.Flags().StringVar(&frameOpts.Font, "font", "", "字体文件路径或字体族名（默认取配置文件）")
+	frameCmd.Flags().StringVar(&frameOpts.Title, "title", "", "标题文字模板（默认 {Camera}）")
+	frameCmd.Flags().StringVar(&frameOpts.Subtitle, "subtitle", "", "副标题文字模板（默认 {LensModel}）")
+	frameCmd.Flags().StringVar(&frameOpts.Info, "info", "", "拍摄参数文字模板")
+	frameCmd.Flags().StringVar(&frameOpts.Date, "date", "", "日期文字模板")
+	frameCmd.Flags().StringVarP(&output, "output", "o", "", "输出文件路径")
+	addBatchFlags(frameCmd)
+	addEncodeFlags(frameCmd)
+}
@@ -1,22 +1,43 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/spf13/cobra"
+	"github.com/xiaoheiwowo/xpix/internal/batch"
 	"github.com/xiaoheiwowo/xpix/internal/processor"
 )
 
 var infoCmd = &cobra.Command{
-	Use:   "info [image]",
+	Use:   "info [image...]",
 	Short: "显示图像的元数据信息",
-	Long:  `显示图像的 EXIF 元数据信息，包括相机型号、拍摄参数、GPS 位置等`,
-	Args:  cobra.ExactArgs(1),
+	Long: `显示图像的 EXIF 元数据信息，包括相机型号、拍摄参数、GPS 位置等
+
+支持多个文件、glob 模式和目录。`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		imagePath := args[0]
-		return processor.ShowImageInfo(imagePath)
+		inputs, err := batch.Collect(args, recursive)
+		if err != nil {
+			return err
+		}
+
+		tasks := make([]batch.Task, 0, len(inputs))
+		for _, in := range inputs {
+			tasks = append(tasks, batch.Task{Input: in.Path, Err: in.Err})
+		}
+
+		// 逐个输出，避免多张图像的信息交错
+		pool := &batch.Pool{Jobs: 1}
+		summary := pool.Run(context.Background(), tasks, func(ctx context.Context, task batch.Task) error {
+			return processor.ShowImageInfo(task.Input)
+		})
+		summary.Print()
+		return summary.Err()
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(infoCmd)
-}
 
+	infoCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "递归处理子目录")
+}
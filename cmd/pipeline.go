@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xiaoheiwowo/xpix/internal/processor"
+)
+
+var (
+	pipelineSteps []string
+	pipelineList  bool
+)
+
+var pipelineCmd = &cobra.Command{
+	Use:   "pipeline [image...]",
+	Short: "在一次解码/编码中串联多个处理步骤",
+	Long: `按顺序执行多个处理步骤，图像只解码和编码一次，避免多次有损压缩。
+
+每个 --step 的格式为 "操作:参数=值,参数=值"，支持的操作：
+  - crop:x=100,y=100,w=500,h=500
+  - resize:w=2000 (可选 h、keep-ratio)
+  - adjust:contrast=10,saturation=5 (参数同 adjust 命令)
+  - watermark:text="© 2025",position=bottom-right,opacity=0.7
+
+参数值中包含逗号时用双引号包裹整个值，引号内用 \" 表示双引号、\\ 表示反斜杠；
+使用 --list 查看全部操作及参数。
+
+示例：
+  xpix pipeline in.jpg --step crop:x=0,y=0,w=3000,h=2000 --step resize:w=2000 \
+    --step adjust:contrast=10 --step 'watermark:text="© Alice, 2025"'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if pipelineList {
+			printOperations()
+			return nil
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("请指定至少一个图像文件")
+		}
+		if len(pipelineSteps) == 0 {
+			return fmt.Errorf("请至少指定一个处理步骤 (--step)")
+		}
+
+		steps := make([]processor.Step, 0, len(pipelineSteps))
+		for _, spec := range pipelineSteps {
+			step, err := processor.ParseStep(spec)
+			if err != nil {
+				return err
+			}
+			steps = append(steps, step)
+		}
+
+		op := processor.StepsOperation(steps)
+		enc, err := encodeOptions(processor.DefaultEncodeOptions())
+		if err != nil {
+			return err
+		}
+		return runBatch(args, "_processed", enc, op)
+	},
+}
+
+// printOperations 输出已注册的操作及其参数
+func printOperations() {
+	for _, spec := range processor.Operations() {
+		fmt.Printf("%s - %s\n", spec.Name, spec.Description)
+		for _, p := range spec.Params {
+			name := p.Name
+			if len(p.Aliases) > 0 {
+				name += " (" + strings.Join(p.Aliases, ", ") + ")"
+			}
+			def := ""
+			if p.Default != "" {
+				def = fmt.Sprintf("，默认 %s", p.Default)
+			}
+			fmt.Printf("  %-28s %-6s %s%s\n", name, p.Type, p.Description, def)
+		}
+		fmt.Println()
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(pipelineCmd)
+
+	pipelineCmd.Flags().StringArrayVar(&pipelineSteps, "step", nil, "处理步骤，可重复指定，按顺序执行")
+	pipelineCmd.Flags().BoolVar(&pipelineList, "list", false, "列出支持的操作及参数")
+	pipelineCmd.Flags().StringVarP(&output, "output", "o", "", "输出文件路径")
+	addBatchFlags(pipelineCmd)
+	addEncodeFlags(pipelineCmd)
+}
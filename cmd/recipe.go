@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/xiaoheiwowo/xpix/internal/recipe"
+)
+
+var recipeCmd = &cobra.Command{
+	Use:   "recipe",
+	Short: "处理配方管理",
+	Long: fmt.Sprintf(`管理处理配方
+
+配方按以下顺序查找（同名时前者优先）：
+  - %s（项目目录）
+  - %s（用户目录）`, recipe.ProjectDir, recipe.UserDir()),
+}
+
+var recipeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出可用的配方",
+	Long:  `列出项目目录和用户目录中的所有配方`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := recipe.List()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Printf("未找到配方（搜索目录: %s）\n", strings.Join(recipe.Dirs(), ", "))
+			return nil
+		}
+
+		for _, e := range entries {
+			r, err := recipe.Load(e.Path)
+			if err != nil {
+				fmt.Printf("  ❌ %-16s %v\n", e.Name, err)
+				continue
+			}
+			fmt.Printf("  📋 %-16s %s\n", r.Name, r.Description)
+			fmt.Printf("     %-16s %s\n", "", r.Path)
+		}
+		return nil
+	},
+}
+
+var recipeShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "显示配方内容",
+	Long:  `校验并显示配方的处理步骤和输出设置`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := recipe.Find(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("配方:     %s\n", r.Name)
+		fmt.Printf("文件:     %s\n", r.Path)
+		if r.Description != "" {
+			fmt.Printf("说明:     %s\n", r.Description)
+		}
+		fmt.Println()
+		fmt.Println("处理步骤:")
+		for i, step := range r.Steps {
+			fmt.Printf("  %d. %s%s\n", i+1, step.Op, formatParams(step.Params))
+		}
+		fmt.Println()
+		fmt.Println("输出设置:")
+		fmt.Printf("  suffix = \"%s\"\n", r.Output.Suffix)
+		if r.Output.Dir != "" {
+			fmt.Printf("  dir = \"%s\"\n", r.Output.Dir)
+		}
+		if r.Output.Quality != 0 {
+			fmt.Printf("  quality = %d\n", r.Output.Quality)
+		}
+		if r.Output.Format != "" {
+			fmt.Printf("  format = \"%s\"\n", r.Output.Format)
+		}
+		if r.Output.Lossless != nil {
+			fmt.Printf("  lossless = %t\n", *r.Output.Lossless)
+		}
+		if r.Output.TIFFCompression != "" {
+			fmt.Printf("  tiff_compression = \"%s\"\n", r.Output.TIFFCompression)
+		}
+		if r.Output.StripMetadata != nil {
+			fmt.Printf("  strip_metadata = %t\n", *r.Output.StripMetadata)
+		}
+		return nil
+	},
+}
+
+// formatParams 按键名排序格式化步骤参数
+func formatParams(params map[string]string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", k, params[k]))
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+func init() {
+	rootCmd.AddCommand(recipeCmd)
+	recipeCmd.AddCommand(recipeListCmd)
+	recipeCmd.AddCommand(recipeShowCmd)
+}
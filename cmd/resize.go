@@ -12,24 +12,25 @@ var (
 )
 
 var resizeCmd = &cobra.Command{
-	Use:   "resize [image]",
+	Use:   "resize [image...]",
 	Short: "调整图像尺寸",
-	Long:  `调整图像到指定的宽度和高度`,
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		inputPath := args[0]
+	Long: `调整图像到指定的宽度和高度
 
+支持多个文件、glob 模式和目录，使用 --output-dir 指定输出目录。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		opts := processor.ResizeOptions{
 			Width:     resizeWidth,
 			Height:    resizeHeight,
 			KeepRatio: keepRatio,
 		}
 
-		if output == "" {
-			output = addSuffix(inputPath, "_resized")
+		op := processor.ResizeOperation{Options: opts}
+		enc, err := encodeOptions(processor.DefaultEncodeOptions())
+		if err != nil {
+			return err
 		}
-
-		return processor.Resize(inputPath, output, opts)
+		return runBatch(args, "_resized", enc, op)
 	},
 }
 
@@ -40,5 +41,9 @@ func init() {
 	resizeCmd.Flags().IntVarP(&resizeHeight, "height", "h", 0, "目标高度")
 	resizeCmd.Flags().BoolVarP(&keepRatio, "keep-ratio", "k", true, "保持宽高比")
 	resizeCmd.Flags().StringVarP(&output, "output", "o", "", "输出文件路径")
+	addBatchFlags(resizeCmd)
+	addEncodeFlags(resizeCmd)
+	// -h 已用于 --height，帮助标志不使用简写
+	resizeCmd.Flags().Bool("help", false, "显示帮助信息")
 }
 
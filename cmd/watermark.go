@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
 	"github.com/xiaoheiwowo/xpix/internal/processor"
 )
@@ -10,42 +13,209 @@ var (
 	watermarkImage    string
 	watermarkPosition string
 	watermarkOpacity  float64
+	watermarkBlend    string
+	watermarkFont     string
+	watermarkMargin   string
+	watermarkScale    float64
+	watermarkScaleBy  string
+
+	watermarkStyle processor.TextStyle
+
+	watermarkTile bool
+	tileAngle     float64
+	tileSpacingX  int
+	tileSpacingY  int
+	tileStagger   float64
+
+	watermarkInvisible bool
+	watermarkPayload   string
 )
 
 var watermarkCmd = &cobra.Command{
-	Use:   "watermark [image]",
+	Use:   "watermark [image...]",
 	Short: "添加文字或图片水印",
 	Long: `为图像添加水印，支持：
-  - 文字水印 (--text)
+  - 文字水印 (--text)，可使用 EXIF 占位符，如 "© Alice · {DateTime:2006-01-02} · {FocalLength}mm f/{FNumber} {ExposureTime}s ISO{ISO}"
   - 图片水印 (--image)
   - 位置控制 (--position: top-left, top-right, top-center, bottom-left, bottom-right, bottom-center, center)
-  - 透明度控制 (--opacity)`,
-	Args: cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		inputPath := args[0]
+  - 透明度控制 (--opacity)
+  - 混合模式 (--blend: normal, multiply, screen, overlay, soft-light, difference)
+  - 边距 (--margin: 像素或图像短边的百分比，如 40、3%；文字和图片水印的外边缘与图像边缘相距该距离)
+  - 图片水印大小 (--scale: 相对于图像宽度的比例，--scale-by short 改为相对于短边；小图会放大，大图会缩小)
+  - 字体 (--font: 字体文件路径或字体族名，如 "Noto Sans")
+  - 描边 (--stroke-width、--stroke-color)
+  - 阴影 (--shadow，配合 --shadow-offset-x/y、--shadow-blur、--shadow-color)
+  - 背景底板 (--background，配合 --background-color、--background-opacity、--background-padding、--background-radius)
+  - 多行文字 (文字中的换行符或 \n 分行，配合 --align、--line-spacing) 和字间距 (--letter-spacing)
+  - 平铺 (--tile: 旋转后重复铺满整幅图像，配合 --tile-angle、--tile-spacing-x/y、--tile-stagger)
+  - 不可见水印 (--invisible --payload "<id>": 在图像的低频部分嵌入最长 32 字节的标识，
+    肉眼不可见，能经受 JPEG 重新压缩和缩放，不支持裁剪；要求图像短边至少 512 像素；
+    使用 xpix watermark detect 检测)
 
+支持多个文件、glob 模式和目录，使用 --output-dir 指定输出目录。`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		opts := processor.WatermarkOptions{
 			Text:     watermarkText,
 			Image:    watermarkImage,
 			Position: watermarkPosition,
 			Opacity:  watermarkOpacity,
+			Blend:    watermarkBlend,
+			Font:     watermarkFont,
+			Scale:    watermarkScale,
+			ScaleBy:  watermarkScaleBy,
+
+			Invisible: watermarkInvisible,
+			Payload:   watermarkPayload,
+		}
+		if watermarkPayload != "" && !watermarkInvisible {
+			return fmt.Errorf("--payload 需要与 --invisible 一起使用")
+		}
+		if cmd.Flags().Changed("margin") {
+			if err := opts.SetMargin(watermarkMargin); err != nil {
+				return err
+			}
 		}
 
-		if output == "" {
-			output = addSuffix(inputPath, "_watermarked")
+		opts.Style = textStyle(cmd)
+		opts.Tile = tileOptions(cmd)
+		if err := opts.Validate(); err != nil {
+			return err
 		}
 
-		return processor.Watermark(inputPath, output, opts)
+		op := processor.WatermarkOperation{Options: opts}
+		enc, err := encodeOptions(processor.DefaultEncodeOptions())
+		if err != nil {
+			return err
+		}
+		return runBatch(args, "_watermarked", enc, op)
 	},
 }
 
+// textStyle 用命令行标志覆盖配置文件中的文字样式，没有指定任何样式标志时返回 nil。
+// 指定任一阴影或底板参数即开启阴影或底板。
+func textStyle(cmd *cobra.Command) *processor.TextStyle {
+	flags := cmd.Flags()
+	s := processor.DefaultTextStyle()
+	changed := false
+	str := func(name string, dst *string, v string) {
+		if flags.Changed(name) {
+			*dst, changed = v, true
+		}
+	}
+	integer := func(name string, dst *int, v int) {
+		if flags.Changed(name) {
+			*dst, changed = v, true
+		}
+	}
+	float := func(name string, dst *float64, v float64) {
+		if flags.Changed(name) {
+			*dst, changed = v, true
+		}
+	}
+
+	integer("stroke-width", &s.StrokeWidth, watermarkStyle.StrokeWidth)
+	str("stroke-color", &s.StrokeColor, watermarkStyle.StrokeColor)
+	if flags.Changed("shadow-offset-x") || flags.Changed("shadow-offset-y") || flags.Changed("shadow-blur") || flags.Changed("shadow-color") {
+		s.Shadow = true
+	}
+	if flags.Changed("shadow") {
+		s.Shadow, changed = watermarkStyle.Shadow, true
+	}
+	integer("shadow-offset-x", &s.ShadowOffsetX, watermarkStyle.ShadowOffsetX)
+	integer("shadow-offset-y", &s.ShadowOffsetY, watermarkStyle.ShadowOffsetY)
+	float("shadow-blur", &s.ShadowBlur, watermarkStyle.ShadowBlur)
+	str("shadow-color", &s.ShadowColor, watermarkStyle.ShadowColor)
+	if flags.Changed("background-color") || flags.Changed("background-opacity") || flags.Changed("background-padding") || flags.Changed("background-radius") {
+		s.Background = true
+	}
+	if flags.Changed("background") {
+		s.Background, changed = watermarkStyle.Background, true
+	}
+	str("background-color", &s.BackgroundColor, watermarkStyle.BackgroundColor)
+	float("background-opacity", &s.BackgroundOpacity, watermarkStyle.BackgroundOpacity)
+	integer("background-padding", &s.BackgroundPadding, watermarkStyle.BackgroundPadding)
+	integer("background-radius", &s.BackgroundRadius, watermarkStyle.BackgroundRadius)
+	str("align", &s.Align, watermarkStyle.Align)
+	float("line-spacing", &s.LineSpacing, watermarkStyle.LineSpacing)
+	float("letter-spacing", &s.LetterSpacing, watermarkStyle.LetterSpacing)
+	if !changed {
+		return nil
+	}
+	return &s
+}
+
+// tileOptions 用命令行标志覆盖配置文件中的平铺设置，指定任一平铺参数即开启平铺
+func tileOptions(cmd *cobra.Command) *processor.TileOptions {
+	flags := cmd.Flags()
+	tile := processor.DefaultTileOptions()
+	changed := false
+	if flags.Changed("tile") {
+		tile.Enabled, changed = watermarkTile, true
+	}
+	if flags.Changed("tile-angle") {
+		tile.Angle, changed = tileAngle, true
+	}
+	if flags.Changed("tile-spacing-x") {
+		tile.SpacingX, changed = tileSpacingX, true
+	}
+	if flags.Changed("tile-spacing-y") {
+		tile.SpacingY, changed = tileSpacingY, true
+	}
+	if flags.Changed("tile-stagger") {
+		tile.Stagger, changed = tileStagger, true
+	}
+	if !changed {
+		return nil
+	}
+	if !flags.Changed("tile") {
+		tile.Enabled = true
+	}
+	return &tile
+}
+
 func init() {
 	rootCmd.AddCommand(watermarkCmd)
 
+	var placeholders strings.Builder
+	placeholders.WriteString("\n\n文字水印中的占位符（{{ 和 }} 表示字面量花括号）:")
+	for _, p := range processor.Placeholders() {
+		fmt.Fprintf(&placeholders, "\n  %-16s %s", "{"+p[0]+"}", p[1])
+	}
+	watermarkCmd.Long += placeholders.String()
+
 	watermarkCmd.Flags().StringVarP(&watermarkText, "text", "t", "", "文字水印内容")
 	watermarkCmd.Flags().StringVar(&watermarkImage, "image", "", "图片水印路径")
 	watermarkCmd.Flags().StringVarP(&watermarkPosition, "position", "p", "bottom-center", "水印位置")
 	watermarkCmd.Flags().Float64Var(&watermarkOpacity, "opacity", 0.5, "水印透明度 (0-1)")
+	watermarkCmd.Flags().StringVar(&watermarkBlend, "blend", "", "混合模式: normal, multiply, screen, overlay, soft-light, difference（默认取配置文件）")
+	watermarkCmd.Flags().StringVar(&watermarkMargin, "margin", "", "水印与图像边缘的距离，像素或短边的百分比，如 40、3%（默认取配置文件）")
+	watermarkCmd.Flags().Float64Var(&watermarkScale, "scale", 0, "图片水印的宽度，相对于图像宽度或短边的比例 0-1（默认取配置文件）")
+	watermarkCmd.Flags().StringVar(&watermarkScaleBy, "scale-by", "", "图片水印大小的基准: width, short（默认取配置文件）")
+	watermarkCmd.Flags().StringVar(&watermarkFont, "font", "", "字体文件路径或字体族名（默认取配置文件）")
+	watermarkCmd.Flags().IntVar(&watermarkStyle.StrokeWidth, "stroke-width", 0, "文字描边宽度（像素，默认取配置文件）")
+	watermarkCmd.Flags().StringVar(&watermarkStyle.StrokeColor, "stroke-color", "", "文字描边颜色 #RRGGBB（默认取配置文件）")
+	watermarkCmd.Flags().BoolVar(&watermarkStyle.Shadow, "shadow", false, "绘制文字阴影（默认取配置文件）")
+	watermarkCmd.Flags().IntVar(&watermarkStyle.ShadowOffsetX, "shadow-offset-x", 0, "阴影的水平偏移（像素，默认取配置文件）")
+	watermarkCmd.Flags().IntVar(&watermarkStyle.ShadowOffsetY, "shadow-offset-y", 0, "阴影的垂直偏移（像素，默认取配置文件）")
+	watermarkCmd.Flags().Float64Var(&watermarkStyle.ShadowBlur, "shadow-blur", 0, "阴影的模糊半径（像素，默认取配置文件）")
+	watermarkCmd.Flags().StringVar(&watermarkStyle.ShadowColor, "shadow-color", "", "阴影颜色 #RRGGBB（默认取配置文件）")
+	watermarkCmd.Flags().BoolVar(&watermarkStyle.Background, "background", false, "在文字下方绘制圆角背景底板（默认取配置文件）")
+	watermarkCmd.Flags().StringVar(&watermarkStyle.BackgroundColor, "background-color", "", "底板颜色 #RRGGBB（默认取配置文件）")
+	watermarkCmd.Flags().Float64Var(&watermarkStyle.BackgroundOpacity, "background-opacity", 0, "底板透明度 0-1（默认取配置文件）")
+	watermarkCmd.Flags().IntVar(&watermarkStyle.BackgroundPadding, "background-padding", 0, "文字与底板边缘的距离（像素，默认取配置文件）")
+	watermarkCmd.Flags().IntVar(&watermarkStyle.BackgroundRadius, "background-radius", 0, "底板圆角半径（像素，默认取配置文件）")
+	watermarkCmd.Flags().StringVar(&watermarkStyle.Align, "align", "", "多行文字的对齐方式: left, center, right（默认取配置文件）")
+	watermarkCmd.Flags().Float64Var(&watermarkStyle.LineSpacing, "line-spacing", 0, "行距，行高的倍数（默认取配置文件）")
+	watermarkCmd.Flags().Float64Var(&watermarkStyle.LetterSpacing, "letter-spacing", 0, "字间距（像素，可为负，默认取配置文件）")
+	watermarkCmd.Flags().BoolVar(&watermarkTile, "tile", false, "平铺水印，重复铺满整幅图像（默认取配置文件）")
+	watermarkCmd.Flags().Float64Var(&tileAngle, "tile-angle", 0, "平铺水印的旋转角度，逆时针为正（默认取配置文件）")
+	watermarkCmd.Flags().IntVar(&tileSpacingX, "tile-spacing-x", 0, "平铺水印的水平间距（像素，默认取配置文件）")
+	watermarkCmd.Flags().IntVar(&tileSpacingY, "tile-spacing-y", 0, "平铺水印的垂直间距（像素，默认取配置文件）")
+	watermarkCmd.Flags().Float64Var(&tileStagger, "tile-stagger", 0, "相邻行的水平错位比例 0-1（默认取配置文件）")
+	watermarkCmd.Flags().BoolVar(&watermarkInvisible, "invisible", false, "嵌入不可见水印（配合 --payload）")
+	watermarkCmd.Flags().StringVar(&watermarkPayload, "payload", "", "不可见水印的载荷，最长 32 字节")
 	watermarkCmd.Flags().StringVarP(&output, "output", "o", "", "输出文件路径")
+	addBatchFlags(watermarkCmd)
+	addEncodeFlags(watermarkCmd)
 }
-
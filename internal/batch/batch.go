@@ -0,0 +1,310 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// imageExts 目录扫描时识别的图像扩展名
+var imageExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".bmp":  true,
+	".tif":  true,
+	".tiff": true,
+	".webp": true,
+}
+
+// Input 待处理的输入文件
+type Input struct {
+	Path string // 文件路径
+	Rel  string // 相对于输入根目录的路径（用于在输出目录中还原目录结构）
+	Err  error  // 无法访问该路径时的错误，对应的任务直接计为失败
+}
+
+// Task 单个处理任务
+type Task struct {
+	Input  string // 输入文件路径
+	Output string // 输出文件路径
+	Err    error  // 非 nil 时任务不执行，直接以该错误计为失败
+}
+
+// Options 批量处理选项
+type Options struct {
+	Output    string // 单文件输出路径（仅在只有一个输入时可用）
+	OutputDir string // 输出目录，保持输入的目录结构
+	Suffix    string // 未指定输出位置时追加到文件名后的后缀
+
+	// SuffixIfSame 为 true 时，未指定输出位置的情况下只有输出会覆盖输入才追加后缀
+	// （如格式转换时扩展名已改变则直接输出到同名文件）
+	SuffixIfSame bool
+
+	// Rename 非空时用于修正输出路径（如按输出格式替换扩展名）
+	Rename func(path string) string
+}
+
+// IsImage 判断文件扩展名是否为支持的图像格式
+func IsImage(path string) bool {
+	return imageExts[strings.ToLower(filepath.Ext(path))]
+}
+
+// Collect 展开文件路径、glob 模式和目录，返回去重后的输入文件列表。
+//
+// 无法访问的路径、没有匹配的 glob 模式作为带 Err 的输入返回，由后续任务计为失败，不影响其他文件。
+// 直接给出的文件的 Rel 相对于这些文件的公共父目录，a/x.jpg 和 b/x.jpg 在输出目录中仍保持区分
+func Collect(args []string, recursive bool) ([]Input, error) {
+	var inputs []Input
+	var direct []int // 直接给出的文件在 inputs 中的下标
+	seen := make(map[string]bool)
+
+	add := func(path, root string) {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if seen[abs] {
+			return
+		}
+		seen[abs] = true
+
+		if root == "" {
+			direct = append(direct, len(inputs))
+			inputs = append(inputs, Input{Path: path})
+			return
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || !within(rel) {
+			rel = filepath.Base(path)
+		}
+		inputs = append(inputs, Input{Path: path, Rel: rel})
+	}
+	fail := func(path string, err error) {
+		inputs = append(inputs, Input{Path: path, Rel: filepath.Base(path), Err: err})
+	}
+
+	for _, arg := range args {
+		paths := []string{arg}
+		root := ""
+
+		if hasMeta(arg) {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				fail(arg, fmt.Errorf("无效的匹配模式: %w", err))
+				continue
+			}
+			if len(matches) == 0 {
+				fail(arg, errors.New("没有匹配的文件"))
+				continue
+			}
+			sort.Strings(matches)
+			paths = matches
+			root = globRoot(arg)
+		}
+
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				fail(path, fmt.Errorf("无法访问: %w", err))
+				continue
+			}
+
+			if !info.IsDir() {
+				if root == "" || IsImage(path) {
+					add(path, root)
+				}
+				continue
+			}
+
+			files, err := scanDir(path, recursive)
+			if err != nil {
+				fail(path, err)
+				continue
+			}
+			dirRoot := path
+			if root != "" {
+				dirRoot = root
+			}
+			for _, file := range files {
+				add(file, dirRoot)
+			}
+		}
+	}
+
+	setDirectRel(inputs, direct)
+	if len(inputs) == 0 {
+		return nil, errors.New("没有找到可处理的图像文件")
+	}
+	return inputs, nil
+}
+
+// setDirectRel 将直接给出的文件的 Rel 设为相对于它们公共父目录的路径
+func setDirectRel(inputs []Input, direct []int) {
+	dirs := make([]string, len(direct))
+	for i, idx := range direct {
+		abs, err := filepath.Abs(inputs[idx].Path)
+		if err != nil {
+			abs = inputs[idx].Path
+		}
+		dirs[i] = filepath.Dir(abs)
+	}
+	root := commonDir(dirs)
+	for i, idx := range direct {
+		rel, err := filepath.Rel(root, filepath.Join(dirs[i], filepath.Base(inputs[idx].Path)))
+		if root == "" || err != nil || !within(rel) {
+			rel = filepath.Base(inputs[idx].Path)
+		}
+		inputs[idx].Rel = rel
+	}
+}
+
+// commonDir 返回各目录的公共父目录，不存在（如位于不同的盘符）时返回空字符串
+func commonDir(dirs []string) string {
+	if len(dirs) == 0 {
+		return ""
+	}
+	common := dirs[0]
+	for _, dir := range dirs[1:] {
+		for {
+			if rel, err := filepath.Rel(common, dir); err == nil && within(rel) {
+				break
+			}
+			parent := filepath.Dir(common)
+			if parent == common {
+				return ""
+			}
+			common = parent
+		}
+	}
+	return common
+}
+
+// within 判断相对路径是否位于其基准目录之内
+func within(rel string) bool {
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// scanDir 扫描目录中的图像文件
+func scanDir(dir string, recursive bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsImage(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("无法读取目录 %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// Plan 根据输出选项为每个输入生成处理任务。
+// 多个输入得到同一输出路径时（如格式转换时 x.jpg 和 x.png 都输出为 x.webp）返回错误，不会开始处理
+func Plan(inputs []Input, opts Options) ([]Task, error) {
+	if opts.Output != "" && len(inputs) > 1 {
+		return nil, errors.New("处理多个文件时不能使用 --output，请使用 --output-dir 指定输出目录")
+	}
+
+	tasks := make([]Task, 0, len(inputs))
+	for _, in := range inputs {
+		if in.Err != nil {
+			tasks = append(tasks, Task{Input: in.Path, Err: in.Err})
+			continue
+		}
+		var out string
+		switch {
+		case opts.Output != "":
+			out = opts.rename(opts.Output)
+		case opts.OutputDir != "" || opts.SuffixIfSame:
+			out = in.Path
+			if opts.OutputDir != "" {
+				out = filepath.Join(opts.OutputDir, in.Rel)
+			}
+			// 先修正路径再判断是否覆盖输入，后缀插在扩展名之前，不需要再次修正
+			out = opts.rename(out)
+			if samePath(out, in.Path) {
+				out = AddSuffix(out, opts.Suffix)
+			}
+		default:
+			out = opts.rename(AddSuffix(in.Path, opts.Suffix))
+		}
+		tasks = append(tasks, Task{Input: in.Path, Output: out})
+	}
+	if err := checkConflicts(tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// rename 按 Rename 修正输出路径，每个输出只调用一次
+func (o Options) rename(path string) string {
+	if o.Rename == nil {
+		return path
+	}
+	return o.Rename(path)
+}
+
+// checkConflicts 检查是否有多个任务写入同一输出文件
+func checkConflicts(tasks []Task) error {
+	owners := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		if task.Err != nil {
+			continue
+		}
+		key, err := filepath.Abs(task.Output)
+		if err != nil {
+			key = task.Output
+		}
+		if prev, ok := owners[key]; ok {
+			return fmt.Errorf("输出文件冲突: %s 和 %s 都将写入 %s，请分开处理或使用 --output-dir 指定不同的输出目录", prev, task.Input, task.Output)
+		}
+		owners[key] = task.Input
+	}
+	return nil
+}
+
+// AddSuffix 为文件名添加后缀
+func AddSuffix(path, suffix string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + suffix + ext
+}
+
+// hasMeta 判断路径中是否包含 glob 通配符
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[`)
+}
+
+// globRoot 返回 glob 模式中不含通配符的目录前缀
+func globRoot(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for hasMeta(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// samePath 判断两个路径是否指向同一文件
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return absA == absB
+}
@@ -0,0 +1,154 @@
+package batch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanConflict(t *testing.T) {
+	inputs := []Input{
+		{Path: filepath.Join("photos", "x.jpg"), Rel: "x.jpg"},
+		{Path: filepath.Join("photos", "x.png"), Rel: "x.png"},
+	}
+	toWebP := func(path string) string {
+		return strings.TrimSuffix(path, filepath.Ext(path)) + ".webp"
+	}
+
+	if _, err := Plan(inputs, Options{Suffix: "_converted", SuffixIfSame: true, Rename: toWebP}); err == nil {
+		t.Error("x.jpg 和 x.png 都输出为 x.webp 时应返回错误")
+	}
+	if _, err := Plan(inputs, Options{OutputDir: "out", Rename: toWebP}); err == nil {
+		t.Error("输出目录中的文件冲突时应返回错误")
+	}
+
+	tasks, err := Plan(inputs, Options{Suffix: "_converted", SuffixIfSame: true})
+	if err != nil {
+		t.Fatalf("输出不冲突时返回了错误: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Output == tasks[1].Output {
+		t.Errorf("任务的输出路径不正确: %+v", tasks)
+	}
+}
+
+// touch 创建空文件，Collect 只检查路径，不读取内容
+func touch(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectDirectFiles(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a", "x.jpg"), filepath.Join(dir, "b", "x.jpg")
+	touch(t, a)
+	touch(t, b)
+
+	inputs, err := Collect([]string{a, b}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 2 {
+		t.Fatalf("期望 2 个输入，实际 %d 个", len(inputs))
+	}
+	if inputs[0].Rel != filepath.Join("a", "x.jpg") || inputs[1].Rel != filepath.Join("b", "x.jpg") {
+		t.Errorf("Rel 应相对于公共父目录: %q, %q", inputs[0].Rel, inputs[1].Rel)
+	}
+	if _, err := Plan(inputs, Options{OutputDir: filepath.Join(dir, "out")}); err != nil {
+		t.Errorf("不同目录下的同名文件不应冲突: %v", err)
+	}
+
+	inputs, err = Collect([]string{a}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inputs[0].Rel != "x.jpg" {
+		t.Errorf("单个文件的 Rel 应为文件名，实际 %q", inputs[0].Rel)
+	}
+}
+
+func TestCollectMissing(t *testing.T) {
+	dir := t.TempDir()
+	ok := filepath.Join(dir, "ok.jpg")
+	touch(t, ok)
+	missing := filepath.Join(dir, "missing.jpg")
+
+	inputs, err := Collect([]string{missing, ok, filepath.Join(dir, "*.png")}, false)
+	if err != nil {
+		t.Fatalf("缺少部分文件时不应中断: %v", err)
+	}
+	if len(inputs) != 3 || inputs[0].Err == nil || inputs[1].Err != nil || inputs[2].Err == nil {
+		t.Fatalf("无法访问的路径和没有匹配的模式应记录为失败的输入: %+v", inputs)
+	}
+
+	tasks, err := Plan(inputs, Options{Suffix: "_out"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ran []string
+	summary := (&Pool{Jobs: 1}).Run(context.Background(), tasks, func(ctx context.Context, task Task) error {
+		ran = append(ran, task.Input)
+		return nil
+	})
+	if summary.Succeeded != 1 || summary.Failed != 2 {
+		t.Errorf("期望成功 1 个、失败 2 个，实际成功 %d 个、失败 %d 个", summary.Succeeded, summary.Failed)
+	}
+	if len(ran) != 1 || ran[0] != ok {
+		t.Errorf("只应处理可访问的文件，实际处理了 %v", ran)
+	}
+}
+
+func TestPlanRenameOnce(t *testing.T) {
+	dir := t.TempDir()
+	in := Input{Path: filepath.Join(dir, "x.jpg"), Rel: "x.jpg"}
+	// 不是幂等的修正函数，调用两次会得到 x.v1.v1.jpg
+	calls := 0
+	version := func(path string) string {
+		calls++
+		return AddSuffix(path, ".v1")
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"output", Options{Output: filepath.Join(dir, "y.jpg")}, filepath.Join(dir, "y.v1.jpg")},
+		{"output-dir", Options{OutputDir: filepath.Join(dir, "out")}, filepath.Join(dir, "out", "x.v1.jpg")},
+		{"output-dir-same", Options{OutputDir: dir, Suffix: "_s"}, filepath.Join(dir, "x.v1.jpg")},
+		{"suffix-if-same", Options{Suffix: "_s", SuffixIfSame: true}, filepath.Join(dir, "x.v1.jpg")},
+		{"suffix", Options{Suffix: "_s"}, filepath.Join(dir, "x_s.v1.jpg")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			tt.opts.Rename = version
+			tasks, err := Plan([]Input{in}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if calls != 1 {
+				t.Errorf("Rename 调用了 %d 次，期望 1 次", calls)
+			}
+			if tasks[0].Output != tt.want {
+				t.Errorf("输出为 %s，期望 %s", tasks[0].Output, tt.want)
+			}
+		})
+	}
+
+	// 修正后仍会覆盖输入时追加后缀
+	same := func(path string) string { return path }
+	tasks, err := Plan([]Input{in}, Options{OutputDir: dir, Suffix: "_s", Rename: same})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "x_s.jpg"); tasks[0].Output != want {
+		t.Errorf("输出为 %s，期望 %s", tasks[0].Output, want)
+	}
+}
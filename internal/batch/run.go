@@ -0,0 +1,222 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Result 单个任务的处理结果
+type Result struct {
+	Task     Task
+	Err      error
+	Duration time.Duration // 任务耗时
+}
+
+// Summary 批量处理汇总
+type Summary struct {
+	Results   []Result
+	Succeeded int
+	Failed    int
+	Skipped   int           // 因取消而未执行的任务数
+	Elapsed   time.Duration // 总耗时（墙钟时间）
+	Busy      time.Duration // 所有任务耗时之和
+}
+
+// Pool 并行执行任务的工作池
+type Pool struct {
+	Jobs       int // 并行任务数，<= 0 时使用 CPU 核数
+	MaxDecoded int // 同时驻留内存的解码图像数上限，同时也限制并行任务数（见 Run），<= 0 时等于 Jobs
+
+	mu       sync.Mutex
+	inflight map[string]bool // 正在写入的输出文件
+}
+
+// Run 使用工作池执行所有任务，单个任务失败不会中断后续任务。
+// ctx 取消后不再派发新任务，进行中的任务会执行完毕。
+//
+// 任务从解码到保存一直持有自己的图像，MaxDecoded 通过限制同时执行的任务数来限制内存占用，
+// 因此实际并行数为 Jobs 和 MaxDecoded 中较小的一个，大于 Jobs 的 MaxDecoded 不起作用。
+func (p *Pool) Run(ctx context.Context, tasks []Task, fn func(context.Context, Task) error) *Summary {
+	jobs := p.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs > len(tasks) {
+		jobs = len(tasks)
+	}
+	maxDecoded := p.MaxDecoded
+	if maxDecoded <= 0 || maxDecoded > jobs {
+		maxDecoded = jobs
+	}
+
+	p.mu.Lock()
+	p.inflight = make(map[string]bool)
+	p.mu.Unlock()
+
+	results := make([]Result, len(tasks))
+	// 每个任务从解码到保存持有一张解码后的图像，用信号量限制同时执行的任务数即可控制内存占用
+	slots := make(chan struct{}, maxDecoded)
+	queue := make(chan int)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range queue {
+				results[idx] = p.runTask(ctx, tasks[idx], slots, fn, len(tasks) > 1)
+			}
+		}()
+	}
+
+dispatch:
+	for i := range tasks {
+		select {
+		case <-ctx.Done():
+			for j := i; j < len(tasks); j++ {
+				results[j] = Result{Task: tasks[j], Err: ctx.Err()}
+			}
+			break dispatch
+		case queue <- i:
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	summary := &Summary{Results: results, Elapsed: time.Since(start)}
+	for _, r := range results {
+		summary.Busy += r.Duration
+		switch {
+		case r.Err == nil:
+			summary.Succeeded++
+		case errors.Is(r.Err, context.Canceled):
+			summary.Skipped++
+		default:
+			summary.Failed++
+		}
+	}
+	return summary
+}
+
+// runTask 在获取内存槽位后执行单个任务，收集输入时已失败的任务直接返回其错误
+func (p *Pool) runTask(ctx context.Context, task Task, slots chan struct{}, fn func(context.Context, Task) error, verbose bool) Result {
+	if task.Err != nil {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "❌ %s: %v\n", task.Input, task.Err)
+		}
+		return Result{Task: task, Err: task.Err}
+	}
+
+	select {
+	case <-ctx.Done():
+		return Result{Task: task, Err: ctx.Err()}
+	case slots <- struct{}{}:
+	}
+	defer func() { <-slots }()
+
+	start := time.Now()
+	p.track(task.Output, true)
+	err := prepareOutput(task)
+	if err == nil {
+		err = fn(ctx, task)
+	}
+	p.track(task.Output, false)
+
+	if err != nil && verbose && !errors.Is(err, context.Canceled) {
+		fmt.Fprintf(os.Stderr, "❌ %s: %v\n", task.Input, err)
+	}
+	return Result{Task: task, Err: err, Duration: time.Since(start)}
+}
+
+// track 记录正在写入的输出文件
+func (p *Pool) track(output string, active bool) {
+	if output == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if active {
+		p.inflight[output] = true
+	} else {
+		delete(p.inflight, output)
+	}
+}
+
+// Abort 删除所有正在写入的输出文件，用于强制退出前清理不完整的结果
+func (p *Pool) Abort() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for output := range p.inflight {
+		if err := os.Remove(output); err == nil {
+			fmt.Fprintf(os.Stderr, "🗑️  已删除未完成的输出: %s\n", output)
+		}
+	}
+}
+
+// prepareOutput 确保输出目录存在
+func prepareOutput(task Task) error {
+	if task.Output == "" {
+		return nil
+	}
+	dir := filepath.Dir(task.Output)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("无法创建输出目录: %w", err)
+	}
+	return nil
+}
+
+// Print 输出处理汇总，只有一个任务时不输出
+func (s *Summary) Print() {
+	if len(s.Results) <= 1 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("📊 处理完成: 共 %d 个，成功 %d 个，失败 %d 个", len(s.Results), s.Succeeded, s.Failed)
+	if s.Skipped > 0 {
+		fmt.Printf("，取消 %d 个", s.Skipped)
+	}
+	fmt.Println()
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	done := s.Succeeded + s.Failed
+	fmt.Printf("总耗时:   %s\n", s.Elapsed.Round(time.Millisecond))
+	if done > 0 && s.Elapsed > 0 {
+		fmt.Printf("平均耗时: %s / 张\n", (s.Busy / time.Duration(done)).Round(time.Millisecond))
+		fmt.Printf("吞吐量:   %.2f 张/秒\n", float64(done)/s.Elapsed.Seconds())
+		fmt.Printf("并行加速: %.2fx\n", s.Busy.Seconds()/s.Elapsed.Seconds())
+	}
+
+	if s.Failed == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("失败列表:")
+	for _, r := range s.Results {
+		if r.Err != nil && !errors.Is(r.Err, context.Canceled) {
+			fmt.Printf("  ❌ %s: %v\n", r.Task.Input, r.Err)
+		}
+	}
+}
+
+// Err 存在失败或取消的任务时返回错误
+func (s *Summary) Err() error {
+	if s.Failed == 0 && s.Skipped == 0 {
+		return nil
+	}
+	if len(s.Results) == 1 {
+		return s.Results[0].Err
+	}
+	if s.Failed == 0 {
+		return fmt.Errorf("已取消，%d 个文件未处理", s.Skipped)
+	}
+	return fmt.Errorf("%d 个文件处理失败", s.Failed)
+}
@@ -0,0 +1,46 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolMaxDecoded(t *testing.T) {
+	tasks := make([]Task, 12)
+	for i := range tasks {
+		tasks[i] = Task{Input: fmt.Sprintf("%d.jpg", i)}
+	}
+
+	tests := []struct {
+		jobs, maxDecoded, want int
+	}{
+		{8, 2, 2},
+		{3, 0, 3},
+		{2, 8, 2}, // 大于 Jobs 的 MaxDecoded 不起作用
+	}
+	for _, tt := range tests {
+		var running, peak atomic.Int32
+		pool := &Pool{Jobs: tt.jobs, MaxDecoded: tt.maxDecoded}
+		summary := pool.Run(context.Background(), tasks, func(context.Context, Task) error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+		if summary.Succeeded != len(tasks) {
+			t.Errorf("Jobs=%d MaxDecoded=%d: 成功 %d 个，期望 %d 个", tt.jobs, tt.maxDecoded, summary.Succeeded, len(tasks))
+		}
+		if got := int(peak.Load()); got > tt.want {
+			t.Errorf("Jobs=%d MaxDecoded=%d: 同时执行了 %d 个任务，上限应为 %d 个", tt.jobs, tt.maxDecoded, got, tt.want)
+		}
+	}
+}
@@ -6,12 +6,16 @@ import (
 	"path/filepath"
 
 	"github.com/BurntSushi/toml"
+	"github.com/xiaoheiwowo/xpix/internal/fonts"
 )
 
 // Config 全局配置
 type Config struct {
 	Watermark WatermarkConfig `toml:"watermark"`
+	Frame     FrameConfig     `toml:"frame"`
+	Adjust    AdjustConfig    `toml:"adjust"`
 	Output    OutputConfig    `toml:"output"`
+	Input     InputConfig     `toml:"input"`
 }
 
 // WatermarkConfig 水印配置
@@ -20,13 +24,74 @@ type WatermarkConfig struct {
 	Position string  `toml:"position"`  // 位置: top-left, top-center, top-right, bottom-left, bottom-center, bottom-right, center
 	Opacity  float64 `toml:"opacity"`   // 透明度 0-1
 	Color    string  `toml:"color"`     // 颜色 (hex 格式，如 #FFFFFF)
-	Margin   int     `toml:"margin"`    // 边距（像素）
+	Blend    string  `toml:"blend"`     // 混合模式: normal, multiply, screen, overlay, soft-light, difference
+	Margin   Length  `toml:"margin"`    // 水印与图像边缘的距离：像素（如 40）或图像短边的百分比（如 "3%"）
+	Scale    float64 `toml:"scale"`     // 图片水印的宽度（相对于 scale_by 所指边长的比例）
+	ScaleBy  string  `toml:"scale_by"`  // 图片水印大小的基准: width（图像宽度）, short（图像短边）
+
+	Font          string   `toml:"font"`           // 字体文件路径或字体族名，空表示自动查找
+	FallbackFonts []string `toml:"fallback_fonts"` // 主字体缺少字形时依次使用的后备字体
+
+	StrokeWidth int    `toml:"stroke_width"` // 描边宽度（像素），0 表示不描边
+	StrokeColor string `toml:"stroke_color"` // 描边颜色
+
+	Shadow        bool    `toml:"shadow"`          // 绘制阴影
+	ShadowOffsetX int     `toml:"shadow_offset_x"` // 阴影的水平偏移（像素）
+	ShadowOffsetY int     `toml:"shadow_offset_y"` // 阴影的垂直偏移（像素）
+	ShadowBlur    float64 `toml:"shadow_blur"`     // 阴影的模糊半径（像素）
+	ShadowColor   string  `toml:"shadow_color"`    // 阴影颜色
+
+	Background        bool    `toml:"background"`         // 在文字下方绘制圆角背景底板
+	BackgroundColor   string  `toml:"background_color"`   // 底板颜色
+	BackgroundOpacity float64 `toml:"background_opacity"` // 底板透明度 0-1
+	BackgroundPadding int     `toml:"background_padding"` // 文字与底板边缘的距离（像素）
+	BackgroundRadius  int     `toml:"background_radius"`  // 底板圆角半径（像素）
+
+	Align         string  `toml:"align"`          // 多行文字的对齐方式: left, center, right
+	LineSpacing   float64 `toml:"line_spacing"`   // 行距（行高的倍数）
+	LetterSpacing float64 `toml:"letter_spacing"` // 字间距（像素）
+
+	Tile         bool    `toml:"tile"`           // 平铺水印
+	TileAngle    float64 `toml:"tile_angle"`     // 平铺水印的旋转角度（度，逆时针为正）
+	TileSpacingX int     `toml:"tile_spacing_x"` // 平铺水印的水平间距（像素）
+	TileSpacingY int     `toml:"tile_spacing_y"` // 平铺水印的垂直间距（像素）
+	TileStagger  float64 `toml:"tile_stagger"`   // 相邻行的水平错位（占水平步长的比例 0-1）
+}
+
+// FrameConfig 相框（相机信息边框）配置
+type FrameConfig struct {
+	Layout         string  `toml:"layout"`          // 布局: classic, center, minimal
+	Color          string  `toml:"color"`           // 边框颜色
+	TextColor      string  `toml:"text_color"`      // 主要文字颜色
+	SecondaryColor string  `toml:"secondary_color"` // 次要文字颜色
+	Border         float64 `toml:"border"`          // 上、左、右边框宽度（相对于图像宽度的比例）
+	Bar            float64 `toml:"bar"`             // 底部信息栏高度（相对于图像宽度的比例）
+	Logo           string  `toml:"logo"`            // 品牌 Logo 图片路径，空表示不显示
+	Font           string  `toml:"font"`            // 字体，空表示使用 [watermark] font
+
+	Title    string `toml:"title"`    // 标题（相机）文字模板
+	Subtitle string `toml:"subtitle"` // 副标题（镜头）文字模板
+	Info     string `toml:"info"`     // 拍摄参数文字模板
+	Date     string `toml:"date"`     // 日期文字模板
+}
+
+// AdjustConfig 调色配置
+type AdjustConfig struct {
+	HSL string `toml:"hsl"` // 默认的 HSL 调整，格式同 --hsl，多个范围用 ; 分隔；命令或配方步骤未指定 HSL 时使用
 }
 
 // OutputConfig 输出配置
 type OutputConfig struct {
-	Quality int    `toml:"quality"` // JPEG 质量 (1-100)
-	Format  string `toml:"format"`  // 输出格式: auto, jpeg, png
+	Quality         int    `toml:"quality"`          // JPEG 和有损 WebP 的质量 (1-100)
+	Format          string `toml:"format"`           // 输出格式: auto, jpeg, png, gif, tiff, bmp, webp
+	Lossless        bool   `toml:"lossless"`         // WebP 使用无损压缩
+	TIFFCompression string `toml:"tiff_compression"` // TIFF 压缩方式: deflate, none
+	StripMetadata   bool   `toml:"strip_metadata"`   // 不保留输入文件的 EXIF、XMP 和 ICC 元数据
+}
+
+// InputConfig 输入配置
+type InputConfig struct {
+	AutoOrient bool `toml:"auto_orient"` // 按 EXIF 方向标签自动摆正图像
 }
 
 var (
@@ -44,11 +109,66 @@ func DefaultConfig() *Config {
 			Position: "bottom-center",
 			Opacity:  0.7,
 			Color:    "#FFFFFF",
-			Margin:   160,
+			Blend:    "normal",
+			Margin:   Length{Value: 160},
+			Scale:    0.2,
+			ScaleBy:  "width",
+
+			Font:          "",
+			FallbackFonts: fonts.DefaultFallbacks,
+
+			StrokeWidth: 0,
+			StrokeColor: "#000000",
+
+			Shadow:        false,
+			ShadowOffsetX: 2,
+			ShadowOffsetY: 2,
+			ShadowBlur:    2,
+			ShadowColor:   "#000000",
+
+			Background:        false,
+			BackgroundColor:   "#000000",
+			BackgroundOpacity: 0.5,
+			BackgroundPadding: 10,
+			BackgroundRadius:  8,
+
+			Align:         "center",
+			LineSpacing:   1.2,
+			LetterSpacing: 0,
+
+			Tile:         false,
+			TileAngle:    30,
+			TileSpacingX: 100,
+			TileSpacingY: 100,
+			TileStagger:  0.5,
+		},
+		Frame: FrameConfig{
+			Layout:         "classic",
+			Color:          "#FFFFFF",
+			TextColor:      "#222222",
+			SecondaryColor: "#888888",
+			Border:         0.03,
+			Bar:            0.12,
+			Logo:           "",
+			Font:           "",
+
+			Title:    "{Camera}",
+			Subtitle: "{LensModel}",
+			Info:     "{FocalLength}mm f/{FNumber} {ExposureTime}s ISO{ISO}",
+			Date:     "{DateTime:2006.01.02 15:04}",
+		},
+		Adjust: AdjustConfig{
+			HSL: "",
 		},
 		Output: OutputConfig{
-			Quality: 95,
-			Format:  "auto",
+			Quality:         95,
+			Format:          "auto",
+			Lossless:        false,
+			TIFFCompression: "deflate",
+			StripMetadata:   false,
+		},
+		Input: InputConfig{
+			AutoOrient: true,
 		},
 	}
 }
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Length 长度：像素值，或相对于图像短边的百分比。
+// 配置文件中写作数字（如 margin = 40）或带 % 的字符串（如 margin = "3%"）。
+type Length struct {
+	Value   float64 // 像素值或百分比
+	Percent bool    // Value 是否为百分比
+}
+
+// Pixels 返回长度对应的像素数，百分比相对于 width 和 height 中较短的一边
+func (l Length) Pixels(width, height int) int {
+	if !l.Percent {
+		return int(math.Round(l.Value))
+	}
+	return int(math.Round(l.Value / 100 * float64(min(width, height))))
+}
+
+// String 返回长度的文字形式，如 "40" 或 "3%"
+func (l Length) String() string {
+	s := strconv.FormatFloat(l.Value, 'f', -1, 64)
+	if l.Percent {
+		s += "%"
+	}
+	return s
+}
+
+// ParseLength 解析像素值（如 "40"、"40px"）或百分比（如 "3%"）
+func ParseLength(s string) (Length, error) {
+	num := strings.TrimSpace(s)
+	var l Length
+	switch {
+	case strings.HasSuffix(num, "%"):
+		num, l.Percent = strings.TrimSpace(strings.TrimSuffix(num, "%")), true
+	case strings.HasSuffix(num, "px"):
+		num = strings.TrimSpace(strings.TrimSuffix(num, "px"))
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		// 错误信息引用原始输入，而不是去掉单位后的数字
+		return Length{}, fmt.Errorf("无效的长度 %q（需要非负的像素值如 40，或百分比如 3%%）", s)
+	}
+	l.Value = v
+	return l, nil
+}
+
+// UnmarshalTOML 接受数字（像素）或字符串（像素或百分比）
+func (l *Length) UnmarshalTOML(v interface{}) error {
+	switch v := v.(type) {
+	case int64:
+		return l.set(strconv.FormatInt(v, 10))
+	case float64:
+		return l.set(strconv.FormatFloat(v, 'f', -1, 64))
+	case string:
+		return l.set(v)
+	default:
+		return fmt.Errorf("长度必须是数字或字符串，得到 %T", v)
+	}
+}
+
+func (l *Length) set(s string) error {
+	parsed, err := ParseLength(s)
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+// MarshalTOML 像素值写作数字，百分比写作字符串
+func (l Length) MarshalTOML() ([]byte, error) {
+	if l.Percent {
+		return []byte(strconv.Quote(l.String())), nil
+	}
+	return []byte(l.String()), nil
+}
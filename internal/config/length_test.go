@@ -0,0 +1,36 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLength(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Length
+	}{
+		{"40", Length{Value: 40}},
+		{" 40px ", Length{Value: 40}},
+		{"3%", Length{Value: 3, Percent: true}},
+		{"2.5 %", Length{Value: 2.5, Percent: true}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLength(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLength(%q) = %v, %v，期望 %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	// 错误信息应引用原始输入
+	for _, in := range []string{"-3%", "-40px", "abc", "%"} {
+		_, err := ParseLength(in)
+		if err == nil {
+			t.Errorf("ParseLength(%q) 应返回错误", in)
+			continue
+		}
+		if !strings.Contains(err.Error(), `"`+in+`"`) {
+			t.Errorf("ParseLength(%q) 的错误信息没有引用原始输入: %v", in, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package fonts
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// FindPrimary 查找主字体。
+//
+// explicit 为显式指定的字体（如 --font），找不到时返回错误；为空时使用配置的字体
+// configured，配置的字体找不到时改用 DefaultFamilies，并在 warning 中说明原因，
+// 以免配置文件在另一台机器上因缺少字体而无法使用。两者都为空时直接尝试 DefaultFamilies。
+func FindPrimary(explicit, configured string) (loc Location, warning string, err error) {
+	if explicit != "" {
+		loc, err = Find(explicit)
+		return loc, "", err
+	}
+	if configured != "" {
+		if loc, err = Find(configured); err == nil {
+			return loc, "", nil
+		}
+		warning = fmt.Sprintf("配置的字体不可用（%v），改用默认字体", err)
+	}
+	for _, spec := range DefaultFamilies {
+		if loc, err = Find(spec); err == nil {
+			return loc, warning, nil
+		}
+	}
+	return Location{}, warning, fmt.Errorf("%w: 未找到任何默认字体（%s），请使用 --font 或配置 [watermark] font 指定字体", ErrNotFound, strings.Join(DefaultFamilies, ", "))
+}
+
+// NewFace 创建指定大小（磅，72 DPI 下等于像素）的字体外观。
+//
+// primary 为主字体（见 FindPrimary），无法解析时返回错误；fallbacks 为后备字体，
+// 主字体中缺少的字形（如中日韩文字、符号）依次从后备字体中查找，不存在的后备字体
+// 会被跳过。返回的外观不能在多个 goroutine 间共享。
+func NewFace(loc Location, fallbacks []string, size float64) (font.Face, error) {
+	f, err := Load(loc)
+	if err != nil {
+		return nil, err
+	}
+
+	face := &fallbackFace{}
+	if err := face.add(f, size); err != nil {
+		return nil, err
+	}
+	for _, spec := range fallbacks {
+		loc, err := Find(spec)
+		if err != nil {
+			continue
+		}
+		f, err := Load(loc)
+		if err != nil {
+			continue
+		}
+		face.add(f, size)
+	}
+	return face, nil
+}
+
+// fallbackFace 按顺序组合多个字体，每个字符使用第一个包含该字形的字体
+type fallbackFace struct {
+	fonts []*sfnt.Font
+	faces []font.Face
+	buf   sfnt.Buffer
+}
+
+func (f *fallbackFace) add(ft *sfnt.Font, size float64) error {
+	for _, existing := range f.fonts {
+		if existing == ft {
+			return nil
+		}
+	}
+	face, err := opentype.NewFace(ft, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingNone})
+	if err != nil {
+		return fmt.Errorf("无法创建字体外观: %w", err)
+	}
+	f.fonts = append(f.fonts, ft)
+	f.faces = append(f.faces, face)
+	return nil
+}
+
+// pick 返回包含字符 r 的第一个字体的序号，都不包含时使用主字体
+func (f *fallbackFace) pick(r rune) int {
+	for i, ft := range f.fonts {
+		if x, err := ft.GlyphIndex(&f.buf, r); err == nil && x != 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+func (f *fallbackFace) Close() error {
+	for _, face := range f.faces {
+		face.Close()
+	}
+	return nil
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.faces[f.pick(r)].Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.faces[f.pick(r)].GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.faces[f.pick(r)].GlyphAdvance(r)
+}
+
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	i := f.pick(r0)
+	if f.pick(r1) != i {
+		return 0
+	}
+	return f.faces[i].Kern(r0, r1)
+}
+
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
@@ -0,0 +1,297 @@
+// Package fonts 查找和加载文字水印使用的字体。
+//
+// 字体可以用文件路径或字体族名指定。按族名查找时扫描各平台的标准字体目录
+// （XDG 数据目录、/usr/share/fonts、~/.local/share/fonts、macOS 和
+// Windows 的系统字体目录），读取字体的 name 表进行匹配。
+package fonts
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// ErrNotFound 找不到指定的字体
+var ErrNotFound = errors.New("找不到字体")
+
+// DefaultFamilies 未指定字体时依次尝试的字体
+var DefaultFamilies = []string{
+	"~/Library/Fonts/CaskaydiaMonoNerdFont-Regular.ttf",
+	"Noto Sans",
+	"DejaVu Sans",
+	"Liberation Sans",
+	"Helvetica",
+	"Arial",
+}
+
+// DefaultFallbacks 默认的后备字体链，覆盖中日韩文字和符号
+var DefaultFallbacks = []string{
+	"Noto Sans CJK SC",
+	"Source Han Sans SC",
+	"WenQuanYi Micro Hei",
+	"PingFang SC",
+	"Microsoft YaHei",
+	"Noto Emoji",
+	"Segoe UI Emoji",
+	"Symbola",
+	"DejaVu Sans",
+}
+
+// fontExts 识别为字体文件的扩展名
+var fontExts = map[string]bool{
+	".ttf": true,
+	".otf": true,
+	".ttc": true,
+	".otc": true,
+}
+
+// Location 字体在磁盘上的位置
+type Location struct {
+	Path  string // 字体文件路径
+	Index int    // 字体集合（.ttc）中的序号
+}
+
+// entry 扫描字体目录得到的字体
+type entry struct {
+	Location
+	family   string // 规范化后的字体族名
+	typo     string // 规范化后的排版族名（name ID 16）
+	full     string // 规范化后的全名
+	style    string // 规范化后的样式名
+	basename string // 规范化后的文件名（不含扩展名）
+}
+
+var (
+	scanOnce sync.Once
+	scanned  []entry
+
+	loadMu sync.Mutex
+	loaded = make(map[Location]*sfnt.Font)
+)
+
+// Dirs 返回按优先级排列的字体搜索目录（用户目录优先）
+func Dirs() []string {
+	var dirs []string
+	home, _ := os.UserHomeDir()
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" && home != "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	if dataHome != "" {
+		dirs = append(dirs, filepath.Join(dataHome, "fonts"))
+	}
+	if home != "" {
+		dirs = append(dirs, filepath.Join(home, ".fonts"), filepath.Join(home, "Library", "Fonts"))
+	}
+
+	dataDirs := os.Getenv("XDG_DATA_DIRS")
+	if dataDirs == "" {
+		dataDirs = "/usr/local/share:/usr/share"
+	}
+	for _, dir := range filepath.SplitList(dataDirs) {
+		if dir != "" {
+			dirs = append(dirs, filepath.Join(dir, "fonts"))
+		}
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		dirs = append(dirs, "/Library/Fonts", "/System/Library/Fonts")
+	case "windows":
+		if windir := os.Getenv("WINDIR"); windir != "" {
+			dirs = append(dirs, filepath.Join(windir, "Fonts"))
+		}
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			dirs = append(dirs, filepath.Join(local, "Microsoft", "Windows", "Fonts"))
+		}
+	}
+	return dedupe(dirs)
+}
+
+// Find 按文件路径或字体族名查找字体。
+// 族名匹配时忽略大小写、空格、连字符和下划线，同一族中优先选择常规样式。
+func Find(spec string) (Location, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Location{}, fmt.Errorf("%w: 字体名称为空", ErrNotFound)
+	}
+
+	if path, ok := asPath(spec); ok {
+		if _, err := os.Stat(path); err != nil {
+			return Location{}, fmt.Errorf("%w: %s: %v", ErrNotFound, spec, err)
+		}
+		return Location{Path: path}, nil
+	}
+
+	name := normalize(spec)
+	best, bestScore := Location{}, 0
+	for _, e := range scan() {
+		score := 0
+		switch {
+		case e.full == name || e.basename == name:
+			score = 3
+		case (e.family == name || e.typo == name) && isRegular(e.style):
+			score = 2
+		case e.family == name || e.typo == name:
+			score = 1
+		}
+		if score > bestScore {
+			best, bestScore = e.Location, score
+		}
+	}
+	if bestScore == 0 {
+		return Location{}, fmt.Errorf("%w: %s（已搜索: %s）", ErrNotFound, spec, strings.Join(Dirs(), ", "))
+	}
+	return best, nil
+}
+
+// Load 加载并缓存字体，返回的字体可被多个 goroutine 共享（各自使用独立的 sfnt.Buffer）
+func Load(loc Location) (*sfnt.Font, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	if f, ok := loaded[loc]; ok {
+		return f, nil
+	}
+
+	data, err := os.ReadFile(loc.Path)
+	if err != nil {
+		return nil, err
+	}
+	var f *sfnt.Font
+	if isCollection(loc.Path) {
+		c, err := sfnt.ParseCollection(data)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析字体 %s: %w", loc.Path, err)
+		}
+		if f, err = c.Font(loc.Index); err != nil {
+			return nil, fmt.Errorf("无法解析字体 %s: %w", loc.Path, err)
+		}
+	} else if f, err = sfnt.Parse(data); err != nil {
+		return nil, fmt.Errorf("无法解析字体 %s: %w", loc.Path, err)
+	}
+
+	loaded[loc] = f
+	return f, nil
+}
+
+// scan 扫描所有字体目录并读取字体名称，结果在进程内缓存
+func scan() []entry {
+	scanOnce.Do(func() {
+		for _, dir := range Dirs() {
+			filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() || !fontExts[strings.ToLower(filepath.Ext(path))] {
+					return nil
+				}
+				scanned = append(scanned, readEntries(path)...)
+				return nil
+			})
+		}
+	})
+	return scanned
+}
+
+// readEntries 读取字体文件（或字体集合中每个字体）的名称
+func readEntries(path string) []entry {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var fonts []*sfnt.Font
+	if isCollection(path) {
+		c, err := sfnt.ParseCollectionReaderAt(file)
+		if err != nil {
+			return nil
+		}
+		for i := 0; i < c.NumFonts(); i++ {
+			f, err := c.Font(i)
+			if err != nil {
+				return nil
+			}
+			fonts = append(fonts, f)
+		}
+	} else {
+		f, err := sfnt.ParseReaderAt(file)
+		if err != nil {
+			return nil
+		}
+		fonts = append(fonts, f)
+	}
+
+	base := normalize(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	var buf sfnt.Buffer
+	name := func(f *sfnt.Font, id sfnt.NameID) string {
+		s, _ := f.Name(&buf, id)
+		return normalize(s)
+	}
+	entries := make([]entry, 0, len(fonts))
+	for i, f := range fonts {
+		entries = append(entries, entry{
+			Location: Location{Path: path, Index: i},
+			family:   name(f, sfnt.NameIDFamily),
+			typo:     name(f, sfnt.NameIDTypographicFamily),
+			full:     name(f, sfnt.NameIDFull),
+			style:    name(f, sfnt.NameIDSubfamily),
+			basename: base,
+		})
+	}
+	return entries
+}
+
+// asPath 判断 spec 是否为文件路径（包含路径分隔符或以字体扩展名结尾），并展开 ~
+func asPath(spec string) (string, bool) {
+	if strings.HasPrefix(spec, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, spec[2:]), true
+		}
+	}
+	if strings.ContainsAny(spec, `/\`) || fontExts[strings.ToLower(filepath.Ext(spec))] {
+		return spec, true
+	}
+	return "", false
+}
+
+func isCollection(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".ttc" || ext == ".otc"
+}
+
+func isRegular(style string) bool {
+	switch style {
+	case "", "regular", "book", "normal", "roman", "medium":
+		return true
+	}
+	return false
+}
+
+// normalize 将字体名称转换为小写并去掉空格、连字符和下划线
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(s)))
+}
+
+func dedupe(dirs []string) []string {
+	seen := make(map[string]bool, len(dirs))
+	out := dirs[:0]
+	for _, d := range dirs {
+		if !seen[d] {
+			seen[d] = true
+			out = append(out, d)
+		}
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package fonts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// writeFonts 将 Go 字体写入临时目录，返回 goregular 和 gomono 的路径
+func writeFonts(t *testing.T) (regular, mono string) {
+	t.Helper()
+	dir := t.TempDir()
+	regular, mono = filepath.Join(dir, "goregular.ttf"), filepath.Join(dir, "gomono.ttf")
+	for path, data := range map[string][]byte{regular: goregular.TTF, mono: gomono.TTF} {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return regular, mono
+}
+
+// withDefaults 在测试期间替换 DefaultFamilies
+func withDefaults(t *testing.T, families ...string) {
+	t.Helper()
+	saved := DefaultFamilies
+	DefaultFamilies = families
+	t.Cleanup(func() { DefaultFamilies = saved })
+}
+
+func TestFindPrimary(t *testing.T) {
+	regular, mono := writeFonts(t)
+	missing := filepath.Join(t.TempDir(), "missing.ttf")
+	withDefaults(t, filepath.Join(t.TempDir(), "also-missing.ttf"), regular)
+
+	tests := []struct {
+		name       string
+		explicit   string
+		configured string
+		want       string
+		warning    bool
+		err        bool
+	}{
+		{"explicit", mono, missing, mono, false, false},
+		{"explicit-missing", missing, mono, "", false, true},
+		{"configured", "", mono, mono, false, false},
+		{"configured-missing", "", missing, regular, true, false},
+		{"defaults", "", "", regular, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, warning, err := FindPrimary(tt.explicit, tt.configured)
+			if tt.err {
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("期望 ErrNotFound，实际 %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindPrimary 失败: %v", err)
+			}
+			if loc.Path != tt.want {
+				t.Errorf("选择了 %s，期望 %s", loc.Path, tt.want)
+			}
+			if got := warning != ""; got != tt.warning {
+				t.Errorf("警告为 %q，期望有警告=%t", warning, tt.warning)
+			}
+			if tt.warning && !strings.Contains(warning, "missing.ttf") {
+				t.Errorf("警告 %q 中应包含找不到的字体", warning)
+			}
+		})
+	}
+}
+
+func TestFindPrimaryNoDefaults(t *testing.T) {
+	withDefaults(t, filepath.Join(t.TempDir(), "missing.ttf"))
+	_, warning, err := FindPrimary("", filepath.Join(t.TempDir(), "configured.ttf"))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("默认字体也找不到时期望 ErrNotFound，实际 %v", err)
+	}
+	if warning == "" {
+		t.Error("配置的字体找不到时应有警告")
+	}
+}
+
+func TestNewFaceFallbacks(t *testing.T) {
+	regular, mono := writeFonts(t)
+	missing := filepath.Join(t.TempDir(), "missing.ttf")
+
+	// 不存在的后备字体被跳过，与主字体相同的后备字体不重复加入
+	face, err := NewFace(Location{Path: mono}, []string{missing, mono, regular, "No Such Font Family"}, 12)
+	if err != nil {
+		t.Fatalf("NewFace 失败: %v", err)
+	}
+	defer face.Close()
+	f := face.(*fallbackFace)
+	if len(f.faces) != 2 {
+		t.Fatalf("字体链中有 %d 个字体，期望主字体和 goregular 共 2 个", len(f.faces))
+	}
+
+	// 主字体包含的字形使用主字体，都不包含时也使用主字体
+	for _, r := range []rune{'A', '中'} {
+		if i := f.pick(r); i != 0 {
+			t.Errorf("%q 使用了第 %d 个字体，期望主字体", r, i)
+		}
+	}
+	if _, ok := face.GlyphAdvance('A'); !ok {
+		t.Error("主字体应包含 A")
+	}
+
+	if _, err := NewFace(Location{Path: missing}, nil, 12); err == nil {
+		t.Error("主字体无法加载时应返回错误")
+	}
+}
@@ -0,0 +1,228 @@
+package metadata
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// EXIF 标签
+const (
+	tagImageWidth      = 0x0100
+	tagImageLength     = 0x0101
+	tagOrientation     = 0x0112
+	tagSoftware        = 0x0131
+	tagExifIFD         = 0x8769
+	tagPixelXDimension = 0xa002
+	tagPixelYDimension = 0xa003
+)
+
+// TIFF 字段类型
+const (
+	typeASCII = 2
+	typeShort = 3
+	typeLong  = 4
+)
+
+var errInvalidEXIF = errors.New("无效的 EXIF 数据")
+
+// byteOrder TIFF 数据的字节序
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// Update 写回 EXIF 时要修正的标签，零值表示保持原值
+type Update struct {
+	Width, Height int    // 输出图像的像素尺寸
+	Orientation   int    // 方向 (1-8)
+	Software      string // 处理软件
+}
+
+// UpdateEXIF 返回修正了尺寸、方向和软件标签的 EXIF 数据。
+//
+// 为了不破坏厂商私有数据（MakerNote）中的绝对偏移，已有的标签都在原位修改：
+// 尺寸改写为条目内的 LONG 值，软件名写回原字符串所在的位置。只有需要增加标签，
+// 或原字符串放不下新的软件名时，才把 IFD0 复制一份追加到末尾，在副本中修改后让文件头指向它。
+// 追加过一次后标签都已存在，因此对结果重复调用不会使数据继续变大。
+func UpdateEXIF(data []byte, u Update) ([]byte, error) {
+	if len(data) < 8 {
+		return nil, errInvalidEXIF
+	}
+	var bo byteOrder
+	switch string(data[:4]) {
+	case "II*\x00":
+		bo = binary.LittleEndian
+	case "MM\x00*":
+		bo = binary.BigEndian
+	default:
+		return nil, errInvalidEXIF
+	}
+
+	out := clone(data)
+	ifd0 := bo.Uint32(out[4:])
+	entries, next, err := readIFD(out, bo, ifd0)
+	if err != nil {
+		return nil, err
+	}
+
+	// 原位修正 ExifIFD 中的像素尺寸
+	if e, ok := entries[tagExifIFD]; ok && (u.Width > 0 || u.Height > 0) {
+		offset := int(bo.Uint32(e[8:]))
+		if _, _, err := readIFD(out, bo, uint32(offset)); err != nil {
+			return nil, err
+		}
+		for i := 0; i < int(bo.Uint16(out[offset:])); i++ {
+			p := out[offset+2+12*i:]
+			switch tag := bo.Uint16(p); {
+			case tag == tagPixelXDimension && u.Width > 0:
+				putLong(p, bo, tag, uint32(u.Width))
+			case tag == tagPixelYDimension && u.Height > 0:
+				putLong(p, bo, tag, uint32(u.Height))
+			}
+		}
+	}
+
+	// 原位修正 IFD0 中已有的标签，无法原位修改的条目留到追加的副本中
+	entryAt := func(tag uint16) []byte {
+		for i := 0; i < int(bo.Uint16(out[ifd0:])); i++ {
+			if p := out[int(ifd0)+2+12*i:]; bo.Uint16(p) == tag {
+				return p[:12]
+			}
+		}
+		return nil
+	}
+	pending := make(map[uint16][12]byte)
+	if p := entryAt(tagImageWidth); p != nil && u.Width > 0 {
+		putLong(p, bo, tagImageWidth, uint32(u.Width))
+	}
+	if p := entryAt(tagImageLength); p != nil && u.Height > 0 {
+		putLong(p, bo, tagImageLength, uint32(u.Height))
+	}
+	if u.Orientation > 0 {
+		if p := entryAt(tagOrientation); p != nil {
+			putShort(p, bo, tagOrientation, uint16(u.Orientation))
+		} else if u.Orientation != 1 {
+			// 没有方向标签等同于 1，只有其他方向才需要增加
+			var e [12]byte
+			putShort(e[:], bo, tagOrientation, uint16(u.Orientation))
+			pending[tagOrientation] = e
+		}
+	}
+	var software []byte
+	if u.Software != "" {
+		value := append([]byte(u.Software), 0)
+		if p := entryAt(tagSoftware); p == nil || !putASCII(out, p, bo, value) {
+			software = value
+		}
+	}
+	if len(pending) == 0 && software == nil {
+		return out, nil
+	}
+
+	// 追加 IFD0 的副本
+	entries, _, _ = readIFD(out, bo, ifd0)
+	for tag, e := range pending {
+		entries[tag] = e
+	}
+	if len(out)%2 == 1 {
+		out = append(out, 0)
+	}
+	ifdOffset := len(out)
+	n := len(entries)
+	if _, ok := entries[tagSoftware]; !ok && software != nil {
+		n++
+	}
+	ifdSize := 2 + 12*n + 4
+
+	var extra []byte
+	if software != nil {
+		var e [12]byte
+		bo.PutUint16(e[0:], tagSoftware)
+		bo.PutUint16(e[2:], typeASCII)
+		bo.PutUint32(e[4:], uint32(len(software)))
+		if len(software) <= 4 {
+			copy(e[8:], software)
+		} else {
+			bo.PutUint32(e[8:], uint32(ifdOffset+ifdSize))
+			extra = software
+		}
+		entries[tagSoftware] = e
+	}
+
+	tags := make([]int, 0, len(entries))
+	for tag := range entries {
+		tags = append(tags, int(tag))
+	}
+	sort.Ints(tags)
+
+	out = bo.AppendUint16(out, uint16(len(tags)))
+	for _, tag := range tags {
+		e := entries[uint16(tag)]
+		out = append(out, e[:]...)
+	}
+	out = bo.AppendUint32(out, next)
+	out = append(out, extra...)
+	bo.PutUint32(out[4:], uint32(ifdOffset))
+	return out, nil
+}
+
+// readIFD 读取 IFD 的全部条目，返回按标签索引的条目和下一个 IFD 的偏移
+func readIFD(data []byte, bo binary.ByteOrder, offset uint32) (map[uint16][12]byte, uint32, error) {
+	if int64(offset)+2 > int64(len(data)) {
+		return nil, 0, errInvalidEXIF
+	}
+	n := int(bo.Uint16(data[offset:]))
+	end := int(offset) + 2 + 12*n
+	if end+4 > len(data) {
+		return nil, 0, errInvalidEXIF
+	}
+	entries := make(map[uint16][12]byte, n)
+	for i := 0; i < n; i++ {
+		var e [12]byte
+		copy(e[:], data[int(offset)+2+12*i:])
+		entries[bo.Uint16(e[:])] = e
+	}
+	return entries, bo.Uint32(data[end:]), nil
+}
+
+// putLong 将条目改写为单个 LONG 值
+func putLong(e []byte, bo binary.ByteOrder, tag uint16, v uint32) {
+	bo.PutUint16(e[0:], tag)
+	bo.PutUint16(e[2:], typeLong)
+	bo.PutUint32(e[4:], 1)
+	bo.PutUint32(e[8:], v)
+}
+
+// putShort 将条目改写为单个 SHORT 值
+func putShort(e []byte, bo binary.ByteOrder, tag, v uint16) {
+	bo.PutUint16(e[0:], tag)
+	bo.PutUint16(e[2:], typeShort)
+	bo.PutUint32(e[4:], 1)
+	bo.PutUint32(e[8:], 0)
+	bo.PutUint16(e[8:], v)
+}
+
+// putASCII 将字符串 value（含结尾的 0）写回 ASCII 条目 e 原来的位置，
+// 原位置放不下时返回 false 且不做修改
+func putASCII(data, e []byte, bo binary.ByteOrder, value []byte) bool {
+	if bo.Uint16(e[2:]) != typeASCII {
+		return false
+	}
+	count := int(bo.Uint32(e[4:]))
+	if len(value) <= 4 {
+		// 4 字节以内的值直接存放在条目中
+		clear(e[8:12])
+		copy(e[8:], value)
+		bo.PutUint32(e[4:], uint32(len(value)))
+		return true
+	}
+	offset := int(bo.Uint32(e[8:]))
+	if count < len(value) || offset+count > len(data) {
+		return false
+	}
+	clear(data[offset : offset+count])
+	copy(data[offset:], value)
+	bo.PutUint32(e[4:], uint32(len(value)))
+	return true
+}
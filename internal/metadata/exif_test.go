@@ -0,0 +1,134 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// testEXIF 构造一段小端序的 EXIF：IFD0 含宽、高、软件名和 ExifIFD 指针，
+// ExifIFD 含像素尺寸，末尾附加一段模拟 MakerNote 的私有数据
+func testEXIF() []byte {
+	bo := binary.LittleEndian
+	const (
+		ifd0     = 8
+		ifd0Size = 2 + 12*4 + 4
+		software = ifd0 + ifd0Size
+		exifIFD  = software + 12
+	)
+	var b []byte
+	b = append(b, "II*\x00"...)
+	b = bo.AppendUint32(b, ifd0)
+
+	entry := func(tag, typ uint16, count, value uint32) {
+		b = bo.AppendUint16(b, tag)
+		b = bo.AppendUint16(b, typ)
+		b = bo.AppendUint32(b, count)
+		b = bo.AppendUint32(b, value)
+	}
+	b = bo.AppendUint16(b, 4)
+	entry(tagImageWidth, typeLong, 1, 4000)
+	entry(tagImageLength, typeLong, 1, 3000)
+	entry(tagSoftware, typeASCII, 12, software)
+	entry(tagExifIFD, typeLong, 1, exifIFD)
+	b = bo.AppendUint32(b, 0)
+	b = append(b, "Camera v1.0\x00"...)
+
+	b = bo.AppendUint16(b, 2)
+	entry(tagPixelXDimension, typeLong, 1, 4000)
+	entry(tagPixelYDimension, typeLong, 1, 3000)
+	b = bo.AppendUint32(b, 0)
+	return append(b, "MAKERNOTE"...)
+}
+
+// exifValues 读取 IFD0 和 ExifIFD 中的尺寸、方向和软件名
+func exifValues(t *testing.T, data []byte) (w, h, px, py, orientation uint32, software string) {
+	t.Helper()
+	bo := binary.LittleEndian
+	ifd0, _, err := readIFD(data, bo, bo.Uint32(data[4:]))
+	if err != nil {
+		t.Fatalf("无法读取 IFD0: %v", err)
+	}
+	long := func(ifd map[uint16][12]byte, tag uint16) uint32 {
+		e := ifd[tag]
+		return bo.Uint32(e[8:])
+	}
+	exif, _, err := readIFD(data, bo, long(ifd0, tagExifIFD))
+	if err != nil {
+		t.Fatalf("无法读取 ExifIFD: %v", err)
+	}
+	if e, ok := ifd0[tagOrientation]; ok {
+		orientation = uint32(bo.Uint16(e[8:]))
+	}
+	e := ifd0[tagSoftware]
+	value := e[8:]
+	if n := bo.Uint32(e[4:]); n > 4 {
+		offset := bo.Uint32(e[8:])
+		value = data[offset : offset+n]
+	}
+	software = strings.TrimRight(string(value), "\x00")
+	return long(ifd0, tagImageWidth), long(ifd0, tagImageLength),
+		long(exif, tagPixelXDimension), long(exif, tagPixelYDimension), orientation, software
+}
+
+func TestUpdateEXIFInPlace(t *testing.T) {
+	data := testEXIF()
+	out := data
+	// 与 ProcessFile 相同，先重置方向再修正尺寸和软件名，重复调用不应使数据变大
+	for i := 0; i < 3; i++ {
+		var err error
+		if out, err = UpdateEXIF(out, Update{Orientation: 1}); err != nil {
+			t.Fatalf("UpdateEXIF 失败: %v", err)
+		}
+		if out, err = UpdateEXIF(out, Update{Width: 800, Height: 600, Software: "xpix"}); err != nil {
+			t.Fatalf("UpdateEXIF 失败: %v", err)
+		}
+	}
+	if len(out) != len(data) {
+		t.Errorf("数据长度从 %d 变为 %d", len(data), len(out))
+	}
+	if !bytes.HasSuffix(out, []byte("MAKERNOTE")) {
+		t.Error("私有数据被移动")
+	}
+	w, h, px, py, orientation, software := exifValues(t, out)
+	if w != 800 || h != 600 || px != 800 || py != 600 {
+		t.Errorf("尺寸为 IFD0 %dx%d、ExifIFD %dx%d，期望 800x600", w, h, px, py)
+	}
+	if orientation != 0 {
+		t.Errorf("不应增加方向标签，实际为 %d", orientation)
+	}
+	if software != "xpix" {
+		t.Errorf("软件名为 %q，期望 xpix", software)
+	}
+	if bytes.Contains(out, []byte("Camera")) {
+		t.Error("原软件名没有被覆盖")
+	}
+}
+
+func TestUpdateEXIFAppend(t *testing.T) {
+	data := testEXIF()
+	update := Update{Orientation: 6, Software: "a software name longer than the original"}
+	once, err := UpdateEXIF(data, update)
+	if err != nil {
+		t.Fatalf("UpdateEXIF 失败: %v", err)
+	}
+	if len(once) <= len(data) {
+		t.Fatal("增加标签时应追加 IFD0 副本")
+	}
+	if !bytes.Equal(once[8:len(data)], data[8:]) {
+		t.Error("追加副本时原有数据被修改")
+	}
+
+	twice, err := UpdateEXIF(once, update)
+	if err != nil {
+		t.Fatalf("UpdateEXIF 失败: %v", err)
+	}
+	if len(twice) != len(once) {
+		t.Errorf("第二次调用使数据长度从 %d 变为 %d", len(once), len(twice))
+	}
+	_, _, _, _, orientation, software := exifValues(t, twice)
+	if orientation != 6 || software != update.Software {
+		t.Errorf("方向为 %d、软件名为 %q，期望 6 和 %q", orientation, software, update.Software)
+	}
+}
@@ -0,0 +1,125 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+const (
+	markerSOS  = 0xda
+	markerAPP1 = 0xe1
+	markerAPP2 = 0xe2
+
+	// maxSegment JPEG 段的最大长度（含 2 字节长度字段）
+	maxSegment = 0xffff
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	xmpHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	iccHeader  = []byte("ICC_PROFILE\x00")
+)
+
+// readJPEG 扫描 SOS 之前的 APPn 段
+func readJPEG(data []byte) *Metadata {
+	m := &Metadata{}
+	type iccChunk struct {
+		seq  byte
+		data []byte
+	}
+	var icc []iccChunk
+
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xff {
+			break
+		}
+		marker := data[pos+1]
+		if marker == 0xff { // 填充字节
+			pos++
+			continue
+		}
+		if marker == markerSOS || marker == 0xd9 {
+			break
+		}
+		n := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if n < 2 || pos+2+n > len(data) {
+			break
+		}
+		payload := data[pos+4 : pos+2+n]
+		pos += 2 + n
+
+		switch {
+		case marker == markerAPP1 && bytes.HasPrefix(payload, exifHeader) && m.EXIF == nil:
+			m.EXIF = clone(payload[len(exifHeader):])
+		case marker == markerAPP1 && bytes.HasPrefix(payload, xmpHeader) && m.XMP == nil:
+			m.XMP = clone(payload[len(xmpHeader):])
+		case marker == markerAPP2 && bytes.HasPrefix(payload, iccHeader) && len(payload) > len(iccHeader)+2:
+			icc = append(icc, iccChunk{payload[len(iccHeader)], payload[len(iccHeader)+2:]})
+		}
+	}
+
+	// ICC 配置文件可能分成多个 APP2 段，按序号拼接
+	sort.SliceStable(icc, func(i, j int) bool { return icc[i].seq < icc[j].seq })
+	for _, c := range icc {
+		m.ICC = append(m.ICC, c.data...)
+	}
+	return m
+}
+
+// embedJPEG 在 SOI 之后插入 EXIF、XMP 和 ICC 段
+func embedJPEG(data []byte, m *Metadata) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, fmt.Errorf("无效的 JPEG 数据")
+	}
+
+	var segs bytes.Buffer
+	writeSeg := func(marker byte, parts ...[]byte) error {
+		n := 2
+		for _, p := range parts {
+			n += len(p)
+		}
+		if n > maxSegment {
+			return ErrTooLarge
+		}
+		segs.Write([]byte{0xff, marker, byte(n >> 8), byte(n)})
+		for _, p := range parts {
+			segs.Write(p)
+		}
+		return nil
+	}
+
+	if len(m.EXIF) > 0 {
+		if err := writeSeg(markerAPP1, exifHeader, m.EXIF); err != nil {
+			return nil, fmt.Errorf("EXIF: %w", err)
+		}
+	}
+	if len(m.XMP) > 0 {
+		if err := writeSeg(markerAPP1, xmpHeader, m.XMP); err != nil {
+			return nil, fmt.Errorf("XMP: %w", err)
+		}
+	}
+	if len(m.ICC) > 0 {
+		const chunkSize = maxSegment - 2 - 14 // 减去长度字段、标识和序号
+		count := (len(m.ICC) + chunkSize - 1) / chunkSize
+		if count > 255 {
+			return nil, fmt.Errorf("ICC: %w", ErrTooLarge)
+		}
+		for i := 0; i < count; i++ {
+			chunk := m.ICC[i*chunkSize : min((i+1)*chunkSize, len(m.ICC))]
+			if err := writeSeg(markerAPP2, iccHeader, []byte{byte(i + 1), byte(count)}, chunk); err != nil {
+				return nil, fmt.Errorf("ICC: %w", err)
+			}
+		}
+	}
+
+	out := make([]byte, 0, len(data)+segs.Len())
+	out = append(out, data[:2]...)
+	out = append(out, segs.Bytes()...)
+	return append(out, data[2:]...), nil
+}
+
+func clone(b []byte) []byte {
+	return append([]byte(nil), b...)
+}
@@ -0,0 +1,73 @@
+// Package metadata 在输入和输出文件之间搬运 EXIF、XMP 和 ICC 元数据。
+//
+// 图像解码后只剩像素，重新编码会丢失相机信息和色彩配置文件。这里直接从
+// 原始文件中提取元数据段（JPEG 的 APPn 段、PNG 和 WebP 的数据块），
+// 按输出格式重新嵌入编码后的数据，并根据处理结果修正 EXIF 中的尺寸、
+// 方向和软件标签。
+package metadata
+
+import (
+	"bytes"
+	"errors"
+)
+
+// 支持嵌入元数据的输出格式
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// ErrTooLarge 元数据超出输出格式允许的大小
+var ErrTooLarge = errors.New("元数据过大，无法写入")
+
+// Metadata 从文件中提取的原始元数据
+type Metadata struct {
+	EXIF []byte // TIFF 结构的 EXIF 数据（不含 "Exif\0\0" 前缀）
+	XMP  []byte // XMP 数据包
+	ICC  []byte // ICC 色彩配置文件
+}
+
+// Empty 判断是否没有任何元数据
+func (m *Metadata) Empty() bool {
+	return m == nil || len(m.EXIF) == 0 && len(m.XMP) == 0 && len(m.ICC) == 0
+}
+
+// Read 根据文件头识别格式并提取元数据，不支持的格式或损坏的元数据段返回空结果
+func Read(data []byte) *Metadata {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		return readJPEG(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return readPNG(data)
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return readWebP(data)
+	}
+	return &Metadata{}
+}
+
+// Supported 判断输出格式是否支持嵌入元数据
+func Supported(format string) bool {
+	switch format {
+	case FormatJPEG, FormatPNG, FormatWebP:
+		return true
+	}
+	return false
+}
+
+// Embed 将元数据嵌入已编码的图像数据，返回新的数据。
+// 元数据为空或格式不支持时原样返回。
+func Embed(data []byte, format string, m *Metadata) ([]byte, error) {
+	if m.Empty() {
+		return data, nil
+	}
+	switch format {
+	case FormatJPEG:
+		return embedJPEG(data, m)
+	case FormatPNG:
+		return embedPNG(data, m)
+	case FormatWebP:
+		return embedWebP(data, m)
+	}
+	return data, nil
+}
@@ -0,0 +1,123 @@
+package metadata
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// xmpKeyword PNG 中存放 XMP 的 iTXt 关键字
+const xmpKeyword = "XML:com.adobe.xmp"
+
+// readPNG 读取 eXIf、iCCP 和 XMP iTXt 数据块
+func readPNG(data []byte) *Metadata {
+	m := &Metadata{}
+	for pos := len(pngSignature); pos+12 <= len(data); {
+		n := int(binary.BigEndian.Uint32(data[pos:]))
+		typ := string(data[pos+4 : pos+8])
+		if pos+12+n > len(data) {
+			break
+		}
+		body := data[pos+8 : pos+8+n]
+		pos += 12 + n
+
+		switch typ {
+		case "eXIf":
+			m.EXIF = clone(body)
+		case "iCCP":
+			// 配置文件名 \0 压缩方法 zlib 数据
+			if i := bytes.IndexByte(body, 0); i >= 0 && i+2 <= len(body) {
+				if icc, err := inflate(body[i+2:]); err == nil {
+					m.ICC = icc
+				}
+			}
+		case "iTXt":
+			if xmp, ok := readXMPText(body); ok {
+				m.XMP = xmp
+			}
+		case "IEND":
+			return m
+		}
+	}
+	return m
+}
+
+// readXMPText 解析 iTXt 数据块，关键字为 XMP 时返回其文本
+func readXMPText(body []byte) ([]byte, bool) {
+	// 关键字 \0 压缩标志 压缩方法 语言 \0 翻译关键字 \0 文本
+	i := bytes.IndexByte(body, 0)
+	if i < 0 || string(body[:i]) != xmpKeyword || i+3 > len(body) {
+		return nil, false
+	}
+	compressed := body[i+1] == 1
+	rest := body[i+3:]
+	for k := 0; k < 2; k++ {
+		j := bytes.IndexByte(rest, 0)
+		if j < 0 {
+			return nil, false
+		}
+		rest = rest[j+1:]
+	}
+	if !compressed {
+		return clone(rest), true
+	}
+	text, err := inflate(rest)
+	return text, err == nil
+}
+
+// embedPNG 在 IHDR 之后插入 iCCP、eXIf 和 XMP iTXt 数据块
+func embedPNG(data []byte, m *Metadata) ([]byte, error) {
+	const ihdrEnd = 8 + 12 + 13
+	if len(data) < ihdrEnd || !bytes.HasPrefix(data, pngSignature) || string(data[12:16]) != "IHDR" {
+		return nil, fmt.Errorf("无效的 PNG 数据")
+	}
+
+	var chunks bytes.Buffer
+	if len(m.ICC) > 0 {
+		var body bytes.Buffer
+		body.WriteString("ICC Profile\x00\x00")
+		zw := zlib.NewWriter(&body)
+		zw.Write(m.ICC)
+		zw.Close()
+		writePNGChunk(&chunks, "iCCP", body.Bytes())
+	}
+	if len(m.EXIF) > 0 {
+		writePNGChunk(&chunks, "eXIf", m.EXIF)
+	}
+	if len(m.XMP) > 0 {
+		body := append([]byte(xmpKeyword+"\x00\x00\x00\x00\x00"), m.XMP...)
+		writePNGChunk(&chunks, "iTXt", body)
+	}
+
+	out := make([]byte, 0, len(data)+chunks.Len())
+	out = append(out, data[:ihdrEnd]...)
+	out = append(out, chunks.Bytes()...)
+	return append(out, data[ihdrEnd:]...), nil
+}
+
+func writePNGChunk(w *bytes.Buffer, typ string, body []byte) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(len(body)))
+	w.Write(b[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(body)
+	w.WriteString(typ)
+	w.Write(body)
+	binary.BigEndian.PutUint32(b[:], crc.Sum32())
+	w.Write(b[:])
+}
+
+func inflate(b []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
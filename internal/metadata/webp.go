@@ -0,0 +1,157 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// VP8X 标志位
+const (
+	flagICC   = 1 << 5
+	flagAlpha = 1 << 4
+	flagEXIF  = 1 << 3
+	flagXMP   = 1 << 2
+)
+
+// riffChunk WebP 文件中的 RIFF 数据块
+type riffChunk struct {
+	fourCC string
+	data   []byte
+}
+
+// parseRIFF 拆分 WebP 文件中的数据块
+func parseRIFF(data []byte) ([]riffChunk, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("无效的 WebP 数据")
+	}
+	var chunks []riffChunk
+	for pos := 12; pos+8 <= len(data); {
+		n := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		if pos+8+n > len(data) {
+			return nil, fmt.Errorf("WebP 数据块 %q 被截断", data[pos:pos+4])
+		}
+		chunks = append(chunks, riffChunk{string(data[pos : pos+4]), data[pos+8 : pos+8+n]})
+		pos += 8 + n + n&1
+	}
+	return chunks, nil
+}
+
+// readWebP 读取 ICCP、EXIF 和 XMP 数据块
+func readWebP(data []byte) *Metadata {
+	m := &Metadata{}
+	chunks, err := parseRIFF(data)
+	if err != nil {
+		return m
+	}
+	for _, c := range chunks {
+		switch c.fourCC {
+		case "ICCP":
+			m.ICC = clone(c.data)
+		case "EXIF":
+			// 部分编码器会保留 JPEG 的 "Exif\0\0" 前缀
+			m.EXIF = clone(bytes.TrimPrefix(c.data, exifHeader))
+		case "XMP ":
+			m.XMP = clone(c.data)
+		}
+	}
+	return m
+}
+
+// embedWebP 将简单格式转换为扩展格式（VP8X），按规范顺序插入元数据块
+func embedWebP(data []byte, m *Metadata) ([]byte, error) {
+	chunks, err := parseRIFF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		header []byte
+		image  []riffChunk // ALPH、VP8、VP8L 等图像数据块
+	)
+	for _, c := range chunks {
+		switch c.fourCC {
+		case "VP8X":
+			header = clone(c.data)
+		case "ICCP", "EXIF", "XMP ":
+		default:
+			image = append(image, c)
+		}
+	}
+	if header == nil {
+		if header, err = simpleHeader(image); err != nil {
+			return nil, err
+		}
+	}
+	if len(header) < 10 {
+		return nil, fmt.Errorf("无效的 VP8X 数据块")
+	}
+
+	out := []riffChunk{{"VP8X", header}}
+	if len(m.ICC) > 0 {
+		header[0] |= flagICC
+		out = append(out, riffChunk{"ICCP", m.ICC})
+	}
+	out = append(out, image...)
+	if len(m.EXIF) > 0 {
+		header[0] |= flagEXIF
+		out = append(out, riffChunk{"EXIF", m.EXIF})
+	}
+	if len(m.XMP) > 0 {
+		header[0] |= flagXMP
+		out = append(out, riffChunk{"XMP ", m.XMP})
+	}
+
+	size := 4
+	for _, c := range out {
+		size += 8 + len(c.data) + len(c.data)&1
+	}
+	buf := make([]byte, 0, 8+size)
+	buf = append(buf, "RIFF"...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(size))
+	buf = append(buf, "WEBP"...)
+	for _, c := range out {
+		buf = append(buf, c.fourCC...)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(c.data)))
+		buf = append(buf, c.data...)
+		if len(c.data)&1 == 1 {
+			buf = append(buf, 0)
+		}
+	}
+	return buf, nil
+}
+
+// simpleHeader 根据简单格式的 VP8 或 VP8L 位流构造 VP8X 数据块
+func simpleHeader(image []riffChunk) ([]byte, error) {
+	if len(image) != 1 {
+		return nil, fmt.Errorf("无效的 WebP 数据")
+	}
+	var w, h int
+	var flags byte
+	switch c := image[0]; {
+	case c.fourCC == "VP8 " && len(c.data) >= 10:
+		w = int(binary.LittleEndian.Uint16(c.data[6:]) & 0x3fff)
+		h = int(binary.LittleEndian.Uint16(c.data[8:]) & 0x3fff)
+	case c.fourCC == "VP8L" && len(c.data) >= 5:
+		bits := binary.LittleEndian.Uint32(c.data[1:])
+		w = int(bits&0x3fff) + 1
+		h = int(bits>>14&0x3fff) + 1
+		if bits>>28&1 == 1 {
+			flags |= flagAlpha
+		}
+	default:
+		return nil, fmt.Errorf("无效的 WebP 数据")
+	}
+
+	b := make([]byte, 10)
+	b[0] = flags
+	putUint24(b[4:], uint32(w-1))
+	putUint24(b[7:], uint32(h-1))
+	return b, nil
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
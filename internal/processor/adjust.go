@@ -1,9 +1,9 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"image"
-	"image/color"
 	"math"
 
 	"github.com/disintegration/imaging"
@@ -11,57 +11,198 @@ import (
 
 // AdjustOptions 调色选项
 type AdjustOptions struct {
-	Brightness  float64 // -100 到 100
+	Brightness  float64 // 中间调亮度 -100 到 100，黑色和白色不变
 	Contrast    float64 // -100 到 100
 	Saturation  float64 // -100 到 100
-	Exposure    float64 // -100 到 100
+	Exposure    float64 // 曝光 -5 到 5 EV，在线性光下乘以 2^Exposure
 	Sharpen     float64 // 0 到 100
 	Gamma       float64 // 0.1 到 3.0
 	Temperature int     // 色温 K (2000-10000，6500 为标准日光)
-	Dehaze      float64 // 0 到 100
+	Tint        float64 // 色调 -100 到 100，正值偏洋红，负值偏绿
+	Vibrance    float64 // 自然饱和度 -100 到 100，主要作用于低饱和度的颜色并保护肤色
+	Dehaze      float64 // 去雾 -100 到 100，负值加雾
+	Highlights  float64 // 高光 -100 到 100，负值压暗亮部以恢复细节
+	Shadows     float64 // 阴影 -100 到 100，正值提亮暗部
+	Whites      float64 // 白色 -100 到 100，调整白场
+	Blacks      float64 // 黑色 -100 到 100，调整黑场
+
+	Levels []Levels        // 色阶，先于曲线应用
+	Curves []Curve         // 色调曲线
+	HSL    []HSLAdjustment // 各色相范围的色相、饱和度、明度调整，为 nil 时使用配置 [adjust] hsl
+
+	// 自动调整在各自的应用位置按当时的中间结果估计：白平衡在去雾之后估计，
+	// 色阶在曝光、对比度、HSL、Gamma 等调整之后、Levels 之前估计，
+	// 因此估计出的参数与其余选项一起重新使用时结果相同
+	AutoWB   string // 自动白平衡的估计方法（AutoWBGrayWorld 等），空表示不自动，不能与 Temperature、Tint 同时使用
+	AutoTone bool   // 根据直方图自动设置色阶，先于 Levels 应用
 }
 
-// Adjust 调整图像的亮度、对比度、饱和度
-func Adjust(inputPath, outputPath string, opts AdjustOptions) error {
-	// 打开图像
-	img, err := imaging.Open(inputPath)
-	if err != nil {
-		return fmt.Errorf("无法打开图像: %w", err)
-	}
+// AdjustOperation 调色操作
+type AdjustOperation struct {
+	Options AdjustOptions
 
-	// 应用调整
-	result := adjustImage(img, opts)
+	// Report 不为 nil 时，在自动白平衡或自动色调估计出参数后调用
+	Report func(ctx context.Context, a AutoAdjustment)
+}
 
-	// 保存结果
-	if err := imaging.Save(result, outputPath); err != nil {
-		return fmt.Errorf("无法保存图像: %w", err)
+// Apply 对图像应用调色
+func (op AdjustOperation) Apply(ctx context.Context, img image.Image) (image.Image, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
+	opts := op.Options
+	if opts.HSL == nil {
+		h, err := ParseHSL(configFromContext(ctx).Adjust.HSL)
+		if err != nil {
+			return nil, fmt.Errorf("配置 [adjust] hsl 无效: %w", err)
+		}
+		opts.HSL = h
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	result, auto := adjustImage(img, opts)
+	if (op.Options.AutoWB != "" || op.Options.AutoTone) && op.Report != nil {
+		op.Report(ctx, auto)
+	}
+	return result, nil
+}
 
-	fmt.Printf("✅ 图像已保存至: %s\n", outputPath)
+// Validate 检查曝光、色阶、曲线、HSL 和自动调整参数
+func (o AdjustOptions) Validate() error {
+	if math.Abs(o.Exposure) > MaxExposure {
+		return fmt.Errorf("%w: 曝光 %g EV 超出 -%g 到 %g 的范围（单位为档，如 1.5）", ErrInvalidOptions, o.Exposure, MaxExposure, MaxExposure)
+	}
+	if err := ValidateAutoWB(o.AutoWB); err != nil {
+		return err
+	}
+	if o.AutoWB != "" && (o.Temperature != 0 && o.Temperature != referenceKelvin || o.Tint != 0) {
+		return fmt.Errorf("%w: 自动白平衡不能与色温、色调同时指定", ErrInvalidOptions)
+	}
+	for _, l := range o.Levels {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, c := range o.Curves {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, a := range o.HSL {
+		if err := a.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func adjustImage(img image.Image, opts AdjustOptions) image.Image {
+func init() {
+	Register(OperationSpec{
+		Name:        "adjust",
+		Description: "调整亮度、对比度、饱和度等",
+		Params: []ParamSpec{
+			{Name: "brightness", Aliases: []string{"b"}, Type: ParamFloat, Default: "0", Description: "中间调亮度 (-100 到 100)"},
+			{Name: "contrast", Aliases: []string{"t"}, Type: ParamFloat, Default: "0", Description: "对比度 (-100 到 100)"},
+			{Name: "saturation", Aliases: []string{"s"}, Type: ParamFloat, Default: "0", Description: "饱和度 (-100 到 100)"},
+			{Name: "exposure", Aliases: []string{"e"}, Type: ParamFloat, Default: "0", Description: "曝光，单位 EV (-5 到 5)"},
+			{Name: "sharpen", Type: ParamFloat, Default: "0", Description: "锐化强度 (0 到 100)"},
+			{Name: "gamma", Type: ParamFloat, Default: "1.0", Description: "Gamma (0.1 到 3.0)"},
+			{Name: "temperature", Type: ParamInt, Default: "6500", Description: "色温，单位 K (2000-10000)"},
+			{Name: "tint", Type: ParamFloat, Default: "0", Description: "色调 (-100 到 100，正值偏洋红)"},
+			{Name: "vibrance", Type: ParamFloat, Default: "0", Description: "自然饱和度 (-100 到 100)"},
+			{Name: "dehaze", Type: ParamFloat, Default: "0", Description: "去雾强度 (-100 到 100，负值加雾)"},
+			{Name: "highlights", Type: ParamFloat, Default: "0", Description: "高光 (-100 到 100)"},
+			{Name: "shadows", Type: ParamFloat, Default: "0", Description: "阴影 (-100 到 100)"},
+			{Name: "whites", Type: ParamFloat, Default: "0", Description: "白色 (-100 到 100)"},
+			{Name: "blacks", Type: ParamFloat, Default: "0", Description: "黑色 (-100 到 100)"},
+			{Name: "levels", Type: ParamString, Description: "色阶 [通道:]黑场,白场[,Gamma[,输出黑场,输出白场]]，多组用 ; 分隔"},
+			{Name: "curve", Type: ParamString, Description: "色调曲线 [通道:]x,y x,y ...，多条用 ; 分隔"},
+			{Name: "hsl", Type: ParamString, Description: "HSL 调整 范围:h=色相,s=饱和度,l=明度，多个范围用 ; 分隔"},
+			{Name: "auto-wb", Type: ParamString, Description: "自动白平衡: gray-world, white-patch, percentile"},
+			{Name: "auto-tone", Type: ParamBool, Description: "根据直方图自动设置色阶"},
+			{Name: "curve-file", Type: ParamString, Description: "曲线文件路径（先于 levels、curve 和 hsl 应用）"},
+		},
+		New: func(p Params) (Operation, error) {
+			var look AdjustOptions
+			if p.Has("curve-file") {
+				var err error
+				if look, err = LoadCurveFile(p.String("curve-file")); err != nil {
+					return nil, p.Errorf("%v", err)
+				}
+			}
+			l, err := ParseLevels(p.String("levels"))
+			if err != nil {
+				return nil, p.Errorf("%v", err)
+			}
+			c, err := ParseCurves(p.String("curve"))
+			if err != nil {
+				return nil, p.Errorf("%v", err)
+			}
+			h, err := ParseHSL(p.String("hsl"))
+			if err != nil {
+				return nil, p.Errorf("%v", err)
+			}
+
+			return AdjustOperation{Options: AdjustOptions{
+				Brightness:  p.Float("brightness"),
+				Contrast:    p.Float("contrast"),
+				Saturation:  p.Float("saturation"),
+				Exposure:    p.Float("exposure"),
+				Sharpen:     p.Float("sharpen"),
+				Gamma:       p.Float("gamma"),
+				Temperature: p.Int("temperature"),
+				Tint:        p.Float("tint"),
+				Vibrance:    p.Float("vibrance"),
+				Dehaze:      p.Float("dehaze"),
+				Highlights:  p.Float("highlights"),
+				Shadows:     p.Float("shadows"),
+				Whites:      p.Float("whites"),
+				Blacks:      p.Float("blacks"),
+				Levels:      append(look.Levels, l...),
+				Curves:      append(look.Curves, c...),
+				HSL:         append(look.HSL, h...),
+				AutoWB:      p.String("auto-wb"),
+				AutoTone:    p.Bool("auto-tone"),
+			}, Report: PrintAutoAdjustment}, nil
+		},
+	})
+}
+
+// Adjust 调整图像的亮度、对比度、饱和度
+func Adjust(inputPath, outputPath string, opts AdjustOptions) error {
+	return ProcessFile(context.Background(), inputPath, outputPath, DefaultDecodeOptions(), DefaultEncodeOptions(), AdjustOperation{Options: opts})
+}
+
+// adjustImage 按固定顺序应用各项调整，返回结果和自动调整估计出的参数
+func adjustImage(img image.Image, opts AdjustOptions) (image.Image, AutoAdjustment) {
 	result := img
+	var auto AutoAdjustment
 
 	// 去雾处理（先处理）
-	if opts.Dehaze > 0 {
+	if opts.Dehaze != 0 {
 		result = applyDehaze(result, opts.Dehaze)
 	}
 
-	// 色温调整
-	if opts.Temperature != 0 && opts.Temperature != 6500 {
-		result = applyTemperature(result, opts.Temperature)
+	// 自动白平衡按去雾后的图像估计，方法已在 Validate 中检查过
+	if opts.AutoWB != "" {
+		opts.Temperature, opts.Tint, _ = EstimateWhiteBalance(result, opts.AutoWB)
+		auto.WhiteBalance, auto.Temperature, auto.Tint = true, opts.Temperature, opts.Tint
+	}
+
+	// 白平衡：色温和色调
+	if (opts.Temperature != 0 && opts.Temperature != 6500) || opts.Tint != 0 {
+		result = applyWhiteBalance(result, opts.Temperature, opts.Tint)
 	}
 
 	// 曝光调整
 	if opts.Exposure != 0 {
-		result = imaging.AdjustBrightness(result, opts.Exposure)
+		result = applyExposure(result, opts.Exposure)
 	}
 
 	// 亮度调整
 	if opts.Brightness != 0 {
-		result = imaging.AdjustBrightness(result, opts.Brightness)
+		result = applyBrightness(result, opts.Brightness)
 	}
 
 	// 对比度调整
@@ -69,168 +210,50 @@ func adjustImage(img image.Image, opts AdjustOptions) image.Image {
 		result = imaging.AdjustContrast(result, opts.Contrast)
 	}
 
+	// 高光、阴影、白色、黑色
+	if opts.Highlights != 0 || opts.Shadows != 0 || opts.Whites != 0 || opts.Blacks != 0 {
+		result = applyTone(result, opts.Highlights, opts.Shadows, opts.Whites, opts.Blacks)
+	}
+
 	// 饱和度调整
 	if opts.Saturation != 0 {
 		result = imaging.AdjustSaturation(result, opts.Saturation)
 	}
 
-	// Gamma 调整
-	if opts.Gamma != 0 && opts.Gamma != 1.0 {
-		result = imaging.AdjustGamma(result, opts.Gamma)
+	// 自然饱和度
+	if opts.Vibrance != 0 {
+		result = applyVibrance(result, opts.Vibrance)
 	}
 
-	// 锐化处理（最后处理）
-	if opts.Sharpen > 0 {
-		sharpness := opts.Sharpen / 10.0 // 转换为合适的范围
-		result = imaging.Sharpen(result, sharpness)
-	}
-
-	return result
-}
-
-// applyTemperature 应用色温调整
-func applyTemperature(img image.Image, kelvin int) image.Image {
-	bounds := img.Bounds()
-	dst := image.NewRGBA(bounds)
-
-	// 将色温转换为 RGB 调整因子
-	// 参考标准：6500K 为标准日光白平衡
-	rFactor, gFactor, bFactor := kelvinToRGB(kelvin)
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			oldColor := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
-
-			r := clamp(float64(oldColor.R) * rFactor)
-			g := clamp(float64(oldColor.G) * gFactor)
-			b := clamp(float64(oldColor.B) * bFactor)
-
-			dst.Set(x, y, color.RGBA{
-				R: uint8(r),
-				G: uint8(g),
-				B: uint8(b),
-				A: oldColor.A,
-			})
-		}
+	// 各色相范围的 HSL 调整
+	if len(opts.HSL) > 0 {
+		result = applyHSL(result, opts.HSL)
 	}
 
-	return dst
-}
-
-// kelvinToRGB 将色温（开尔文）转换为 RGB 调整因子
-// 基于 Tanner Helland 的算法
-func kelvinToRGB(kelvin int) (float64, float64, float64) {
-	// 限制色温范围
-	if kelvin < 1000 {
-		kelvin = 1000
-	}
-	if kelvin > 40000 {
-		kelvin = 40000
-	}
-
-	temp := float64(kelvin) / 100.0
-	var r, g, b float64
-
-	// 计算红色
-	if temp <= 66 {
-		r = 255
-	} else {
-		r = temp - 60
-		r = 329.698727446 * math.Pow(r, -0.1332047592)
-		if r < 0 {
-			r = 0
-		}
-		if r > 255 {
-			r = 255
-		}
+	// Gamma 调整
+	if opts.Gamma != 0 && opts.Gamma != 1.0 {
+		result = imaging.AdjustGamma(result, opts.Gamma)
 	}
 
-	// 计算绿色
-	if temp <= 66 {
-		g = temp
-		g = 99.4708025861*math.Log(g) - 161.1195681661
-		if g < 0 {
-			g = 0
-		}
-		if g > 255 {
-			g = 255
-		}
-	} else {
-		g = temp - 60
-		g = 288.1221695283 * math.Pow(g, -0.0755148492)
-		if g < 0 {
-			g = 0
-		}
-		if g > 255 {
-			g = 255
-		}
+	// 自动色调按此前各项调整后的图像估计，先于 Levels 应用
+	if opts.AutoTone {
+		l := EstimateTone(result)
+		opts.Levels = append([]Levels{l}, opts.Levels...)
+		auto.Levels = &l
 	}
 
-	// 计算蓝色
-	if temp >= 66 {
-		b = 255
-	} else if temp <= 19 {
-		b = 0
-	} else {
-		b = temp - 10
-		b = 138.5177312231*math.Log(b) - 305.0447927307
-		if b < 0 {
-			b = 0
-		}
-		if b > 255 {
-			b = 255
-		}
+	// 色阶和曲线
+	if len(opts.Levels) > 0 || len(opts.Curves) > 0 {
+		result = applyLevelsCurves(result, opts.Levels, opts.Curves)
 	}
 
-	// 转换为调整因子（相对于 6500K 标准日光）
-	return r / 255.0, g / 255.0, b / 255.0
-}
-
-// applyDehaze 应用去雾效果
-func applyDehaze(img image.Image, strength float64) image.Image {
-	bounds := img.Bounds()
-	dst := image.NewRGBA(bounds)
-
-	// 将强度转换为合适的因子 (0-100 -> 0-1)
-	factor := strength / 100.0
-
-	// 去雾：增加对比度和饱和度，降低灰度
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			oldColor := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
-
-			r := float64(oldColor.R)
-			g := float64(oldColor.G)
-			b := float64(oldColor.B)
-
-			// 计算灰度值
-			gray := (r + g + b) / 3
-
-			// 增加对比度（拉伸色阶）
-			r = clamp(gray + (r-gray)*(1+factor*0.5))
-			g = clamp(gray + (g-gray)*(1+factor*0.5))
-			b = clamp(gray + (b-gray)*(1+factor*0.5))
-
-			// 增加饱和度
-			max := math.Max(math.Max(r, g), b)
-			min := math.Min(math.Min(r, g), b)
-			if max > min {
-				saturation := 1 + factor*0.3
-				r = clamp(max - (max-r)*saturation)
-				g = clamp(max - (max-g)*saturation)
-				b = clamp(max - (max-b)*saturation)
-			}
-
-			dst.Set(x, y, color.RGBA{
-				R: uint8(r),
-				G: uint8(g),
-				B: uint8(b),
-				A: oldColor.A,
-			})
-		}
+	// 锐化处理（最后处理）
+	if opts.Sharpen > 0 {
+		sharpness := opts.Sharpen / 10.0 // 转换为合适的范围
+		result = imaging.Sharpen(result, sharpness)
 	}
 
-	return dst
+	return result, auto
 }
 
 // clamp 将值限制在 0-255 范围内
@@ -0,0 +1,226 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// 自动白平衡的估计方法
+const (
+	AutoWBGrayWorld  = "gray-world"  // 灰度世界：假设画面的平均颜色为中性灰
+	AutoWBWhitePatch = "white-patch" // 白点：假设画面中最亮的颜色为白色
+	AutoWBPercentile = "percentile"  // 百分位：取各通道的高百分位作为白色，不受少量过曝和噪点影响
+)
+
+const (
+	autoSampleSize     = 512   // 估计时先将图像缩小到的短边上限
+	autoWBPercentile   = 0.98  // percentile 方法取的百分位
+	autoToneClip       = 0.005 // 自动色调在暗部和亮部各裁掉的像素比例
+	autoToneMaxBlack   = 64    // 自动色调的黑场上限，避免压暗低调画面
+	autoToneMinWhite   = 160   // 自动色调的白场下限，避免过度提亮暗场景
+	autoToneMinRange   = 16    // 亮度范围小于该值时不调整色阶
+	autoToneTargetMean = 0.45  // 自动色调使平均亮度接近的值（0-1）
+	autoToneGammaLimit = 1.25  // 自动色调 Gamma 的上限，下限为其倒数
+	autoWBClippedValue = 250   // 任一通道达到该值的像素视为过曝，不参与估计
+	autoWBMinKelvin    = 2000  // 自动白平衡结果的色温下限
+	autoWBMaxKelvin    = 25000 // 自动白平衡结果的色温上限
+	autoWBKelvinStep   = 10    // 自动白平衡的色温取整到该值的倍数
+)
+
+// AutoWBMethods 返回自动白平衡的估计方法
+func AutoWBMethods() []string {
+	return []string{AutoWBGrayWorld, AutoWBWhitePatch, AutoWBPercentile}
+}
+
+// ValidateAutoWB 检查自动白平衡的估计方法，空字符串表示不自动白平衡
+func ValidateAutoWB(method string) error {
+	switch method {
+	case "", AutoWBGrayWorld, AutoWBWhitePatch, AutoWBPercentile:
+		return nil
+	}
+	return fmt.Errorf("%w: 未知的自动白平衡方法 %q（可选: %s）", ErrInvalidOptions, method, strings.Join(AutoWBMethods(), ", "))
+}
+
+// AutoAdjustment 自动白平衡和自动色调估计出的参数
+type AutoAdjustment struct {
+	WhiteBalance bool    // 是否估计了白平衡
+	Temperature  int     // 色温 K
+	Tint         float64 // 色调
+	Levels       *Levels // 自动色调的色阶，未估计时为 nil
+}
+
+// String 返回可直接用于 pipeline --step 的形式，如 adjust:temperature=5400,tint=6,levels="4,247,1.08"。
+// 配方文件中 adjust 步骤使用相同的参数名
+func (a AutoAdjustment) String() string {
+	var params []string
+	if a.WhiteBalance {
+		params = append(params, fmt.Sprintf("temperature=%d", a.Temperature), "tint="+strconv.FormatFloat(a.Tint, 'f', -1, 64))
+	}
+	if a.Levels != nil {
+		params = append(params, fmt.Sprintf("levels=%q", a.Levels.String()))
+	}
+	return "adjust:" + strings.Join(params, ",")
+}
+
+// PrintAutoAdjustment 输出自动调整的参数，供 AdjustOperation.Report 使用
+func PrintAutoAdjustment(ctx context.Context, a AutoAdjustment) {
+	name := "自动调整"
+	if src, ok := SourceFromContext(ctx); ok {
+		name = src.Path
+	}
+	fmt.Printf("🎯 %s: %s\n", name, a)
+}
+
+// EstimateWhiteBalance 估计图像的光源颜色，返回校正色偏所需的色温和色调：
+// 将它们设置到 AdjustOptions.Temperature 和 Tint 后，光源颜色变为中性
+func EstimateWhiteBalance(img image.Image, method string) (int, float64, error) {
+	if err := ValidateAutoWB(method); err != nil {
+		return 0, 0, err
+	}
+	small := autoSample(img)
+
+	// 各通道的线性值：累加和、最大值与直方图
+	var sum, peak [3]float64
+	var hist [3][256]int
+	n := 0
+	for i := 0; i < len(small.Pix); i += 4 {
+		p := small.Pix[i : i+4]
+		if p[3] == 0 || max(p[0], p[1], p[2]) >= autoWBClippedValue {
+			continue
+		}
+		for c := 0; c < 3; c++ {
+			v := srgbToLinear[p[c]]
+			sum[c] += v
+			peak[c] = max(peak[c], v)
+			hist[c][p[c]]++
+		}
+		n++
+	}
+	if n == 0 {
+		return referenceKelvin, 0, nil
+	}
+
+	var illuminant [3]float64
+	switch method {
+	case AutoWBGrayWorld:
+		for c := range illuminant {
+			illuminant[c] = sum[c] / float64(n)
+		}
+	case AutoWBWhitePatch:
+		illuminant = peak
+	default:
+		for c := range illuminant {
+			illuminant[c] = srgbToLinear[histPercentile(hist[c][:], n, autoWBPercentile)]
+		}
+	}
+	kelvin, tint := neutralizingWhiteBalance(illuminant)
+	return kelvin, tint, nil
+}
+
+// neutralizingWhiteBalance 返回使线性 sRGB 颜色 illuminant 变为中性的色温和色调。
+//
+// 白平衡对 LMS 各分量的增益为 目标白点 / 6500K 白点，要使光源变为 sRGB 的白色（D65），
+// 目标白点应为 6500K 白点 × D65 / 光源；再在普朗克轨迹上找到离它最近的色温，
+// 到轨迹的有向距离即为色调。色温受轨迹范围的限制，很强的偏暖光源只能部分校正
+func neutralizingWhiteBalance(illuminant [3]float64) (int, float64) {
+	if illuminant[0] <= 0 || illuminant[1] <= 0 || illuminant[2] <= 0 {
+		return referenceKelvin, 0
+	}
+	ref := bradford.apply(whitePoint(referenceKelvin, 0))
+	white := bradford.apply(srgbToXYZ.apply([3]float64{1, 1, 1}))
+	src := bradford.apply(srgbToXYZ.apply(illuminant))
+	var target [3]float64
+	for i := range target {
+		target[i] = ref[i] * white[i] / src[i]
+	}
+	xyz := bradford.inverse().apply(target)
+	sum := xyz[0] + xyz[1] + xyz[2]
+	u, v := xyToUV(xyz[0]/sum, xyz[1]/sum)
+
+	// 按倒数色温（mired）均匀搜索，色温越高轨迹上的点越密
+	distance := func(mired float64) float64 {
+		lu, lv := xyToUV(planckianXY(1e6 / mired))
+		return math.Hypot(u-lu, v-lv)
+	}
+	best := 1e6 / autoWBMaxKelvin
+	for m := best; m <= 1e6/autoWBMinKelvin; m += 0.5 {
+		if distance(m) < distance(best) {
+			best = m
+		}
+	}
+	t := math.Round(1e6/best/autoWBKelvinStep) * autoWBKelvinStep
+
+	lu, lv := xyToUV(planckianXY(t))
+	nu, nv := locusNormal(t)
+	tint := ((u-lu)*nu + (v-lv)*nv) / tintRange * 100
+	tint = math.Round(math.Max(-100, math.Min(tint, 100)))
+	return int(t), tint
+}
+
+// EstimateTone 根据亮度直方图估计自动色调的色阶：暗部和亮部各裁掉少量像素后拉伸到 0-255，
+// 再按平均亮度选择 Gamma
+func EstimateTone(img image.Image) Levels {
+	small := autoSample(img)
+	var hist [256]int
+	n := 0
+	for i := 0; i < len(small.Pix); i += 4 {
+		p := small.Pix[i : i+4]
+		if p[3] == 0 {
+			continue
+		}
+		hist[int(math.Round(luminance(p)*255))]++
+		n++
+	}
+	l := Levels{Channel: ChannelRGB, InBlack: 0, InWhite: 255, Gamma: 1, OutWhite: 255}
+	if n == 0 {
+		return l
+	}
+
+	black := min(histPercentile(hist[:], n, autoToneClip), autoToneMaxBlack)
+	white := max(histPercentile(hist[:], n, 1-autoToneClip), autoToneMinWhite)
+	if white-black < autoToneMinRange {
+		return l
+	}
+	l.InBlack, l.InWhite = float64(black), float64(white)
+
+	// 平均亮度在拉伸后的位置，Gamma 使其接近目标值
+	mean := 0.0
+	for v, count := range hist {
+		mean += l.apply(float64(v)) / 255 * float64(count)
+	}
+	mean /= float64(n)
+	if mean > 0 && mean < 1 {
+		gamma := math.Log(mean) / math.Log(autoToneTargetMean)
+		gamma = math.Max(1/autoToneGammaLimit, math.Min(gamma, autoToneGammaLimit))
+		l.Gamma = math.Round(gamma*100) / 100
+	}
+	return l
+}
+
+// autoSample 返回缩小后用于估计的图像
+func autoSample(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	if short := min(b.Dx(), b.Dy()); short > autoSampleSize {
+		return imaging.Resize(img, b.Dx()*autoSampleSize/short, b.Dy()*autoSampleSize/short, imaging.Box)
+	}
+	return imaging.Clone(img)
+}
+
+// histPercentile 返回直方图中累计比例达到 p 的值
+func histPercentile(hist []int, total int, p float64) int {
+	want := int(math.Ceil(p * float64(total)))
+	count := 0
+	for v, c := range hist {
+		count += c
+		if count >= want {
+			return v
+		}
+	}
+	return len(hist) - 1
+}
@@ -0,0 +1,158 @@
+package processor
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// castImage 返回不同亮度的灰色色块，在线性光下各通道乘以 gain 形成色偏
+func castImage(gain [3]float64) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			v := srgbToLinear[uint8(40+(x/8+y/8*8)*3)]
+			var c [3]uint8
+			for i := range c {
+				c[i] = linearToSRGB(v * gain[i])
+			}
+			img.SetNRGBA(x, y, color.NRGBA{R: c[0], G: c[1], B: c[2], A: 255})
+		}
+	}
+	return img
+}
+
+// channelRatios 返回线性光下 R/G 和 B/G 的平均比值
+func channelRatios(img image.Image) (float64, float64) {
+	src := imaging.Clone(img)
+	var sum [3]float64
+	for i := 0; i < len(src.Pix); i += 4 {
+		for c := 0; c < 3; c++ {
+			sum[c] += srgbToLinear[src.Pix[i+c]]
+		}
+	}
+	return sum[0] / sum[1], sum[2] / sum[1]
+}
+
+func TestEstimateWhiteBalance(t *testing.T) {
+	tests := []struct {
+		name string
+		gain [3]float64
+		warm bool // 图像偏暖，校正应使用高于 6500K 的色温
+	}{
+		{"warm", [3]float64{1.25, 1, 0.7}, true},
+		{"cool", [3]float64{0.75, 1, 1.3}, false},
+	}
+	for _, method := range AutoWBMethods() {
+		for _, tt := range tests {
+			t.Run(method+"-"+tt.name, func(t *testing.T) {
+				img := castImage(tt.gain)
+				kelvin, tint, err := EstimateWhiteBalance(img, method)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if (kelvin > referenceKelvin) != tt.warm {
+					t.Errorf("色温为 %dK，校正方向错误", kelvin)
+				}
+				r, b := channelRatios(applyWhiteBalance(img, kelvin, tint))
+				if math.Abs(r-1) > 0.05 || math.Abs(b-1) > 0.05 {
+					t.Errorf("按 %dK、色调 %g 校正后 R/G=%.3f、B/G=%.3f，仍有色偏", kelvin, tint, r, b)
+				}
+			})
+		}
+	}
+
+	kelvin, tint, err := EstimateWhiteBalance(castImage([3]float64{1, 1, 1}), AutoWBPercentile)
+	if err != nil || math.Abs(float64(kelvin-referenceKelvin)) > 100 || math.Abs(tint) > 2 {
+		t.Errorf("中性图像的估计为 %dK、色调 %g（%v），期望约 6500K、0", kelvin, tint, err)
+	}
+	if _, _, err := EstimateWhiteBalance(castImage([3]float64{1, 1, 1}), "unknown"); err == nil {
+		t.Error("未知方法应返回错误")
+	}
+}
+
+// grayRamp 返回亮度从 lo 到 hi 水平渐变的灰度图像
+func grayRamp(lo, hi int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 256, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 256; x++ {
+			v := uint8(lo + x*(hi-lo)/255)
+			img.SetNRGBA(x, y, color.NRGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+// lumaRange 返回图像 G 通道的最小值和最大值（灰度图像中各通道相同）
+func lumaRange(img image.Image) (int, int) {
+	src := imaging.Clone(img)
+	lo, hi := 255, 0
+	for i := 1; i < len(src.Pix); i += 4 {
+		lo, hi = min(lo, int(src.Pix[i])), max(hi, int(src.Pix[i]))
+	}
+	return lo, hi
+}
+
+func TestEstimateTone(t *testing.T) {
+	l := EstimateTone(grayRamp(60, 180))
+	if math.Abs(l.InBlack-60) > 2 || math.Abs(l.InWhite-180) > 2 {
+		t.Errorf("60-180 的渐变估计出黑场 %g、白场 %g", l.InBlack, l.InWhite)
+	}
+	if lo, hi := lumaRange(applyLevelsCurves(grayRamp(60, 180), []Levels{l}, nil)); lo > 2 || hi < 253 {
+		t.Errorf("应用自动色阶后范围为 %d-%d，期望拉伸到 0-255", lo, hi)
+	}
+
+	l = EstimateTone(grayRamp(0, 255))
+	if l.InBlack > 2 || l.InWhite < 253 {
+		t.Errorf("完整范围的渐变估计出黑场 %g、白场 %g，不应拉伸", l.InBlack, l.InWhite)
+	}
+}
+
+// TestAutoAtPointOfUse 检查自动调整按应用位置的中间结果估计，与曝光、去雾同时使用时不会错位
+func TestAutoAtPointOfUse(t *testing.T) {
+	img := castImage([3]float64{1.2, 1, 0.8})
+
+	var auto AutoAdjustment
+	op := AdjustOperation{
+		Options: AdjustOptions{Exposure: -1.5, Dehaze: 30, AutoWB: AutoWBPercentile, AutoTone: true},
+		Report:  func(_ context.Context, a AutoAdjustment) { auto = a },
+	}
+	got, err := op.Apply(context.Background(), img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dehazed := applyDehaze(img, 30)
+	kelvin, tint, _ := EstimateWhiteBalance(dehazed, AutoWBPercentile)
+	if !auto.WhiteBalance || auto.Temperature != kelvin || auto.Tint != tint {
+		t.Errorf("白平衡估计为 %dK、%g，期望按去雾后的图像估计的 %dK、%g", auto.Temperature, auto.Tint, kelvin, tint)
+	}
+	before, _ := adjustImage(img, AdjustOptions{Exposure: -1.5, Dehaze: 30, Temperature: kelvin, Tint: tint})
+	if want := EstimateTone(before); auto.Levels == nil || *auto.Levels != want {
+		t.Errorf("自动色阶为 %v，期望按曝光后的图像估计的 %v", auto.Levels, want)
+	}
+
+	// 估计出的参数与其余选项一起使用时结果相同
+	again, _ := adjustImage(img, AdjustOptions{Exposure: -1.5, Dehaze: 30, Temperature: kelvin, Tint: tint, Levels: []Levels{*auto.Levels}})
+	if maxDelta, _ := compareImages(t, got, again); maxDelta != 0 {
+		t.Errorf("复用估计出的参数后结果不同，最大差值 %d", maxDelta)
+	}
+
+	// 曝光降低后白场随之降低，自动色阶仍能拉伸到接近白色
+	if _, hi := lumaRange(adjustOrDie(t, grayRamp(30, 220), AdjustOptions{Exposure: -0.5, AutoTone: true})); hi < 245 {
+		t.Errorf("降低曝光后自动色调的最亮值为 %d，白场按曝光前的图像估计", hi)
+	}
+}
+
+func adjustOrDie(t *testing.T, img image.Image, opts AdjustOptions) image.Image {
+	t.Helper()
+	got, err := AdjustOperation{Options: opts}.Apply(context.Background(), img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
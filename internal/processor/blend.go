@@ -0,0 +1,121 @@
+package processor
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// 混合模式
+const (
+	BlendNormal     = "normal"     // 普通的 alpha 合成
+	BlendMultiply   = "multiply"   // 正片叠底：变暗，白色不可见
+	BlendScreen     = "screen"     // 滤色：变亮，黑色不可见
+	BlendOverlay    = "overlay"    // 叠加：增强底图的对比度
+	BlendSoftLight  = "soft-light" // 柔光：较柔和的叠加
+	BlendDifference = "difference" // 差值：取两者之差的绝对值
+)
+
+// blendFuncs 各混合模式对单个通道的混合函数，cb 为底图颜色，cs 为上层颜色，取值 0-1
+var blendFuncs = map[string]func(cb, cs float64) float64{
+	BlendNormal:   func(_, cs float64) float64 { return cs },
+	BlendMultiply: func(cb, cs float64) float64 { return cb * cs },
+	BlendScreen:   screen,
+	BlendOverlay: func(cb, cs float64) float64 {
+		return hardLight(cs, cb)
+	},
+	BlendSoftLight: func(cb, cs float64) float64 {
+		if cs <= 0.5 {
+			return cb - (1-2*cs)*cb*(1-cb)
+		}
+		d := math.Sqrt(cb)
+		if cb <= 0.25 {
+			d = ((16*cb-12)*cb + 4) * cb
+		}
+		return cb + (2*cs-1)*(d-cb)
+	},
+	BlendDifference: func(cb, cs float64) float64 { return math.Abs(cb - cs) },
+}
+
+func screen(cb, cs float64) float64 {
+	return cb + cs - cb*cs
+}
+
+// hardLight 强光，overlay 即交换参数的强光
+func hardLight(cb, cs float64) float64 {
+	if cs <= 0.5 {
+		return cb * 2 * cs
+	}
+	return screen(cb, 2*cs-1)
+}
+
+// BlendModes 返回按名称排序的混合模式
+func BlendModes() []string {
+	modes := make([]string, 0, len(blendFuncs))
+	for mode := range blendFuncs {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	return modes
+}
+
+// ValidateBlendMode 检查混合模式，空字符串表示使用配置文件
+func ValidateBlendMode(mode string) error {
+	if _, ok := blendFuncs[mode]; ok || mode == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: 未知的混合模式 %q（可选: %s）", ErrInvalidOptions, mode, strings.Join(BlendModes(), ", "))
+}
+
+// blendDraw 与 draw.Draw(dst, r, src, sp, draw.Over) 相同，但按混合模式合成。
+//
+// 按 W3C Compositing 规范：上层颜色先与底图按模式混合（底图透明处保持上层颜色），
+// 再按上层的 alpha 进行普通的 source-over 合成。normal 或空模式直接使用 draw.Draw。
+func blendDraw(dst *image.RGBA, r image.Rectangle, src image.Image, sp image.Point, mode string) {
+	blend, ok := blendFuncs[mode]
+	if !ok || mode == BlendNormal {
+		draw.Draw(dst, r, src, sp, draw.Over)
+		return
+	}
+
+	s, isNRGBA := src.(*image.NRGBA)
+	if !isNRGBA {
+		s = imaging.Clone(src)
+		sp = sp.Sub(src.Bounds().Min)
+	}
+
+	// 与 draw.Draw 一样将 r 裁剪到 dst 和 src 的范围内
+	clipped := r.Intersect(dst.Bounds()).Intersect(s.Bounds().Add(r.Min.Sub(sp)))
+	if clipped.Empty() {
+		return
+	}
+	sp = sp.Add(clipped.Min.Sub(r.Min))
+
+	for y := clipped.Min.Y; y < clipped.Max.Y; y++ {
+		si := s.PixOffset(sp.X, sp.Y+y-clipped.Min.Y)
+		di := dst.PixOffset(clipped.Min.X, y)
+		for x := clipped.Min.X; x < clipped.Max.X; x, si, di = x+1, si+4, di+4 {
+			as := float64(s.Pix[si+3]) / 255
+			if as == 0 {
+				continue
+			}
+			ab := float64(dst.Pix[di+3]) / 255
+			for c := 0; c < 3; c++ {
+				cs := float64(s.Pix[si+c]) / 255
+				cbp := float64(dst.Pix[di+c]) / 255 // 预乘 alpha 的底图颜色
+				cb := 0.0
+				if ab > 0 {
+					cb = cbp / ab
+				}
+				mixed := (1-ab)*cs + ab*blend(cb, cs)
+				dst.Pix[di+c] = uint8(math.Round(clamp((as*mixed + (1-as)*cbp) * 255)))
+			}
+			dst.Pix[di+3] = uint8(math.Round(clamp((as + ab*(1-as)) * 255)))
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+)
+
+// blendBase 返回随机颜色的底图，opaque 为 false 时部分像素半透明或全透明
+func blendBase(w, h int, opaque bool) *image.RGBA {
+	r := rand.New(rand.NewSource(int64(w * h)))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		a := 255
+		if !opaque {
+			a = []int{0, 128, 255}[r.Intn(3)]
+		}
+		for c := 0; c < 3; c++ {
+			img.Pix[i+c] = uint8(r.Intn(a + 1)) // 预乘 alpha
+		}
+		img.Pix[i+3] = uint8(a)
+	}
+	return img
+}
+
+// blendLayer 返回单一颜色、alpha 从左到右渐变的上层图像
+func blendLayer(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := c.A
+			if w > 1 {
+				a = uint8(int(c.A) * x / (w - 1))
+			}
+			img.SetNRGBA(x, y, color.NRGBA{c.R, c.G, c.B, a})
+		}
+	}
+	return img
+}
+
+// maxPixelDelta 返回两幅 RGBA 图像对应字节的最大差值
+func maxPixelDelta(a, b *image.RGBA) int {
+	d := 0
+	for i := range a.Pix {
+		d = max(d, absInt(int(a.Pix[i])-int(b.Pix[i])))
+	}
+	return d
+}
+
+func TestBlendNormal(t *testing.T) {
+	src := photoImage(24, 16)
+	for _, opaque := range []bool{true, false} {
+		want := blendBase(32, 32, opaque)
+		got := image.NewRGBA(want.Rect)
+		copy(got.Pix, want.Pix)
+		r := image.Rect(4, 6, 28, 22)
+		draw.Draw(want, r, src, image.Point{}, draw.Over)
+		blendDraw(got, r, src, image.Point{}, BlendNormal)
+		if d := maxPixelDelta(want, got); d != 0 {
+			t.Errorf("normal 与 draw.Draw 的最大差值为 %d，期望完全一致", d)
+		}
+	}
+}
+
+// TestBlendCompositing 用与 normal 等价的混合函数走逐像素合成的路径，结果应与 draw.Draw 一致
+func TestBlendCompositing(t *testing.T) {
+	blendFuncs["test-normal"] = blendFuncs[BlendNormal]
+	defer delete(blendFuncs, "test-normal")
+
+	layer := blendLayer(20, 12, color.NRGBA{200, 80, 30, 255})
+	// 不透明的 *image.RGBA，Min 不在原点，检验转换为 NRGBA 后的坐标偏移
+	rgba := image.NewRGBA(image.Rect(5, 5, 25, 17))
+	draw.Draw(rgba, rgba.Rect, photoImage(20, 12), image.Point{}, draw.Src)
+
+	tests := []struct {
+		name string
+		r    image.Rectangle
+		src  image.Image
+		sp   image.Point
+	}{
+		{"inside", image.Rect(6, 6, 26, 18), layer, image.Point{}},
+		{"top-left", image.Rect(-8, -4, 12, 8), layer, image.Point{}},
+		{"bottom-right", image.Rect(20, 24, 40, 36), layer, image.Point{}},
+		{"src-offset", image.Rect(0, 0, 32, 32), layer, image.Pt(5, 3)},
+		{"non-nrgba", image.Rect(-3, 10, 17, 22), rgba, image.Pt(5, 5)},
+		{"outside", image.Rect(40, 40, 60, 52), layer, image.Point{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, opaque := range []bool{true, false} {
+				want := blendBase(32, 32, opaque)
+				got := image.NewRGBA(want.Rect)
+				copy(got.Pix, want.Pix)
+				draw.Draw(want, tt.r, tt.src, tt.sp, draw.Over)
+				blendDraw(got, tt.r, tt.src, tt.sp, "test-normal")
+				if d := maxPixelDelta(want, got); d > 1 {
+					t.Errorf("opaque=%t: 与 draw.Draw 的最大差值为 %d", opaque, d)
+				}
+			}
+		})
+	}
+}
+
+func TestBlendNeutralColors(t *testing.T) {
+	tests := []struct {
+		mode  string
+		color color.NRGBA
+	}{
+		{BlendMultiply, color.NRGBA{255, 255, 255, 255}},
+		{BlendScreen, color.NRGBA{0, 0, 0, 255}},
+		{BlendDifference, color.NRGBA{0, 0, 0, 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			want := blendBase(32, 32, true)
+			got := image.NewRGBA(want.Rect)
+			copy(got.Pix, want.Pix)
+			blendDraw(got, got.Rect, blendLayer(32, 32, tt.color), image.Point{}, tt.mode)
+			if d := maxPixelDelta(want, got); d != 0 {
+				t.Errorf("与 %v 混合后底图的最大变化为 %d，期望不变", tt.color, d)
+			}
+		})
+	}
+}
+
+func TestBlendDirection(t *testing.T) {
+	gray := color.NRGBA{128, 128, 128, 255}
+	for mode, darken := range map[string]bool{BlendMultiply: true, BlendScreen: false} {
+		t.Run(mode, func(t *testing.T) {
+			base := blendBase(16, 16, true)
+			got := image.NewRGBA(base.Rect)
+			copy(got.Pix, base.Pix)
+			blendDraw(got, got.Rect, blendLayer(16, 16, gray), image.Point{}, mode)
+			for i := range got.Pix {
+				if b, g := int(base.Pix[i]), int(got.Pix[i]); i%4 != 3 && (darken && g > b || !darken && g < b) {
+					t.Fatalf("第 %d 字节由 %d 变为 %d，期望变暗=%t", i, b, g, darken)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"image"
 
@@ -15,24 +16,53 @@ type CropOptions struct {
 	Height int // 裁剪高度
 }
 
+// CropOperation 裁剪操作
+type CropOperation struct {
+	Options CropOptions
+}
+
+// Apply 裁剪图像
+func (op CropOperation) Apply(ctx context.Context, img image.Image) (image.Image, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return cropImage(img, op.Options)
+}
+
+func init() {
+	Register(OperationSpec{
+		Name:        "crop",
+		Description: "裁剪图像",
+		Params: []ParamSpec{
+			{Name: "x", Type: ParamInt, Default: "0", Description: "起始 X 坐标"},
+			{Name: "y", Type: ParamInt, Default: "0", Description: "起始 Y 坐标"},
+			{Name: "width", Aliases: []string{"w"}, Type: ParamInt, Description: "裁剪宽度（必需）"},
+			{Name: "height", Aliases: []string{"h"}, Type: ParamInt, Description: "裁剪高度（必需）"},
+		},
+		New: func(p Params) (Operation, error) {
+			opts := CropOptions{X: p.Int("x"), Y: p.Int("y"), Width: p.Int("width"), Height: p.Int("height")}
+			if opts.Width <= 0 || opts.Height <= 0 {
+				return nil, p.Errorf("必须指定正的 width 和 height")
+			}
+			return CropOperation{Options: opts}, nil
+		},
+	})
+}
+
 // Crop 裁剪图像
 func Crop(inputPath, outputPath string, opts CropOptions) error {
-	// 打开图像
-	img, err := imaging.Open(inputPath)
-	if err != nil {
-		return fmt.Errorf("无法打开图像: %w", err)
+	return ProcessFile(context.Background(), inputPath, outputPath, DefaultDecodeOptions(), DefaultEncodeOptions(), CropOperation{Options: opts})
+}
+
+func cropImage(img image.Image, opts CropOptions) (image.Image, error) {
+	if opts.Width <= 0 || opts.Height <= 0 {
+		return nil, fmt.Errorf("%w: 裁剪宽度和高度必须大于 0", ErrInvalidOptions)
 	}
 
-	// 裁剪
 	rect := image.Rect(opts.X, opts.Y, opts.X+opts.Width, opts.Y+opts.Height)
-	result := imaging.Crop(img, rect)
-
-	// 保存结果
-	if err := imaging.Save(result, outputPath); err != nil {
-		return fmt.Errorf("无法保存图像: %w", err)
+	if !rect.Overlaps(img.Bounds()) {
+		return nil, fmt.Errorf("%w: 裁剪区域 %v 超出图像范围 %v", ErrInvalidOptions, rect, img.Bounds())
 	}
-
-	fmt.Printf("✅ 图像已保存至: %s\n", outputPath)
-	return nil
+	return imaging.Crop(img, rect), nil
 }
 
@@ -0,0 +1,385 @@
+package processor
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// 色阶和曲线作用的通道
+const (
+	ChannelRGB   = "rgb"   // 同时作用于 R、G、B
+	ChannelRed   = "red"   // 红色通道
+	ChannelGreen = "green" // 绿色通道
+	ChannelBlue  = "blue"  // 蓝色通道
+)
+
+// Levels 色阶：将输入的黑场到白场拉伸到输出范围，中间调按 Gamma 调整。
+// 取值均为 0-255，OutBlack 和 OutWhite 均为 0 时输出范围为 0-255
+type Levels struct {
+	Channel  string  // 通道，空表示 rgb
+	InBlack  float64 // 输入黑场，低于它的值变为 OutBlack
+	InWhite  float64 // 输入白场，高于它的值变为 OutWhite
+	Gamma    float64 // 中间调 Gamma，大于 1 提亮，0 表示 1
+	OutBlack float64 // 输出黑场
+	OutWhite float64 // 输出白场
+}
+
+// CurvePoint 曲线的控制点，取值均为 0-255
+type CurvePoint struct {
+	X, Y float64
+}
+
+// Curve 色调曲线，控制点之间用单调三次样条插值（Fritsch-Carlson），
+// 控制点单调时曲线也单调，不会出现过冲；第一个点之前和最后一个点之后保持水平
+type Curve struct {
+	Channel string // 通道，空表示 rgb
+	Points  []CurvePoint
+}
+
+// Validate 检查色阶参数
+func (l Levels) Validate() error {
+	if err := validateChannel(l.Channel); err != nil {
+		return err
+	}
+	for _, v := range []float64{l.InBlack, l.InWhite, l.OutBlack, l.OutWhite} {
+		if v < 0 || v > 255 {
+			return fmt.Errorf("%w: 色阶的取值必须在 0-255 之间", ErrInvalidOptions)
+		}
+	}
+	if l.InBlack >= l.InWhite {
+		return fmt.Errorf("%w: 色阶的输入黑场 (%g) 必须小于白场 (%g)", ErrInvalidOptions, l.InBlack, l.InWhite)
+	}
+	if l.Gamma < 0 || l.Gamma > 0 && (l.Gamma < 0.1 || l.Gamma > 10) {
+		return fmt.Errorf("%w: 色阶的 Gamma 必须在 0.1-10 之间", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// Validate 检查曲线的控制点
+func (c Curve) Validate() error {
+	if err := validateChannel(c.Channel); err != nil {
+		return err
+	}
+	if len(c.Points) < 2 {
+		return fmt.Errorf("%w: 曲线至少需要 2 个控制点", ErrInvalidOptions)
+	}
+	seen := make(map[float64]bool, len(c.Points))
+	for _, p := range c.Points {
+		if p.X < 0 || p.X > 255 || p.Y < 0 || p.Y > 255 {
+			return fmt.Errorf("%w: 曲线控制点 (%g,%g) 超出 0-255 的范围", ErrInvalidOptions, p.X, p.Y)
+		}
+		if seen[p.X] {
+			return fmt.Errorf("%w: 曲线中有多个输入值为 %g 的控制点", ErrInvalidOptions, p.X)
+		}
+		seen[p.X] = true
+	}
+	return nil
+}
+
+func validateChannel(channel string) error {
+	switch channel {
+	case "", ChannelRGB, ChannelRed, ChannelGreen, ChannelBlue:
+		return nil
+	}
+	return fmt.Errorf("%w: 未知的通道 %q（可选: rgb, red, green, blue）", ErrInvalidOptions, channel)
+}
+
+// ParseLevels 解析色阶，格式为 [通道:]输入黑场,输入白场[,Gamma[,输出黑场,输出白场]]，
+// 如 "10,245"、"10,245,1.2"、"blue:0,255,1,0,235"。多组色阶用 ; 分隔
+func ParseLevels(s string) ([]Levels, error) {
+	var list []Levels
+	for _, spec := range splitSpecs(s) {
+		channel, body := splitChannel(spec)
+		values, err := parseNumbers(strings.Split(body, ","))
+		if err != nil {
+			return nil, fmt.Errorf("%w: 无效的色阶 %q: %v", ErrInvalidOptions, spec, err)
+		}
+		l := Levels{Channel: channel, Gamma: 1, OutBlack: 0, OutWhite: 255}
+		switch len(values) {
+		case 5:
+			l.OutBlack, l.OutWhite = values[3], values[4]
+			fallthrough
+		case 3:
+			l.Gamma = values[2]
+			fallthrough
+		case 2:
+			l.InBlack, l.InWhite = values[0], values[1]
+		default:
+			return nil, fmt.Errorf("%w: 无效的色阶 %q（格式: [通道:]黑场,白场[,Gamma[,输出黑场,输出白场]]）", ErrInvalidOptions, spec)
+		}
+		if err := l.Validate(); err != nil {
+			return nil, err
+		}
+		list = append(list, l)
+	}
+	return list, nil
+}
+
+// String 返回 ParseLevels 可解析的形式，省略默认值
+func (l Levels) String() string {
+	gamma := l.Gamma
+	if gamma == 0 {
+		gamma = 1
+	}
+	values := []float64{l.InBlack, l.InWhite}
+	switch {
+	case l.OutBlack != 0 || l.OutWhite != 0 && l.OutWhite != 255:
+		values = append(values, gamma, l.OutBlack, l.OutWhite)
+	case gamma != 1:
+		values = append(values, gamma)
+	}
+	fields := make([]string, len(values))
+	for i, v := range values {
+		fields[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	spec := strings.Join(fields, ",")
+	if l.Channel != "" && l.Channel != ChannelRGB {
+		spec = l.Channel + ":" + spec
+	}
+	return spec
+}
+
+// ParseCurves 解析曲线，格式为 [通道:]x,y x,y ...，如 "0,0 64,56 192,200 255,255"、
+// "red:0,0 128,140 255,255"。多条曲线用 ; 分隔
+func ParseCurves(s string) ([]Curve, error) {
+	var list []Curve
+	for _, spec := range splitSpecs(s) {
+		channel, body := splitChannel(spec)
+		c := Curve{Channel: channel}
+		for _, field := range strings.Fields(body) {
+			xy, err := parseNumbers(strings.Split(field, ","))
+			if err != nil || len(xy) != 2 {
+				return nil, fmt.Errorf("%w: 无效的曲线控制点 %q（格式: x,y）", ErrInvalidOptions, field)
+			}
+			c.Points = append(c.Points, CurvePoint{X: xy[0], Y: xy[1]})
+		}
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, nil
+}
+
+// LoadCurveFile 读取曲线文件，用于分享调色风格。每行为一条色阶、曲线或 HSL 调整：
+//
+//	# 注释
+//	levels 8,248,1.05
+//	curve 0,0 64,58 128,132 192,204 255,250
+//	curve blue:0,12 255,240
+//	hsl orange:s=-15; blue:s=10,l=-10
+//
+// 返回的 AdjustOptions 只设置了 Levels、Curves 和 HSL
+func LoadCurveFile(path string) (AdjustOptions, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return AdjustOptions{}, fmt.Errorf("无法打开曲线文件: %w", err)
+	}
+	defer f.Close()
+
+	opts, err := ParseCurveFile(f)
+	if err != nil {
+		return AdjustOptions{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return opts, nil
+}
+
+// ParseCurveFile 解析曲线文件的内容，格式见 LoadCurveFile
+func ParseCurveFile(r io.Reader) (AdjustOptions, error) {
+	var opts AdjustOptions
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = strings.TrimSpace(text[:i])
+		}
+		if text == "" {
+			continue
+		}
+		keyword, spec, _ := strings.Cut(text, " ")
+		var err error
+		switch keyword {
+		case "levels":
+			var l []Levels
+			l, err = ParseLevels(spec)
+			opts.Levels = append(opts.Levels, l...)
+		case "curve":
+			var c []Curve
+			c, err = ParseCurves(spec)
+			opts.Curves = append(opts.Curves, c...)
+		case "hsl":
+			var h []HSLAdjustment
+			h, err = ParseHSL(spec)
+			opts.HSL = append(opts.HSL, h...)
+		default:
+			err = fmt.Errorf("%w: 未知的关键字 %q（可选: levels, curve, hsl）", ErrInvalidOptions, keyword)
+		}
+		if err == nil && len(splitSpecs(spec)) == 0 {
+			err = fmt.Errorf("%w: %s 后缺少内容", ErrInvalidOptions, keyword)
+		}
+		if err != nil {
+			return AdjustOptions{}, fmt.Errorf("第 %d 行: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return AdjustOptions{}, fmt.Errorf("读取曲线文件失败: %w", err)
+	}
+	return opts, nil
+}
+
+func splitSpecs(s string) []string {
+	var specs []string
+	for _, spec := range strings.Split(s, ";") {
+		if spec = strings.TrimSpace(spec); spec != "" {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// splitChannel 拆分 "通道:内容"，没有通道前缀时通道为 rgb
+func splitChannel(spec string) (string, string) {
+	if channel, body, ok := strings.Cut(spec, ":"); ok {
+		return strings.ToLower(strings.TrimSpace(channel)), strings.TrimSpace(body)
+	}
+	return ChannelRGB, spec
+}
+
+func parseNumbers(fields []string) ([]float64, error) {
+	values := make([]float64, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q 不是数字", strings.TrimSpace(f))
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// apply 返回色阶对输入值 v (0-255) 的输出
+func (l Levels) apply(v float64) float64 {
+	t := math.Max(0, math.Min(1, (v-l.InBlack)/(l.InWhite-l.InBlack)))
+	if l.Gamma != 0 && l.Gamma != 1 {
+		t = math.Pow(t, 1/l.Gamma)
+	}
+	outBlack, outWhite := l.OutBlack, l.OutWhite
+	if outBlack == 0 && outWhite == 0 {
+		outWhite = 255
+	}
+	return outBlack + t*(outWhite-outBlack)
+}
+
+// spline 返回曲线的插值函数
+func (c Curve) spline() func(x float64) float64 {
+	pts := append([]CurvePoint(nil), c.Points...)
+	sort.Slice(pts, func(i, j int) bool { return pts[i].X < pts[j].X })
+	n := len(pts)
+
+	// 各段斜率和 Fritsch-Carlson 切线
+	delta := make([]float64, n-1)
+	for k := range delta {
+		delta[k] = (pts[k+1].Y - pts[k].Y) / (pts[k+1].X - pts[k].X)
+	}
+	m := make([]float64, n)
+	m[0], m[n-1] = delta[0], delta[n-2]
+	for k := 1; k < n-1; k++ {
+		if delta[k-1]*delta[k] > 0 {
+			m[k] = (delta[k-1] + delta[k]) / 2
+		}
+	}
+	for k, d := range delta {
+		if d == 0 {
+			m[k], m[k+1] = 0, 0
+			continue
+		}
+		a, b := m[k]/d, m[k+1]/d
+		if s := a*a + b*b; s > 9 {
+			tau := 3 / math.Sqrt(s)
+			m[k], m[k+1] = tau*a*d, tau*b*d
+		}
+	}
+
+	return func(x float64) float64 {
+		if x <= pts[0].X {
+			return pts[0].Y
+		}
+		if x >= pts[n-1].X {
+			return pts[n-1].Y
+		}
+		k := sort.Search(n-1, func(i int) bool { return pts[i+1].X >= x })
+		h := pts[k+1].X - pts[k].X
+		t := (x - pts[k].X) / h
+		t2, t3 := t*t, t*t*t
+		return (2*t3-3*t2+1)*pts[k].Y + (t3-2*t2+t)*h*m[k] +
+			(-2*t3+3*t2)*pts[k+1].Y + (t3-t2)*h*m[k+1]
+	}
+}
+
+// applyLevelsCurves 依次应用色阶和曲线，每一组中先应用 rgb 再应用单独的通道
+func applyLevelsCurves(img image.Image, levels []Levels, curves []Curve) image.Image {
+	var lut [3][256]float64
+	for c := range lut {
+		for v := range lut[c] {
+			lut[c][v] = float64(v)
+		}
+	}
+	channels := func(channel string) []int {
+		switch channel {
+		case ChannelRed:
+			return []int{0}
+		case ChannelGreen:
+			return []int{1}
+		case ChannelBlue:
+			return []int{2}
+		}
+		return []int{0, 1, 2}
+	}
+	// rgb 排在单独的通道之前
+	order := func(channel string) int {
+		if channel == "" || channel == ChannelRGB {
+			return 0
+		}
+		return 1
+	}
+
+	sorted := append([]Levels(nil), levels...)
+	sort.SliceStable(sorted, func(i, j int) bool { return order(sorted[i].Channel) < order(sorted[j].Channel) })
+	for _, l := range sorted {
+		for _, c := range channels(l.Channel) {
+			for v := range lut[c] {
+				lut[c][v] = l.apply(lut[c][v])
+			}
+		}
+	}
+	sortedCurves := append([]Curve(nil), curves...)
+	sort.SliceStable(sortedCurves, func(i, j int) bool { return order(sortedCurves[i].Channel) < order(sortedCurves[j].Channel) })
+	for _, curve := range sortedCurves {
+		f := curve.spline()
+		for _, c := range channels(curve.Channel) {
+			for v := range lut[c] {
+				lut[c][v] = f(lut[c][v])
+			}
+		}
+	}
+
+	var table [3][256]uint8
+	for c := range table {
+		for v := range table[c] {
+			table[c][v] = uint8(math.Round(clamp(lut[c][v])))
+		}
+	}
+	return imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		return color.NRGBA{R: table[0][c.R], G: table[1][c.G], B: table[2][c.B], A: c.A}
+	})
+}
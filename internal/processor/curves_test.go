@@ -0,0 +1,107 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCurveMonotonic(t *testing.T) {
+	tests := []string{
+		"0,0 64,56 192,200 255,255",
+		"0,0 10,200 20,210 255,255",   // 陡峭后平缓，普通三次样条会过冲
+		"0,0 128,128 129,250 255,255", // 相邻控制点间的跳变
+		"0,0 64,100 192,100 255,255",  // 中间的水平段
+		"192,200 0,0 64,56 255,255",   // 控制点无序
+		"0,255 100,240 110,20 255,0",  // 单调递减
+		"32,16 224,240",
+	}
+	for _, spec := range tests {
+		t.Run(spec, func(t *testing.T) {
+			curves, err := ParseCurves(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pts := curves[0].Points
+			f := curves[0].spline()
+			for _, p := range pts {
+				if got := f(p.X); math.Abs(got-p.Y) > 1e-9 {
+					t.Errorf("曲线在控制点 x=%g 处为 %g，期望 %g", p.X, got, p.Y)
+				}
+			}
+
+			increasing := f(255) >= f(0)
+			prev := f(0)
+			for x := 0.25; x <= 255; x += 0.25 {
+				y := f(x)
+				if increasing && y < prev-1e-9 || !increasing && y > prev+1e-9 {
+					t.Fatalf("曲线在 x=%g 处不单调: %g -> %g", x, prev, y)
+				}
+				prev = y
+			}
+
+			// 每一段都不超出两端控制点的范围
+			for i := 0; i+1 < len(pts); i++ {
+				a, b := pts[i], pts[i+1]
+				if a.X > b.X {
+					continue
+				}
+				lo, hi := math.Min(a.Y, b.Y), math.Max(a.Y, b.Y)
+				for x := a.X; x <= b.X; x += 0.25 {
+					if y := f(x); y < lo-1e-9 || y > hi+1e-9 {
+						t.Fatalf("x=%g 处的值 %g 超出控制点 %v 与 %v 之间的范围", x, y, a, b)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParseCurveFile(t *testing.T) {
+	file := strings.Join([]string{
+		"# 胶片风格",
+		"",
+		"levels 8,248,1.05   # 行尾注释",
+		"  curve 0,0 64,58 128,132 192,204 255,250",
+		"curve blue:0,12 255,240; red:0,0 255,250",
+		"hsl orange:s=-15; blue:s=10,l=-10",
+	}, "\n")
+	opts, err := ParseCurveFile(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.Levels) != 1 || len(opts.Curves) != 3 || len(opts.HSL) != 2 {
+		t.Errorf("解析出 %d 组色阶、%d 条曲线、%d 个 HSL 调整，期望 1、3、2", len(opts.Levels), len(opts.Curves), len(opts.HSL))
+	}
+	if opts.Levels[0].Gamma != 1.05 || opts.Curves[1].Channel != ChannelBlue {
+		t.Errorf("解析结果不正确: %+v", opts)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		line int
+	}{
+		{"unknown directive", "levels 8,248\n\nlevle 8,248", 3},
+		{"bad levels", "# 注释\nlevels 8,x", 2},
+		{"bad levels range", "levels 248,8", 1},
+		{"bad curve point", "curve 0,0 64", 1},
+		{"single curve point", "levels 8,248\ncurve 0,0", 2},
+		{"bad channel", "curve cyan:0,0 255,255", 1},
+		{"bad hsl", "hsl pink:h=5", 1},
+		{"keyword only", "\ncurve", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCurveFile(strings.NewReader(tt.text))
+			if !errors.Is(err, ErrInvalidOptions) {
+				t.Fatalf("期望 ErrInvalidOptions，实际为 %v", err)
+			}
+			if want := fmt.Sprintf("第 %d 行", tt.line); !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("错误信息应以 %q 开头: %v", want, err)
+			}
+		})
+	}
+}
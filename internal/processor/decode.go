@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+
+	"github.com/disintegration/imaging"
+	"github.com/xiaoheiwowo/xpix/internal/config"
+	"github.com/xiaoheiwowo/xpix/internal/metadata"
+)
+
+// DecodeOptions 解码选项
+type DecodeOptions struct {
+	AutoOrient bool // 按 EXIF 方向标签旋转/翻转图像（仅 JPEG）
+}
+
+// DefaultDecodeOptions 返回配置文件中的解码选项
+func DefaultDecodeOptions() DecodeOptions {
+	cfg := config.Get()
+	return DecodeOptions{AutoOrient: cfg.Input.AutoOrient}
+}
+
+// Decode 按解码选项解码图像数据
+func Decode(data []byte, opts DecodeOptions) (image.Image, error) {
+	return imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(opts.AutoOrient))
+}
+
+// resetOrientation 图像已按方向摆正后，将元数据中的方向标签重置为 1（正常）。
+// imaging 只按 JPEG 中的方向标签摆正图像，其他格式的像素保持原样，方向标签也随之保留
+func resetOrientation(data []byte, opts DecodeOptions, meta *metadata.Metadata) {
+	if !opts.AutoOrient || !bytes.HasPrefix(data, []byte{0xff, 0xd8}) || len(meta.EXIF) == 0 {
+		return
+	}
+	exif, err := metadata.UpdateEXIF(meta.EXIF, metadata.Update{Orientation: 1})
+	if err != nil {
+		// 保留原数据，保存时会再次报告并丢弃损坏的 EXIF
+		return
+	}
+	meta.EXIF = exif
+}
+
+// orientationNames EXIF 方向标签的含义
+var orientationNames = map[int]string{
+	1: "正常",
+	2: "水平翻转",
+	3: "旋转 180°",
+	4: "垂直翻转",
+	5: "水平翻转后逆时针旋转 90°",
+	6: "顺时针旋转 90°",
+	7: "水平翻转后顺时针旋转 90°",
+	8: "逆时针旋转 90°",
+}
+
+// describeOrientation 返回方向标签及其含义
+func describeOrientation(orientation int) string {
+	if name, ok := orientationNames[orientation]; ok {
+		return fmt.Sprintf("%d（%s）", orientation, name)
+	}
+	return fmt.Sprint(orientation)
+}
+
+// orientedSize 返回按方向标签摆正后的显示尺寸，方向 5-8 会交换宽高
+func orientedSize(width, height, orientation int) (int, int) {
+	if orientation >= 5 && orientation <= 8 {
+		return height, width
+	}
+	return width, height
+}
@@ -0,0 +1,53 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/xiaoheiwowo/xpix/internal/metadata"
+)
+
+// orientedEXIF 返回 IFD0 中只有方向标签的小端序 EXIF
+func orientedEXIF(orientation uint16) []byte {
+	bo := binary.LittleEndian
+	b := []byte("II*\x00\x08\x00\x00\x00\x01\x00")
+	b = bo.AppendUint16(b, 0x0112)
+	b = bo.AppendUint16(b, 3)
+	b = bo.AppendUint32(b, 1)
+	b = bo.AppendUint16(b, orientation)
+	b = append(b, 0, 0)
+	return bo.AppendUint32(b, 0)
+}
+
+func TestResetOrientation(t *testing.T) {
+	var jpeg, png bytes.Buffer
+	img := gradientImage(8, 8, false)
+	if err := Encode(&jpeg, img, "", EncodeOptions{Format: FormatJPEG}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Encode(&png, img, "", EncodeOptions{Format: FormatPNG}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		opts DecodeOptions
+		want uint16
+	}{
+		{"jpeg", jpeg.Bytes(), DecodeOptions{AutoOrient: true}, 1},
+		{"jpeg-no-auto-orient", jpeg.Bytes(), DecodeOptions{}, 6},
+		// imaging 不摆正 PNG 的像素，方向标签必须保留
+		{"png", png.Bytes(), DecodeOptions{AutoOrient: true}, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := &metadata.Metadata{EXIF: orientedEXIF(6)}
+			resetOrientation(tt.data, tt.opts, meta)
+			if got := binary.LittleEndian.Uint16(meta.EXIF[18:]); got != tt.want {
+				t.Errorf("方向标签为 %d，期望 %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package processor
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+// 去雾（暗通道先验，He 等，2009）
+//
+// 无雾图像的大部分局部区域中总有某个通道接近 0，有雾时这些区域被大气光抬亮，
+// 因此局部最暗通道（暗通道）反映了雾的浓度。先从暗通道最亮的部分估计大气光 A，
+// 再由 t = 1 - ω·暗通道(I/A) 得到透射率，用引导滤波沿物体边缘细化，
+// 最后按 J = (I - A) / t + A 恢复无雾图像。
+//
+// 去雾强度决定透射率的下限：强度越大下限越低，浓雾区域恢复得越多；
+// 负值反过来按 I·t + A·(1-t) 加雾，远处（透射率低）的区域加得更多。
+
+const (
+	dehazeOmega     = 0.95  // 保留少量雾气使远景有层次
+	dehazeTopRatio  = 0.001 // 估计大气光时取暗通道最亮的像素比例
+	dehazeMinFloor  = 0.1   // 强度为 100 时的透射率下限
+	dehazeEps       = 1e-3  // 细化透射率的引导滤波正则化参数
+	hazeUniform     = 0.3   // 加雾强度为 100 时均匀加入的雾
+	hazeDepth       = 0.4   // 加雾强度为 100 时按透射率额外加入的雾
+	dehazeGridSize  = 512   // 估计暗通道和透射率的网格短边上限
+	dehazePatchSize = 64    // 暗通道的窗口半径为网格短边的 1/dehazePatchSize
+)
+
+// applyDehaze 应用去雾，strength 为 -100 到 100，负值加雾
+func applyDehaze(img image.Image, strength float64) image.Image {
+	dst := imaging.Clone(img)
+	w, h := dst.Rect.Dx(), dst.Rect.Dy()
+	if w == 0 || h == 0 {
+		return dst
+	}
+	s := max(-1, min(strength/100, 1))
+
+	// 在缩小的网格上估计大气光和透射率
+	small := dst
+	if short := min(w, h); short > dehazeGridSize {
+		small = imaging.Resize(dst, w*dehazeGridSize/short, h*dehazeGridSize/short, imaging.Box)
+	}
+	gw, gh := small.Rect.Dx(), small.Rect.Dy()
+	radius := max(3, min(gw, gh)/dehazePatchSize)
+
+	dark := darkChannel(small, [3]float64{1, 1, 1}, radius)
+	a := atmosphericLight(small, dark)
+	transmission := darkChannel(small, a, radius)
+	gray := make([]float64, gw*gh)
+	for i := range transmission {
+		transmission[i] = 1 - dehazeOmega*transmission[i]
+		gray[i] = luminance(small.Pix[(i/gw)*small.Stride+(i%gw)*4:])
+	}
+
+	// 以亮度为引导细化透射率，系数放大到原图尺寸后按原图亮度求出每个像素的透射率
+	ca, cb := guidedCoefficients(gray, transmission, gw, gh, radius*2, dehazeEps)
+	sampleA, sampleB := upsampler(ca, gw, gh, w, h), upsampler(cb, gw, gh, w, h)
+	floor := 1 - s*(1-dehazeMinFloor)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := dst.Pix[y*dst.Stride+x*4:]
+			t := max(0, min(sampleA(x, y)*luminance(p)+sampleB(x, y), 1))
+			for c := 0; c < 3; c++ {
+				v := float64(p[c]) / 255
+				if s > 0 {
+					v = (v-a[c])/max(t, floor) + a[c]
+				} else {
+					k := -s * (hazeUniform + hazeDepth*(1-t))
+					v = v*(1-k) + a[c]*k
+				}
+				p[c] = uint8(math.Round(clamp(v * 255)))
+			}
+		}
+	}
+	return dst
+}
+
+// darkChannel 返回各通道除以 a 后的最小值在半径为 radius 的窗口内的最小值（0-1）
+func darkChannel(img *image.NRGBA, a [3]float64, radius int) []float64 {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dark := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := img.Pix[y*img.Stride+x*4:]
+			v := 1.0
+			for c := 0; c < 3; c++ {
+				v = min(v, float64(p[c])/255/a[c])
+			}
+			dark[y*w+x] = v
+		}
+	}
+	return minFilter(dark, w, h, radius)
+}
+
+// minFilter 返回半径为 radius 的方框内的最小值，先按行再按列分两次求
+func minFilter(src []float64, w, h, radius int) []float64 {
+	tmp := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := src[y*w+x]
+			for i := max(x-radius, 0); i < min(x+radius+1, w); i++ {
+				v = min(v, src[y*w+i])
+			}
+			tmp[y*w+x] = v
+		}
+	}
+	dst := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := tmp[y*w+x]
+			for i := max(y-radius, 0); i < min(y+radius+1, h); i++ {
+				v = min(v, tmp[i*w+x])
+			}
+			dst[y*w+x] = v
+		}
+	}
+	return dst
+}
+
+// atmosphericLight 取暗通道最亮的少量像素，返回它们颜色的平均值作为大气光（0-1）
+func atmosphericLight(img *image.NRGBA, dark []float64) [3]float64 {
+	w := img.Rect.Dx()
+	index := make([]int, len(dark))
+	for i := range index {
+		index[i] = i
+	}
+	sort.Slice(index, func(i, j int) bool { return dark[index[i]] > dark[index[j]] })
+	n := max(1, int(float64(len(dark))*dehazeTopRatio))
+
+	var a [3]float64
+	for _, i := range index[:n] {
+		p := img.Pix[(i/w)*img.Stride+(i%w)*4:]
+		for c := 0; c < 3; c++ {
+			a[c] += float64(p[c]) / 255 / float64(n)
+		}
+	}
+	// 避免除以 0：大气光不低于很暗的灰
+	for c := range a {
+		a[c] = max(a[c], 0.05)
+	}
+	return a
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// clearScene 返回由 8x8 色块组成的无雾场景，每个色块都有一个接近 0 的通道，符合暗通道先验
+func clearScene(w, h int) *image.NRGBA {
+	r := rand.New(rand.NewSource(3))
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for by := 0; by < h; by += 8 {
+		for bx := 0; bx < w; bx += 8 {
+			c := [3]uint8{uint8(40 + r.Intn(200)), uint8(40 + r.Intn(200)), uint8(40 + r.Intn(200))}
+			c[r.Intn(3)] = uint8(r.Intn(20))
+			for y := by; y < min(by+8, h); y++ {
+				for x := bx; x < min(bx+8, w); x++ {
+					img.SetNRGBA(x, y, color.NRGBA{c[0], c[1], c[2], 255})
+				}
+			}
+		}
+	}
+	return img
+}
+
+// hazeScene 按 I = J·t + A·(1-t) 加入浅灰色的雾，透射率从上到下由 0.8 降到 0.3
+func hazeScene(img *image.NRGBA) *image.NRGBA {
+	const a = 0.9 * 255
+	hazed := imaging.Clone(img)
+	h := hazed.Rect.Dy()
+	for y := 0; y < h; y++ {
+		t := 0.8 - 0.5*float64(y)/float64(h-1)
+		for x := 0; x < hazed.Rect.Dx(); x++ {
+			p := hazed.Pix[y*hazed.Stride+x*4:]
+			for c := 0; c < 3; c++ {
+				p[c] = uint8(math.Round(float64(p[c])*t + a*(1-t)))
+			}
+		}
+	}
+	return hazed
+}
+
+// lumaStdDev 返回亮度的标准差，用于衡量对比度
+func lumaStdDev(img image.Image) float64 {
+	n := imaging.Clone(img)
+	var sum, sq float64
+	count := float64(n.Rect.Dx() * n.Rect.Dy())
+	for i := 0; i < len(n.Pix); i += 4 {
+		l := luminance(n.Pix[i:])
+		sum += l
+		sq += l * l
+	}
+	mean := sum / count
+	return math.Sqrt(sq/count - mean*mean)
+}
+
+func TestDehazeIdentity(t *testing.T) {
+	img := hazeScene(clearScene(96, 64))
+	if maxDelta, _ := compareImages(t, img, applyDehaze(img, 0)); maxDelta != 0 {
+		t.Errorf("强度为 0 时最大误差为 %d，期望不变", maxDelta)
+	}
+}
+
+func TestDehazeContrast(t *testing.T) {
+	clear := clearScene(96, 64)
+	hazed := hazeScene(clear)
+	base := lumaStdDev(hazed)
+	_, hazedPSNR := compareImages(t, clear, hazed)
+
+	// 强度越大越接近无雾场景；对比度在强度较大时会因截断略有回落，只要求高于加雾图像
+	prevPSNR := hazedPSNR
+	for _, strength := range []float64{30, 60, 100} {
+		got := applyDehaze(hazed, strength)
+		if contrast := lumaStdDev(got); contrast <= base {
+			t.Errorf("强度 %g 的亮度标准差为 %.4f，期望大于加雾图像的 %.4f", strength, contrast, base)
+		}
+		_, psnr := compareImages(t, clear, got)
+		if psnr <= prevPSNR {
+			t.Errorf("强度 %g 去雾后与无雾场景的 PSNR 为 %.2f dB，期望高于 %.2f dB", strength, psnr, prevPSNR)
+		}
+		prevPSNR = psnr
+	}
+
+	if contrast := lumaStdDev(applyDehaze(hazed, -50)); contrast >= base {
+		t.Errorf("加雾后亮度标准差为 %.4f，期望小于 %.4f", contrast, base)
+	}
+}
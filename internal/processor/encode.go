@@ -0,0 +1,293 @@
+package processor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/xiaoheiwowo/xpix/internal/config"
+	"github.com/xiaoheiwowo/xpix/internal/metadata"
+	"github.com/xiaoheiwowo/xpix/internal/webp"
+	"golang.org/x/image/tiff"
+
+	// 注册 WebP 解码器，使 WebP 文件可以作为输入
+	_ "golang.org/x/image/webp"
+)
+
+// 输出格式
+const (
+	FormatAuto = "auto" // 根据输出文件扩展名推断
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatTIFF = "tiff"
+	FormatBMP  = "bmp"
+	FormatWebP = "webp"
+)
+
+// TIFF 压缩方式
+const (
+	TIFFDeflate = "deflate"
+	TIFFNone    = "none"
+)
+
+// DefaultQuality 默认 JPEG/WebP 质量
+const DefaultQuality = 95
+
+// metadataSoftware 写入输出文件 EXIF Software 标签的值
+const metadataSoftware = "xpix"
+
+// formatExts 各输出格式的标准扩展名
+var formatExts = map[string]string{
+	FormatJPEG: ".jpg",
+	FormatPNG:  ".png",
+	FormatGIF:  ".gif",
+	FormatTIFF: ".tif",
+	FormatBMP:  ".bmp",
+	FormatWebP: ".webp",
+}
+
+// extFormats 扩展名对应的输出格式
+var extFormats = map[string]string{
+	".jpg":  FormatJPEG,
+	".jpeg": FormatJPEG,
+	".png":  FormatPNG,
+	".gif":  FormatGIF,
+	".tif":  FormatTIFF,
+	".tiff": FormatTIFF,
+	".bmp":  FormatBMP,
+	".webp": FormatWebP,
+}
+
+// EncodeOptions 编码选项
+type EncodeOptions struct {
+	Format          string // 输出格式: auto, jpeg, png, gif, tiff, bmp, webp
+	Quality         int    // JPEG 和有损 WebP 的质量 (1-100)，0 表示默认值
+	Lossless        bool   // WebP 使用无损压缩
+	TIFFCompression string // TIFF 压缩方式: deflate, none，空表示 deflate
+	StripMetadata   bool   // 不把输入文件的 EXIF、XMP 和 ICC 元数据写入输出
+}
+
+// DefaultEncodeOptions 返回配置文件中的编码选项
+func DefaultEncodeOptions() EncodeOptions {
+	cfg := config.Get()
+	return EncodeOptions{
+		Format:          cfg.Output.Format,
+		Quality:         cfg.Output.Quality,
+		Lossless:        cfg.Output.Lossless,
+		TIFFCompression: cfg.Output.TIFFCompression,
+		StripMetadata:   cfg.Output.StripMetadata,
+	}
+}
+
+// NormalizeFormat 规范化输出格式名称（如 jpg -> jpeg），空字符串视为 auto
+func NormalizeFormat(format string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(format)); f {
+	case "", FormatAuto:
+		return FormatAuto, nil
+	case "jpg":
+		return FormatJPEG, nil
+	case "tif":
+		return FormatTIFF, nil
+	default:
+		if _, ok := formatExts[f]; ok {
+			return f, nil
+		}
+		return "", fmt.Errorf("%w: %s（可选: auto, jpeg, png, gif, tiff, bmp, webp）", ErrUnsupportedFormat, format)
+	}
+}
+
+// NormalizeTIFFCompression 规范化 TIFF 压缩方式，空字符串视为 deflate
+func NormalizeTIFFCompression(compression string) (string, error) {
+	switch c := strings.ToLower(strings.TrimSpace(compression)); c {
+	case "", TIFFDeflate:
+		return TIFFDeflate, nil
+	case TIFFNone:
+		return TIFFNone, nil
+	default:
+		return "", fmt.Errorf("%w: 不支持的 TIFF 压缩方式 %s（可选: deflate, none）", ErrInvalidOptions, compression)
+	}
+}
+
+// FormatExt 返回输出格式的标准扩展名，auto 返回空字符串
+func FormatExt(format string) string {
+	f, err := NormalizeFormat(format)
+	if err != nil {
+		return ""
+	}
+	return formatExts[f]
+}
+
+// FormatFromPath 根据文件扩展名推断输出格式
+func FormatFromPath(path string) (string, error) {
+	if f, ok := extFormats[strings.ToLower(filepath.Ext(path))]; ok {
+		return f, nil
+	}
+	return "", fmt.Errorf("%w: 无法根据扩展名 %q 推断输出格式", ErrUnsupportedFormat, filepath.Ext(path))
+}
+
+// OutputPath 按输出格式修正文件扩展名，auto 或扩展名已匹配时原样返回
+func (o EncodeOptions) OutputPath(path string) string {
+	f, err := NormalizeFormat(o.Format)
+	if err != nil || f == FormatAuto {
+		return path
+	}
+	if inferred, err := FormatFromPath(path); err == nil && inferred == f {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + formatExts[f]
+}
+
+// Validate 校验输出格式、质量和 TIFF 压缩方式
+func (o EncodeOptions) Validate() error {
+	if _, err := NormalizeFormat(o.Format); err != nil {
+		return err
+	}
+	if _, err := NormalizeTIFFCompression(o.TIFFCompression); err != nil {
+		return err
+	}
+	_, err := o.quality()
+	return err
+}
+
+// resolve 确定实际编码格式
+func (o EncodeOptions) resolve(path string) (string, error) {
+	f, err := NormalizeFormat(o.Format)
+	if err != nil {
+		return "", err
+	}
+	if f == FormatAuto {
+		return FormatFromPath(path)
+	}
+	return f, nil
+}
+
+// quality 返回有效的 JPEG/WebP 质量
+func (o EncodeOptions) quality() (int, error) {
+	if o.Quality == 0 {
+		return DefaultQuality, nil
+	}
+	if o.Quality < 1 || o.Quality > 100 {
+		return 0, fmt.Errorf("%w: 输出质量必须在 1-100 之间，得到 %d", ErrInvalidOptions, o.Quality)
+	}
+	return o.Quality, nil
+}
+
+// Encode 按编码选项将图像写入 w，auto 格式需要通过 name 推断
+func Encode(w io.Writer, img image.Image, name string, opts EncodeOptions) error {
+	format, err := opts.resolve(name)
+	if err != nil {
+		return err
+	}
+	quality, err := opts.quality()
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatWebP:
+		return webp.Encode(w, img, &webp.Options{Lossless: opts.Lossless, Quality: quality})
+	case FormatTIFF:
+		compression, err := NormalizeTIFFCompression(opts.TIFFCompression)
+		if err != nil {
+			return err
+		}
+		c := tiff.Deflate
+		if compression == TIFFNone {
+			c = tiff.Uncompressed
+		}
+		return tiff.Encode(w, img, &tiff.Options{Compression: c})
+	}
+
+	f, err := imaging.FormatFromExtension(format)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	return imaging.Encode(w, img, f, imaging.JPEGQuality(quality))
+}
+
+// SaveImage 按编码选项保存图像，返回修正扩展名后实际写入的路径。
+// meta 为输入文件的元数据，输出格式支持且未设置 StripMetadata 时一并写入；
+// 部分元数据无法写入而被丢弃时，原因作为 warnings 返回，不影响保存。
+func SaveImage(img image.Image, path string, opts EncodeOptions, meta *metadata.Metadata) (string, []error, error) {
+	path = opts.OutputPath(path)
+	format, err := opts.resolve(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, path, opts); err != nil {
+		return "", nil, err
+	}
+	data := buf.Bytes()
+	var warnings []error
+	if !opts.StripMetadata {
+		if data, warnings, err = embedMetadata(data, format, img.Bounds(), meta); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		os.Remove(path)
+		return "", nil, err
+	}
+	return path, warnings, nil
+}
+
+// embedMetadata 修正 EXIF 中的尺寸和软件标签后将元数据写入编码结果，
+// 返回被丢弃的元数据的原因
+func embedMetadata(data []byte, format string, bounds image.Rectangle, meta *metadata.Metadata) ([]byte, []error, error) {
+	if meta.Empty() || !metadata.Supported(format) {
+		return data, nil, nil
+	}
+
+	m := *meta
+	var warnings []error
+	if len(m.EXIF) > 0 {
+		exif, err := metadata.UpdateEXIF(m.EXIF, metadata.Update{
+			Width:    bounds.Dx(),
+			Height:   bounds.Dy(),
+			Software: metadataSoftware,
+		})
+		if err != nil {
+			// EXIF 损坏时只丢弃 EXIF，保留其余元数据
+			warnings = append(warnings, fmt.Errorf("%w，输出中不包含 EXIF", err))
+		}
+		m.EXIF = exif
+	}
+
+	out, err := metadata.Embed(data, format, &m)
+	if errors.Is(err, metadata.ErrTooLarge) {
+		// 超出格式限制（如 JPEG 单个段 64KB）的部分逐项丢弃，其余元数据照常写入
+		parts := []struct {
+			name  string
+			field *[]byte
+			only  metadata.Metadata
+		}{
+			{"EXIF", &m.EXIF, metadata.Metadata{EXIF: m.EXIF}},
+			{"XMP", &m.XMP, metadata.Metadata{XMP: m.XMP}},
+			{"ICC", &m.ICC, metadata.Metadata{ICC: m.ICC}},
+		}
+		for _, part := range parts {
+			if part.only.Empty() {
+				continue
+			}
+			if _, err := metadata.Embed(data, format, &part.only); errors.Is(err, metadata.ErrTooLarge) {
+				warnings = append(warnings, fmt.Errorf("%w，输出中不包含 %s", err, part.name))
+				*part.field = nil
+			}
+		}
+		out, err = metadata.Embed(data, format, &m)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("无法写入元数据（可使用 --strip-metadata 跳过）: %w", err)
+	}
+	return out, warnings, nil
+}
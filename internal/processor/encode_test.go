@@ -0,0 +1,213 @@
+package processor
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/xiaoheiwowo/xpix/internal/metadata"
+)
+
+// gradientImage 返回带平滑渐变的测试图像，alpha 为 false 时完全不透明。
+// alpha 从 64 开始，避免解码器经过预乘的颜色转换后丢失几乎透明的像素的颜色
+func gradientImage(w, h int, alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := uint8(255)
+			if alpha {
+				a = uint8(64 + x*191/(w-1))
+			}
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(x * 255 / (w - 1)),
+				G: uint8(y * 255 / (h - 1)),
+				B: uint8(128 + 64*math.Sin(float64(x+y)/8)),
+				A: a,
+			})
+		}
+	}
+	return img
+}
+
+// paletteImage 返回只包含 GIF 默认调色板 (Plan9) 颜色的测试图像，编码时不会产生量化误差
+func paletteImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, palette.Plan9[(x/8+y/8*7)%len(palette.Plan9)])
+		}
+	}
+	return img
+}
+
+// compareImages 返回两幅图像 RGBA 各通道的最大差值和 RGB 的峰值信噪比 (dB)
+func compareImages(t *testing.T, want, got image.Image) (int, float64) {
+	t.Helper()
+	if want.Bounds().Size() != got.Bounds().Size() {
+		t.Fatalf("尺寸不一致: 期望 %v，实际 %v", want.Bounds().Size(), got.Bounds().Size())
+	}
+	a, b := imaging.Clone(want), imaging.Clone(got)
+	maxDelta, sse := 0, 0.0
+	for i := range a.Pix {
+		d := int(a.Pix[i]) - int(b.Pix[i])
+		maxDelta = max(maxDelta, d, -d)
+		if i%4 != 3 {
+			sse += float64(d * d)
+		}
+	}
+	if sse == 0 {
+		return maxDelta, math.Inf(1)
+	}
+	mse := sse / float64(len(a.Pix)/4*3)
+	return maxDelta, 10 * math.Log10(255*255/mse)
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		img     *image.NRGBA
+		opts    EncodeOptions
+		minPSNR float64 // 0 表示必须无损
+		limited bool    // 解码结果按 VP8 规定的有限范围 BT.601 转换为 RGB
+	}{
+		{"jpeg", gradientImage(64, 48, false), EncodeOptions{Format: FormatJPEG, Quality: 95}, 35, false},
+		{"png", gradientImage(64, 48, true), EncodeOptions{Format: FormatPNG}, 0, false},
+		{"gif", paletteImage(64, 48), EncodeOptions{Format: FormatGIF}, 0, false},
+		{"bmp", gradientImage(64, 48, false), EncodeOptions{Format: FormatBMP}, 0, false},
+		{"tiff-deflate", gradientImage(64, 48, true), EncodeOptions{Format: FormatTIFF, TIFFCompression: TIFFDeflate}, 0, false},
+		{"tiff-none", gradientImage(64, 48, true), EncodeOptions{Format: FormatTIFF, TIFFCompression: TIFFNone}, 0, false},
+		{"webp-lossy", gradientImage(64, 48, false), EncodeOptions{Format: FormatWebP, Quality: 95}, 35, true},
+		{"webp-lossless", gradientImage(64, 48, true), EncodeOptions{Format: FormatWebP, Lossless: true}, 0, false},
+		{"webp-lossy-alpha", gradientImage(64, 48, true), EncodeOptions{Format: FormatWebP, Quality: 95}, 35, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, tt.img, "", tt.opts); err != nil {
+				t.Fatalf("编码失败: %v", err)
+			}
+			got, _, err := image.Decode(&buf)
+			if err != nil {
+				t.Fatalf("解码失败: %v", err)
+			}
+
+			if tt.limited {
+				// x/image/webp 按全范围系数转换为 RGB，而 libwebp 和浏览器按有限范围解码，
+				// 因此直接比较 YCbCr 平面按有限范围转换后的颜色
+				got = limitedRangeRGB(t, got)
+			}
+
+			want := image.Image(tt.img)
+			if tt.minPSNR > 0 {
+				// 有损格式只比较颜色，透明通道单独要求无损
+				checkAlpha(t, tt.img, got)
+				want = opaque(tt.img)
+				got = opaque(got)
+			}
+			maxDelta, psnr := compareImages(t, want, got)
+			if tt.minPSNR == 0 && maxDelta != 0 {
+				t.Errorf("无损格式的像素差值为 %d", maxDelta)
+			}
+			if psnr < tt.minPSNR {
+				t.Errorf("PSNR 为 %.1f dB，低于 %.0f dB（最大差值 %d）", psnr, tt.minPSNR, maxDelta)
+			}
+		})
+	}
+}
+
+// checkAlpha 检查解码结果的透明通道与原图一致
+func checkAlpha(t *testing.T, want *image.NRGBA, got image.Image) {
+	t.Helper()
+	g := imaging.Clone(got)
+	for i := 3; i < len(want.Pix); i += 4 {
+		if want.Pix[i] != g.Pix[i] {
+			t.Errorf("第 %d 个像素的 alpha 为 %d，期望 %d", i/4, g.Pix[i], want.Pix[i])
+			return
+		}
+	}
+}
+
+// opaque 返回将 alpha 设为 255 的副本，用于只比较颜色
+func opaque(img image.Image) *image.NRGBA {
+	dst := imaging.Clone(img)
+	for i := 3; i < len(dst.Pix); i += 4 {
+		dst.Pix[i] = 255
+	}
+	return dst
+}
+
+func TestSaveImageOversizedEXIF(t *testing.T) {
+	// 没有任何条目的 IFD0，后面跟着超出 JPEG 单个段上限的数据
+	exif := append([]byte("II*\x00\x08\x00\x00\x00\x00\x00\x00\x00\x00\x00"), make([]byte, 70000)...)
+	xmp := []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"></x:xmpmeta>`)
+	meta := &metadata.Metadata{EXIF: exif, XMP: xmp}
+
+	path := filepath.Join(t.TempDir(), "out.jpg")
+	_, warnings, err := SaveImage(gradientImage(16, 16, false), path, EncodeOptions{Format: FormatJPEG}, meta)
+	if err != nil {
+		t.Fatalf("EXIF 过大时保存失败: %v", err)
+	}
+	if len(warnings) != 1 || !errors.Is(warnings[0], metadata.ErrTooLarge) {
+		t.Errorf("期望一条 ErrTooLarge 警告，实际为 %v", warnings)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := metadata.Read(data)
+	if len(got.EXIF) != 0 {
+		t.Error("输出中不应包含 EXIF")
+	}
+	if !bytes.Equal(got.XMP, xmp) {
+		t.Error("XMP 应照常写入")
+	}
+}
+
+// limitedRangeRGB 按有限范围 (16-235) 的 BT.601 将 VP8 解码出的 YCbCr 图像转换为 RGB，
+// 与 libwebp 的转换一致，透明通道原样保留
+func limitedRangeRGB(t *testing.T, img image.Image) *image.NRGBA {
+	t.Helper()
+	var ycc *image.YCbCr
+	var alpha *image.NYCbCrA
+	switch m := img.(type) {
+	case *image.YCbCr:
+		ycc = m
+	case *image.NYCbCrA:
+		ycc, alpha = &m.YCbCr, m
+	default:
+		t.Fatalf("期望解码为 YCbCr 图像，实际为 %T", img)
+	}
+
+	b := ycc.Bounds()
+	dst := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			yy := 1.164 * (float64(ycc.Y[ycc.YOffset(x, y)]) - 16)
+			cb := float64(ycc.Cb[ycc.COffset(x, y)]) - 128
+			cr := float64(ycc.Cr[ycc.COffset(x, y)]) - 128
+			a := uint8(255)
+			if alpha != nil {
+				a = alpha.A[alpha.AOffset(x, y)]
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: clampByte(yy + 1.596*cr),
+				G: clampByte(yy - 0.813*cr - 0.391*cb),
+				B: clampByte(yy + 2.018*cb),
+				A: a,
+			})
+		}
+	}
+	return dst
+}
+
+func clampByte(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(255, v))))
+}
@@ -0,0 +1,18 @@
+package processor
+
+import "errors"
+
+var (
+	// ErrInvalidOptions 处理参数无效（如裁剪区域为空、缩放尺寸均为 0）
+	ErrInvalidOptions = errors.New("参数无效")
+	// ErrNoWatermark 既没有指定文字水印或图片水印，也没有指定不可见水印
+	ErrNoWatermark = errors.New("请指定文字水印 (--text)、图片水印 (--image) 或不可见水印 (--invisible --payload)")
+	// ErrFontLoad 无法加载水印字体
+	ErrFontLoad = errors.New("无法加载字体")
+	// ErrNoMetadata 图像中没有 EXIF 元数据
+	ErrNoMetadata = errors.New("图像没有 EXIF 元数据")
+	// ErrNoPayload 图像中没有检测到不可见水印
+	ErrNoPayload = errors.New("未检测到不可见水印")
+	// ErrUnsupportedFormat 不支持的输出格式
+	ErrUnsupportedFormat = errors.New("不支持的输出格式")
+)
@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// MaxExposure 曝光调整的最大档数（EV）
+	MaxExposure = 5.0
+
+	exposureKnee = 0.5 // 线性亮度超过该值后开始高光压缩
+)
+
+// applyExposure 按档数 (EV) 调整曝光：在线性光下乘以 2^ev，与相机曝光补偿的效果相同。
+//
+// 增加曝光时，超过 exposureKnee 的线性值被平滑地压缩到 1 以内（高光肩部），
+// 原来的白色仍为白色，高光的层次逐渐过渡而不是直接截断
+func applyExposure(img image.Image, ev float64) image.Image {
+	gain := math.Exp2(ev)
+	var lut [256]uint8
+	for i := range lut {
+		lut[i] = linearToSRGB(exposureCurve(srgbToLinear[i], gain))
+	}
+	return imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		return color.NRGBA{R: lut[c.R], G: lut[c.G], B: lut[c.B], A: c.A}
+	})
+}
+
+// exposureCurve 返回线性值 v (0-1) 乘以 gain 并压缩高光后的值。
+//
+// 在 [knee, gain] 上使用 1-(1-u)^m 形式的肩部：m 取 (gain-knee)/(1-knee)，
+// 使曲线在 knee 处连续且斜率为 1，并恰好把 gain 映射到 1
+func exposureCurve(v, gain float64) float64 {
+	x := v * gain
+	if gain <= 1 || x <= exposureKnee {
+		return x
+	}
+	m := (gain - exposureKnee) / (1 - exposureKnee)
+	u := (x - exposureKnee) / (gain - exposureKnee)
+	return exposureKnee + (1-exposureKnee)*(1-math.Pow(1-u, m))
+}
+
+// applyBrightness 调整中间调的亮度 (-100 到 100)，黑色和白色保持不变。
+// 曲线为 v + k·v·(1-v)，k 为 ±1 时中灰 (0.5) 变为 0.75 或 0.25
+func applyBrightness(img image.Image, brightness float64) image.Image {
+	k := max(-1, min(brightness/100, 1))
+	var lut [256]uint8
+	for i := range lut {
+		v := float64(i) / 255
+		lut[i] = uint8(math.Round(clamp((v + k*v*(1-v)) * 255)))
+	}
+	return imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		return color.NRGBA{R: lut[c.R], G: lut[c.G], B: lut[c.B], A: c.A}
+	})
+}
@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// grayLevels 对 0-255 的灰阶应用调整函数，返回每个灰阶的结果
+func grayLevels(fn func(image.Image) image.Image) [256]uint8 {
+	img := image.NewNRGBA(image.Rect(0, 0, 256, 1))
+	for v := 0; v < 256; v++ {
+		img.SetNRGBA(v, 0, color.NRGBA{R: uint8(v), G: uint8(v), B: uint8(v), A: 255})
+	}
+	dst := fn(img)
+	var out [256]uint8
+	for v := range out {
+		r, _, _, _ := dst.At(v, 0).RGBA()
+		out[v] = uint8(r >> 8)
+	}
+	return out
+}
+
+func TestExposure(t *testing.T) {
+	for _, ev := range []float64{1, -1} {
+		out := grayLevels(func(img image.Image) image.Image { return applyExposure(img, ev) })
+		if out[0] != 0 {
+			t.Errorf("曝光 %+.0f 后黑色变为 %d", ev, out[0])
+		}
+		for v := 1; v < 256; v++ {
+			if out[v] < out[v-1] {
+				t.Fatalf("曝光 %+.0f 的结果不单调: %d → %d，%d → %d", ev, v-1, out[v-1], v, out[v])
+			}
+		}
+
+		// 线性光下未进入高光肩部的中间调按 2^ev 缩放，允许 8 位量化带来的误差
+		for _, v := range []int{64, 96, 118, 140} {
+			want := srgbToLinear[v] * math.Exp2(ev)
+			got := srgbToLinear[out[v]]
+			if math.Abs(got-want)/want > 0.03 {
+				t.Errorf("曝光 %+.0f: %d 的线性值为 %.4f，期望约 %.4f", ev, v, got, want)
+			}
+		}
+	}
+
+	out := grayLevels(func(img image.Image) image.Image { return applyExposure(img, 1) })
+	if out[255] != 255 {
+		t.Errorf("曝光 +1 后白色变为 %d", out[255])
+	}
+	if out[230] <= 230 || out[230] == 255 {
+		t.Errorf("曝光 +1 时高光应被压缩而不是截断，230 变为 %d", out[230])
+	}
+}
+
+func TestBrightness(t *testing.T) {
+	for _, b := range []float64{50, -50, 100, -100} {
+		out := grayLevels(func(img image.Image) image.Image { return applyBrightness(img, b) })
+		if out[0] != 0 || out[255] != 255 {
+			t.Errorf("亮度 %+.0f 时黑白应保持不变，实际为 %d 和 %d", b, out[0], out[255])
+		}
+		if b > 0 && out[128] <= 128 || b < 0 && out[128] >= 128 {
+			t.Errorf("亮度 %+.0f 时中灰 128 变为 %d", b, out[128])
+		}
+		for v := 1; v < 256; v++ {
+			if out[v] < out[v-1] {
+				t.Fatalf("亮度 %+.0f 的结果不单调: %d → %d，%d → %d", b, v-1, out[v-1], v, out[v])
+			}
+		}
+	}
+}
@@ -0,0 +1,435 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/fogleman/gg"
+	"github.com/xiaoheiwowo/xpix/internal/config"
+	"golang.org/x/image/font"
+)
+
+// 相框布局
+const (
+	FrameClassic = "classic" // 底栏左侧为相机和镜头，右侧为 Logo、拍摄参数和日期
+	FrameCenter  = "center"  // 底栏居中排列 Logo、相机和拍摄参数
+	FrameMinimal = "minimal" // 底栏居中一行：相机 · 拍摄参数
+)
+
+// FrameOptions 相框选项：为照片加上边框和显示相机信息的底栏，零值字段使用配置中的设置（见 WithConfig）
+type FrameOptions struct {
+	Layout         string      // 布局: classic, center, minimal
+	Color          string      // 边框颜色 (#RRGGBB)
+	TextColor      string      // 主要文字颜色 (#RRGGBB)
+	SecondaryColor string      // 次要文字颜色 (#RRGGBB)
+	Border         float64     // 上、左、右边框宽度（相对于图像宽度的比例）
+	Bar            float64     // 底栏高度（相对于图像宽度的比例）
+	Logo           string      // 品牌 Logo 图片路径
+	LogoPicture    image.Image // 已解码的品牌 Logo，非 nil 时优先于 Logo
+	Font           string      // 字体文件路径或字体族名，为空时使用配置的字体（找不到时改用默认字体）
+
+	// 文字模板，支持与文字水印相同的 EXIF 占位符，占位符全部为空的行不显示
+	Title    string // 标题（相机）
+	Subtitle string // 副标题（镜头）
+	Info     string // 拍摄参数
+	Date     string // 日期
+}
+
+// withDefaults 用配置中的设置填充零值字段
+func (o FrameOptions) withDefaults(c *config.Config) FrameOptions {
+	cfg := c.Frame
+	fill := func(v *string, def string) {
+		if *v == "" {
+			*v = def
+		}
+	}
+	fill(&o.Layout, cfg.Layout)
+	fill(&o.Color, cfg.Color)
+	fill(&o.TextColor, cfg.TextColor)
+	fill(&o.SecondaryColor, cfg.SecondaryColor)
+	fill(&o.Logo, cfg.Logo)
+	fill(&o.Title, cfg.Title)
+	fill(&o.Subtitle, cfg.Subtitle)
+	fill(&o.Info, cfg.Info)
+	fill(&o.Date, cfg.Date)
+	if o.Border == 0 {
+		o.Border = cfg.Border
+	}
+	if o.Bar == 0 {
+		o.Bar = cfg.Bar
+	}
+	return o
+}
+
+// Validate 校验选项，零值字段取内置默认配置，与没有携带配置时的 Apply 一致
+func (o FrameOptions) Validate() error {
+	return o.ValidateWith(builtinConfig)
+}
+
+// ValidateWith 用给定配置填充零值字段后校验选项，命令行按加载的配置文件校验时使用
+func (o FrameOptions) ValidateWith(cfg *config.Config) error {
+	return o.withDefaults(cfg).validate()
+}
+
+// validate 校验填充默认值后的选项
+func (o FrameOptions) validate() error {
+	switch o.Layout {
+	case FrameClassic, FrameCenter, FrameMinimal:
+	default:
+		return fmt.Errorf("%w: 未知的相框布局 %q（可选: classic, center, minimal）", ErrInvalidOptions, o.Layout)
+	}
+	if o.Border < 0 || o.Bar <= 0 {
+		return fmt.Errorf("%w: 边框宽度不能为负数，底栏高度必须大于 0", ErrInvalidOptions)
+	}
+	for _, t := range []string{o.Title, o.Subtitle, o.Info, o.Date} {
+		if err := ValidateTemplate(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FrameOperation 相框操作
+type FrameOperation struct {
+	Options FrameOptions
+}
+
+// Apply 为图像加上相框
+func (op FrameOperation) Apply(ctx context.Context, img image.Image) (image.Image, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return frameImage(ctx, img, op.Options)
+}
+
+func init() {
+	Register(OperationSpec{
+		Name:        "frame",
+		Description: "加上边框和显示相机信息的底栏",
+		Params: []ParamSpec{
+			{Name: "layout", Aliases: []string{"l"}, Type: ParamString, Description: "布局: classic, center, minimal（默认取配置文件）"},
+			{Name: "color", Type: ParamString, Description: "边框颜色 (#RRGGBB)"},
+			{Name: "text-color", Type: ParamString, Description: "主要文字颜色 (#RRGGBB)"},
+			{Name: "secondary-color", Type: ParamString, Description: "次要文字颜色 (#RRGGBB)"},
+			{Name: "border", Type: ParamFloat, Description: "边框宽度（相对于图像宽度的比例）"},
+			{Name: "bar", Type: ParamFloat, Description: "底栏高度（相对于图像宽度的比例）"},
+			{Name: "logo", Type: ParamString, Description: "品牌 Logo 图片路径"},
+			{Name: "font", Type: ParamString, Description: "字体文件路径或字体族名"},
+			{Name: "title", Type: ParamString, Description: "标题文字模板（默认 {Camera}）"},
+			{Name: "subtitle", Type: ParamString, Description: "副标题文字模板（默认 {LensModel}）"},
+			{Name: "info", Type: ParamString, Description: "拍摄参数文字模板"},
+			{Name: "date", Type: ParamString, Description: "日期文字模板"},
+		},
+		New: func(p Params) (Operation, error) {
+			opts := FrameOptions{
+				Layout:         p.String("layout"),
+				Color:          p.String("color"),
+				TextColor:      p.String("text-color"),
+				SecondaryColor: p.String("secondary-color"),
+				Border:         p.Float("border"),
+				Bar:            p.Float("bar"),
+				Logo:           p.String("logo"),
+				Font:           p.String("font"),
+				Title:          p.String("title"),
+				Subtitle:       p.String("subtitle"),
+				Info:           p.String("info"),
+				Date:           p.String("date"),
+			}
+			if err := opts.withDefaults(config.Get()).validate(); err != nil {
+				return nil, p.Errorf("%v", err)
+			}
+			return FrameOperation{Options: opts}, nil
+		},
+	})
+}
+
+// Frame 为图像加上相框
+func Frame(inputPath, outputPath string, opts FrameOptions) error {
+	return ProcessFile(context.Background(), inputPath, outputPath, DefaultDecodeOptions(), DefaultEncodeOptions(), FrameOperation{Options: opts})
+}
+
+// frameLayout 绘制底栏时用到的尺寸和内容
+type frameLayout struct {
+	dc        *gg.Context
+	cfg       *config.Config
+	opts      FrameOptions
+	logo      image.Image
+	bar       float64 // 底栏高度
+	barTop    float64 // 底栏上边缘
+	padX      float64 // 文字与画布左右边缘的距离
+	width     float64 // 画布宽度
+	title     string
+	subtitle  string
+	info      string
+	date      string
+	primary   font.Face
+	secondary font.Face
+}
+
+func frameImage(ctx context.Context, img image.Image, opts FrameOptions) (image.Image, error) {
+	cfg := configFromContext(ctx)
+	opts = opts.withDefaults(cfg)
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	// 文字内容
+	src, _ := SourceFromContext(ctx)
+	l := &frameLayout{cfg: cfg, opts: opts}
+	for _, f := range []struct {
+		dst      *string
+		template string
+	}{
+		{&l.title, opts.Title},
+		{&l.subtitle, opts.Subtitle},
+		{&l.info, opts.Info},
+		{&l.date, opts.Date},
+	} {
+		text, err := expandLine(f.template, src)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = strings.TrimSpace(text)
+	}
+
+	if opts.LogoPicture != nil {
+		l.logo = opts.LogoPicture
+	} else if opts.Logo != "" {
+		logo, err := imaging.Open(opts.Logo, imaging.AutoOrientation(true))
+		if err != nil {
+			return nil, fmt.Errorf("无法打开 Logo 图像: %w", err)
+		}
+		l.logo = logo
+	}
+
+	// 画布：照片四周为边框，底部为信息栏
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	border := int(math.Round(opts.Border * float64(w)))
+	bar := max(int(math.Round(opts.Bar*float64(w))), border, 1)
+	canvas := image.NewRGBA(image.Rect(0, 0, w+2*border, h+border+bar))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(parseColor(opts.Color, 1)), image.Point{}, draw.Src)
+	draw.Draw(canvas, image.Rect(border, border, border+w, border+h), img, bounds.Min, draw.Src)
+
+	l.dc = gg.NewContextForRGBA(canvas)
+	l.bar = float64(bar)
+	l.barTop = float64(border + h)
+	l.width = float64(canvas.Bounds().Dx())
+	l.padX = max(float64(border), l.bar*0.25)
+
+	var err error
+	switch opts.Layout {
+	case FrameClassic:
+		err = l.drawClassic()
+	case FrameCenter:
+		err = l.drawCenter()
+	case FrameMinimal:
+		err = l.drawMinimal()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return canvas, nil
+}
+
+// loadFaces 加载主要和次要文字的字体。needed 返回给定字体下内容所需的宽度，
+// 超出可用宽度时按比例缩小字号
+func (l *frameLayout) loadFaces(primary, secondary float64, needed func() float64) error {
+	configured := l.cfg.Frame.Font
+	if configured == "" {
+		configured = l.cfg.Watermark.Font
+	}
+	for attempt := 0; attempt < 2; attempt++ {
+		var err error
+		if l.primary, err = loadFace(l.cfg, l.opts.Font, configured, nil, primary); err != nil {
+			return err
+		}
+		if l.secondary, err = loadFace(l.cfg, l.opts.Font, configured, nil, secondary); err != nil {
+			l.primary.Close()
+			return err
+		}
+		avail := l.width - 2*l.padX
+		n := needed()
+		if n <= avail || attempt == 1 {
+			return nil
+		}
+		l.primary.Close()
+		l.secondary.Close()
+		scale := avail / n
+		primary, secondary = primary*scale, secondary*scale
+	}
+	return nil
+}
+
+// scaledLogo 返回缩放到指定高度的 Logo，没有 Logo 时返回 nil
+func (l *frameLayout) scaledLogo(height float64) image.Image {
+	if l.logo == nil || height < 1 {
+		return nil
+	}
+	return imaging.Resize(l.logo, 0, int(math.Round(height)), imaging.Lanczos)
+}
+
+func (l *frameLayout) drawText(face font.Face, color, text string, x, y, ax, ay float64) {
+	if text == "" {
+		return
+	}
+	l.dc.SetFontFace(face)
+	l.dc.SetColor(parseColor(color, 1))
+	l.dc.DrawStringAnchored(text, x, y, ax, ay)
+}
+
+// drawPair 在 x 处绘制上下两行文字，整体在底栏中垂直居中，只有一行时该行居中
+func (l *frameLayout) drawPair(top, bottom string, x, ax float64) {
+	cy := l.barTop + l.bar/2
+	gap := l.bar * 0.04
+	switch {
+	case top != "" && bottom != "":
+		l.drawText(l.primary, l.opts.TextColor, top, x, cy-gap, ax, 0)
+		l.drawText(l.secondary, l.opts.SecondaryColor, bottom, x, cy+gap, ax, 1)
+	case top != "":
+		l.drawText(l.primary, l.opts.TextColor, top, x, cy, ax, 0.5)
+	default:
+		l.drawText(l.secondary, l.opts.SecondaryColor, bottom, x, cy, ax, 0.5)
+	}
+}
+
+// drawClassic 左侧为相机和镜头，右侧为拍摄参数和日期，二者之间为 Logo 和分隔线
+func (l *frameLayout) drawClassic() error {
+	logoH := l.bar * 0.36
+	gap := l.bar * 0.15
+	logo := l.scaledLogo(logoH)
+
+	blockWidth := func(top, bottom string) float64 {
+		return max(measure(l.primary, top), measure(l.secondary, bottom))
+	}
+	logoWidth := func() float64 {
+		if logo == nil {
+			return 0
+		}
+		return float64(logo.Bounds().Dx()) + 2*gap
+	}
+	err := l.loadFaces(l.bar*0.2, l.bar*0.15, func() float64 {
+		return blockWidth(l.title, l.subtitle) + blockWidth(l.info, l.date) + logoWidth() + 2*gap
+	})
+	if err != nil {
+		return err
+	}
+	defer l.primary.Close()
+	defer l.secondary.Close()
+
+	left, right := l.padX, l.width-l.padX
+	l.drawPair(l.title, l.subtitle, left, 0)
+	l.drawPair(l.info, l.date, right, 1)
+
+	if logo != nil {
+		cy := l.barTop + l.bar/2
+		divider := right - blockWidth(l.info, l.date) - gap
+		l.dc.SetColor(parseColor(l.opts.SecondaryColor, 1))
+		l.dc.SetLineWidth(max(1, l.bar*0.01))
+		l.dc.DrawLine(divider, cy-logoH/2, divider, cy+logoH/2)
+		l.dc.Stroke()
+		x := divider - gap - float64(logo.Bounds().Dx())
+		l.dc.DrawImage(logo, int(math.Round(x)), int(math.Round(cy-float64(logo.Bounds().Dy())/2)))
+	}
+	return nil
+}
+
+// drawCenter 底栏居中，自上而下为 Logo、相机和拍摄参数
+func (l *frameLayout) drawCenter() error {
+	logo := l.scaledLogo(l.bar * 0.28)
+	err := l.loadFaces(l.bar*0.16, l.bar*0.12, func() float64 {
+		return max(measure(l.primary, l.title), measure(l.secondary, l.info))
+	})
+	if err != nil {
+		return err
+	}
+	defer l.primary.Close()
+	defer l.secondary.Close()
+
+	// 计算各项的高度后整体垂直居中
+	gap := l.bar * 0.06
+	type item struct {
+		height float64
+		draw   func(y float64)
+	}
+	var items []item
+	cx := l.width / 2
+	if logo != nil {
+		items = append(items, item{float64(logo.Bounds().Dy()), func(y float64) {
+			l.dc.DrawImage(logo, int(math.Round(cx-float64(logo.Bounds().Dx())/2)), int(math.Round(y)))
+		}})
+	}
+	for _, t := range []struct {
+		face  font.Face
+		color string
+		text  string
+	}{
+		{l.primary, l.opts.TextColor, l.title},
+		{l.secondary, l.opts.SecondaryColor, l.info},
+	} {
+		if t.text == "" {
+			continue
+		}
+		t := t
+		items = append(items, item{float64(t.face.Metrics().Height) / 64, func(y float64) {
+			l.drawText(t.face, t.color, t.text, cx, y, 0.5, 1)
+		}})
+	}
+
+	total := gap * float64(max(len(items)-1, 0))
+	for _, it := range items {
+		total += it.height
+	}
+	y := l.barTop + (l.bar-total)/2
+	for _, it := range items {
+		it.draw(y)
+		y += it.height + gap
+	}
+	return nil
+}
+
+// drawMinimal 底栏居中一行文字：相机 · 拍摄参数，Logo 位于文字左侧
+func (l *frameLayout) drawMinimal() error {
+	var parts []string
+	for _, s := range []string{l.title, l.info} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	text := strings.Join(parts, "  ·  ")
+
+	logoH := l.bar * 0.3
+	gap := l.bar * 0.12
+	logo := l.scaledLogo(logoH)
+	logoWidth := 0.0
+	if logo != nil {
+		logoWidth = float64(logo.Bounds().Dx()) + gap
+	}
+	err := l.loadFaces(l.bar*0.2, l.bar*0.2, func() float64 {
+		return measure(l.primary, text) + logoWidth
+	})
+	if err != nil {
+		return err
+	}
+	defer l.primary.Close()
+	defer l.secondary.Close()
+
+	cy := l.barTop + l.bar/2
+	x := (l.width - measure(l.primary, text) - logoWidth) / 2
+	if logo != nil {
+		l.dc.DrawImage(logo, int(math.Round(x)), int(math.Round(cy-float64(logo.Bounds().Dy())/2)))
+		x += logoWidth
+	}
+	l.drawText(l.primary, l.opts.TextColor, text, x, cy, 0, 0.5)
+	return nil
+}
+
+// measure 返回文字在给定字体下的宽度（像素）
+func measure(face font.Face, text string) float64 {
+	return float64(font.MeasureString(face, text)) / 64
+}
@@ -0,0 +1,203 @@
+package processor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// HSL 调整：分别调整八个色相范围的色相、饱和度和明度。
+//
+// 每个范围有一个中心色相，相邻两个中心之间的颜色按距离平滑地混合两侧范围的调整量，
+// 因此调整不会在色相上出现断层；低饱和度的颜色受到的影响按饱和度减弱，中性灰保持不变。
+
+// hslRanges 各色相范围的名称和中心色相（度），按色相排列
+var hslRanges = []struct {
+	name string
+	hue  float64
+}{
+	{"red", 0},
+	{"orange", 30},
+	{"yellow", 60},
+	{"green", 120},
+	{"aqua", 180},
+	{"blue", 240},
+	{"purple", 270},
+	{"magenta", 300},
+}
+
+const (
+	hslHueShift   = 30.0 // 色相为 ±100 时的偏移角度
+	hslLightness  = 0.5  // 明度为 ±100 时的最大变化比例
+	hslNeutralSat = 0.2  // 饱和度低于该值时调整量逐渐减弱到 0
+)
+
+// HSLAdjustment 单个色相范围的调整，各项取值 -100 到 100
+type HSLAdjustment struct {
+	Range      string  // 色相范围：red、orange、yellow、green、aqua、blue、purple、magenta
+	Hue        float64 // 色相，正值向色环的下一个范围偏移（如红色偏橙）
+	Saturation float64 // 饱和度
+	Luminance  float64 // 明度
+}
+
+// HSLRanges 返回按色相排列的色相范围名称
+func HSLRanges() []string {
+	names := make([]string, len(hslRanges))
+	for i, r := range hslRanges {
+		names[i] = r.name
+	}
+	return names
+}
+
+// Validate 检查 HSL 调整参数
+func (a HSLAdjustment) Validate() error {
+	if hslRangeIndex(a.Range) < 0 {
+		return fmt.Errorf("%w: 未知的色相范围 %q（可选: %s）", ErrInvalidOptions, a.Range, strings.Join(HSLRanges(), ", "))
+	}
+	for _, v := range []float64{a.Hue, a.Saturation, a.Luminance} {
+		if v < -100 || v > 100 {
+			return fmt.Errorf("%w: %s 的 HSL 调整量 %g 超出 -100 到 100 的范围", ErrInvalidOptions, a.Range, v)
+		}
+	}
+	return nil
+}
+
+func hslRangeIndex(name string) int {
+	for i, r := range hslRanges {
+		if r.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseHSL 解析 HSL 调整，格式为 范围:h=色相,s=饱和度,l=明度，可只写其中几项，
+// 如 "blue:s=-20,l=10"、"orange:h=-5,s=-15"。多个范围用 ; 分隔
+func ParseHSL(s string) ([]HSLAdjustment, error) {
+	var list []HSLAdjustment
+	for _, spec := range splitSpecs(s) {
+		name, body, ok := strings.Cut(spec, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: 无效的 HSL 调整 %q（格式: 范围:h=色相,s=饱和度,l=明度）", ErrInvalidOptions, spec)
+		}
+		a := HSLAdjustment{Range: strings.ToLower(strings.TrimSpace(name))}
+		for _, field := range strings.Split(body, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+			if !ok {
+				return nil, fmt.Errorf("%w: 无效的 HSL 调整项 %q（格式: h=色相、s=饱和度、l=明度）", ErrInvalidOptions, field)
+			}
+			v, err := parseNumbers([]string{value})
+			if err != nil {
+				return nil, fmt.Errorf("%w: 无效的 HSL 调整项 %q: %v", ErrInvalidOptions, field, err)
+			}
+			switch strings.ToLower(strings.TrimSpace(key)) {
+			case "h", "hue":
+				a.Hue = v[0]
+			case "s", "saturation":
+				a.Saturation = v[0]
+			case "l", "luminance":
+				a.Luminance = v[0]
+			default:
+				return nil, fmt.Errorf("%w: 未知的 HSL 调整项 %q（可选: h, s, l）", ErrInvalidOptions, key)
+			}
+		}
+		if err := a.Validate(); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+// applyHSL 应用各色相范围的调整，同一范围出现多次时调整量相加
+func applyHSL(img image.Image, adjustments []HSLAdjustment) image.Image {
+	var hue, sat, lum [8]float64
+	for _, a := range adjustments {
+		i := hslRangeIndex(a.Range)
+		hue[i] += a.Hue / 100 * hslHueShift
+		sat[i] += a.Saturation / 100
+		lum[i] += a.Luminance / 100
+	}
+
+	return imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		h, s, l := rgbToHSL(c.R, c.G, c.B)
+		if s == 0 {
+			return c
+		}
+
+		// 找到两侧的范围，按在两个中心之间的位置平滑插值
+		i := len(hslRanges) - 1
+		for j := range hslRanges {
+			if hslRanges[j].hue <= h {
+				i = j
+			}
+		}
+		next := (i + 1) % len(hslRanges)
+		span := math.Mod(hslRanges[next].hue-hslRanges[i].hue+360, 360)
+		t := smoothstep(0, 1, math.Mod(h-hslRanges[i].hue+360, 360)/span)
+		weight := smoothstep(0, hslNeutralSat, s)
+
+		dh := (hue[i]*(1-t) + hue[next]*t) * weight
+		ds := (sat[i]*(1-t) + sat[next]*t) * weight
+		dl := (lum[i]*(1-t) + lum[next]*t) * weight * hslLightness
+
+		h = math.Mod(h+dh+360, 360)
+		s = min(1, max(0, s*(1+ds)))
+		if dl < 0 {
+			l *= 1 + dl
+		} else {
+			l += (1 - l) * dl
+		}
+		r, g, b := hslToRGB(h, s, l)
+		return color.NRGBA{R: r, G: g, B: b, A: c.A}
+	})
+}
+
+// rgbToHSL 将 RGB 转换为色相（0-360）、饱和度和明度（0-1）
+func rgbToHSL(r8, g8, b8 uint8) (h, s, l float64) {
+	r, g, b := float64(r8)/255, float64(g8)/255, float64(b8)/255
+	hi, lo := max(r, g, b), min(r, g, b)
+	l = (hi + lo) / 2
+	d := hi - lo
+	if d == 0 {
+		return 0, 0, l
+	}
+	s = d / (1 - math.Abs(2*l-1))
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d+6, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60, s, l
+}
+
+// hslToRGB 将色相（0-360）、饱和度和明度（0-1）转换为 RGB
+func hslToRGB(h, s, l float64) (uint8, uint8, uint8) {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var r, g, b float64
+	switch int(h/60) % 6 {
+	case 0:
+		r, g, b = c, x, 0
+	case 1:
+		r, g, b = x, c, 0
+	case 2:
+		r, g, b = 0, c, x
+	case 3:
+		r, g, b = 0, x, c
+	case 4:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	m := l - c/2
+	to8 := func(v float64) uint8 { return uint8(math.Round(clamp((v + m) * 255))) }
+	return to8(r), to8(g), to8(b)
+}
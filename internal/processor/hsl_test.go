@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/xiaoheiwowo/xpix/internal/config"
+)
+
+// solidImage 返回单一颜色的图像
+func solidImage(c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:], []uint8{c.R, c.G, c.B, c.A})
+	}
+	return img
+}
+
+// hslOf 返回对单一颜色应用 HSL 调整后的颜色
+func hslOf(c color.NRGBA, adjustments ...HSLAdjustment) color.NRGBA {
+	return imaging.Clone(applyHSL(solidImage(c), adjustments)).NRGBAAt(1, 1)
+}
+
+// hueColor 返回给定色相、饱和度 1、明度 0.5 的颜色
+func hueColor(h float64) color.NRGBA {
+	r, g, b := hslToRGB(h, 1, 0.5)
+	return color.NRGBA{r, g, b, 255}
+}
+
+func TestHSLRoundTrip(t *testing.T) {
+	for r := 0; r < 256; r += 5 {
+		for g := 0; g < 256; g += 3 {
+			for b := 0; b < 256; b += 7 {
+				h, s, l := rgbToHSL(uint8(r), uint8(g), uint8(b))
+				if r2, g2, b2 := hslToRGB(h, s, l); int(r2) != r || int(g2) != g || int(b2) != b {
+					t.Fatalf("(%d,%d,%d) 往返转换后为 (%d,%d,%d)", r, g, b, r2, g2, b2)
+				}
+			}
+		}
+	}
+}
+
+func TestHSLIdentity(t *testing.T) {
+	img := photoImage(64, 48)
+	for _, adjustments := range [][]HSLAdjustment{
+		nil,
+		{{Range: "red"}, {Range: "blue"}},
+	} {
+		if maxDelta, _ := compareImages(t, img, applyHSL(img, adjustments)); maxDelta != 0 {
+			t.Errorf("%v: 调整量为 0 时最大误差为 %d，期望不变", adjustments, maxDelta)
+		}
+	}
+}
+
+func TestHSLHueShift(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	got := hslOf(red, HSLAdjustment{Range: "red", Hue: 50})
+	if h, _, _ := rgbToHSL(got.R, got.G, got.B); h < 10 || h > 20 {
+		t.Errorf("红色的色相 +50 后色相为 %.1f°，期望向橙色偏移约 15°", h)
+	}
+	got = hslOf(red, HSLAdjustment{Range: "red", Hue: -50})
+	if h, _, _ := rgbToHSL(got.R, got.G, got.B); h < 340 || h > 350 {
+		t.Errorf("红色的色相 -50 后色相为 %.1f°，期望向洋红偏移约 15°", h)
+	}
+
+	blue := color.NRGBA{0, 0, 255, 255}
+	if got := hslOf(blue, HSLAdjustment{Range: "red", Hue: 50}); got != blue {
+		t.Errorf("调整红色时蓝色变为 %v", got)
+	}
+}
+
+func TestHSLRanges(t *testing.T) {
+	for _, r := range hslRanges {
+		t.Run(r.name, func(t *testing.T) {
+			center := hueColor(r.hue)
+			opposite := hueColor(math.Mod(r.hue+180, 360))
+			adjust := HSLAdjustment{Range: r.name, Saturation: -50, Luminance: -50}
+
+			_, s0, l0 := rgbToHSL(center.R, center.G, center.B)
+			got := hslOf(center, adjust)
+			if _, s, l := rgbToHSL(got.R, got.G, got.B); s >= s0-0.2 || l >= l0-0.1 {
+				t.Errorf("中心色 %v 调整后为 %v（饱和度 %.2f、明度 %.2f），期望饱和度和明度都降低", center, got, s, l)
+			}
+			if got := hslOf(opposite, adjust); got != opposite {
+				t.Errorf("相反色相 %v 变为 %v", opposite, got)
+			}
+			gray := color.NRGBA{128, 128, 128, 255}
+			if got := hslOf(gray, adjust); got != gray {
+				t.Errorf("中性灰变为 %v", got)
+			}
+		})
+	}
+}
+
+func TestHSLFromConfig(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Adjust.HSL = "red:l=-50"
+	ctx := WithConfig(context.Background(), cfg)
+	red := solidImage(color.NRGBA{255, 0, 0, 255})
+
+	got, err := AdjustOperation{}.Apply(ctx, red)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := imaging.Clone(got).NRGBAAt(0, 0); c.R >= 200 {
+		t.Errorf("未指定 HSL 时应使用配置中的调整，红色为 %v", c)
+	}
+
+	// 选项中的 HSL 优先于配置
+	got, err = AdjustOperation{Options: AdjustOptions{HSL: []HSLAdjustment{{Range: "blue"}}}}.Apply(ctx, red)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := imaging.Clone(got).NRGBAAt(0, 0); c.R != 255 {
+		t.Errorf("指定了 HSL 时不应使用配置，红色为 %v", c)
+	}
+
+	cfg.Adjust.HSL = "sky:s=10"
+	if _, err := (AdjustOperation{}).Apply(ctx, red); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("配置无效时应返回 ErrInvalidOptions，实际 %v", err)
+	}
+}
@@ -2,13 +2,78 @@ package processor
 
 import (
 	"fmt"
+	"image"
+	"io"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/rwcarlsen/goexif/exif"
 	"github.com/rwcarlsen/goexif/tiff"
 )
 
+// ImageMetadata 图像的 EXIF 元数据，缺失的字段为零值
+type ImageMetadata struct {
+	Make         string    // 相机品牌
+	Model        string    // 相机型号
+	LensModel    string    // 镜头型号
+	DateTime     time.Time // 拍摄时间
+	ISO          string    // ISO 感光度
+	FNumber      string    // 光圈值
+	ExposureTime string    // 快门速度（秒）
+	FocalLength  string    // 焦距（毫米）
+	ExposureBias string    // 曝光补偿（EV）
+	PixelWidth   string    // EXIF 记录的像素宽度
+	PixelHeight  string    // EXIF 记录的像素高度
+	Orientation  string    // 方向
+	Software     string    // 编辑软件
+	HasGPS       bool      // 是否包含 GPS 位置
+	Latitude     float64   // 纬度
+	Longitude    float64   // 经度
+}
+
+// ReadMetadata 从图像数据中读取 EXIF 元数据，没有 EXIF 时返回 ErrNoMetadata
+func ReadMetadata(r io.Reader) (*ImageMetadata, error) {
+	exifData, err := exif.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoMetadata, err)
+	}
+
+	meta := &ImageMetadata{}
+	fields := []struct {
+		name  exif.FieldName
+		value *string
+	}{
+		{exif.Make, &meta.Make},
+		{exif.Model, &meta.Model},
+		{exif.LensModel, &meta.LensModel},
+		{exif.ISOSpeedRatings, &meta.ISO},
+		{exif.FNumber, &meta.FNumber},
+		{exif.ExposureTime, &meta.ExposureTime},
+		{exif.FocalLength, &meta.FocalLength},
+		{exif.ExposureBiasValue, &meta.ExposureBias},
+		{exif.PixelXDimension, &meta.PixelWidth},
+		{exif.PixelYDimension, &meta.PixelHeight},
+		{exif.Orientation, &meta.Orientation},
+		{exif.Software, &meta.Software},
+	}
+	for _, f := range fields {
+		if tag, err := exifData.Get(f.name); err == nil {
+			*f.value = formatExifValue(tag)
+		}
+	}
+
+	if dateTime, err := exifData.DateTime(); err == nil {
+		meta.DateTime = dateTime
+	}
+	if lat, lon, err := exifData.LatLong(); err == nil {
+		meta.HasGPS = true
+		meta.Latitude, meta.Longitude = lat, lon
+	}
+
+	return meta, nil
+}
+
 // ShowImageInfo 显示图像的元数据信息
 func ShowImageInfo(imagePath string) error {
 	// 打开图像文件
@@ -25,7 +90,14 @@ func ShowImageInfo(imagePath string) error {
 	}
 
 	// 解析 EXIF 数据
-	exifData, err := exif.Decode(file)
+	meta, err := ReadMetadata(file)
+
+	// 读取存储的像素尺寸
+	var size image.Config
+	var sizeErr error
+	if _, sizeErr = file.Seek(0, io.SeekStart); sizeErr == nil {
+		size, _, sizeErr = image.DecodeConfig(file)
+	}
 
 	// 显示基本文件信息
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -34,6 +106,15 @@ func ShowImageInfo(imagePath string) error {
 	fmt.Printf("文件名:   %s\n", fileInfo.Name())
 	fmt.Printf("文件大小: %s\n", formatFileSize(fileInfo.Size()))
 	fmt.Printf("修改时间: %s\n", fileInfo.ModTime().Format("2006-01-02 15:04:05"))
+	if sizeErr == nil {
+		orientation := 0
+		if err == nil {
+			orientation, _ = strconv.Atoi(meta.Orientation)
+		}
+		fmt.Printf("存储尺寸: %d x %d 像素\n", size.Width, size.Height)
+		w, h := orientedSize(size.Width, size.Height, orientation)
+		fmt.Printf("显示尺寸: %d x %d 像素\n", w, h)
+	}
 	fmt.Println()
 
 	// 如果没有 EXIF 数据
@@ -48,73 +129,64 @@ func ShowImageInfo(imagePath string) error {
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
 	// 相机信息
-	if make, err := exifData.Get(exif.Make); err == nil {
-		fmt.Printf("相机品牌: %s\n", formatExifValue(make))
+	if meta.Make != "" {
+		fmt.Printf("相机品牌: %s\n", meta.Make)
 	}
-	if model, err := exifData.Get(exif.Model); err == nil {
-		fmt.Printf("相机型号: %s\n", formatExifValue(model))
+	if meta.Model != "" {
+		fmt.Printf("相机型号: %s\n", meta.Model)
 	}
-	if lens, err := exifData.Get(exif.LensModel); err == nil {
-		fmt.Printf("镜头型号: %s\n", formatExifValue(lens))
+	if meta.LensModel != "" {
+		fmt.Printf("镜头型号: %s\n", meta.LensModel)
 	}
 
 	fmt.Println()
 
 	// 拍摄参数
-	if dateTime, err := exifData.DateTime(); err == nil {
-		weekday := getChineseWeekday(dateTime.Weekday())
-		fmt.Printf("拍摄时间: %s %s\n", dateTime.Format("2006-01-02 15:04:05"), weekday)
+	if !meta.DateTime.IsZero() {
+		weekday := getChineseWeekday(meta.DateTime.Weekday())
+		fmt.Printf("拍摄时间: %s %s\n", meta.DateTime.Format("2006-01-02 15:04:05"), weekday)
 	}
-	if iso, err := exifData.Get(exif.ISOSpeedRatings); err == nil {
-		fmt.Printf("ISO:      %s\n", formatExifValue(iso))
+	if meta.ISO != "" {
+		fmt.Printf("ISO:      %s\n", meta.ISO)
 	}
-	if fNumber, err := exifData.Get(exif.FNumber); err == nil {
-		fmt.Printf("光圈:     f/%s\n", formatExifValue(fNumber))
+	if meta.FNumber != "" {
+		fmt.Printf("光圈:     f/%s\n", meta.FNumber)
 	}
-	if expTime, err := exifData.Get(exif.ExposureTime); err == nil {
-		fmt.Printf("快门速度: %s 秒\n", formatExifValue(expTime))
+	if meta.ExposureTime != "" {
+		fmt.Printf("快门速度: %s 秒\n", meta.ExposureTime)
 	}
-	if focalLength, err := exifData.Get(exif.FocalLength); err == nil {
-		fmt.Printf("焦距:     %s mm\n", formatExifValue(focalLength))
+	if meta.FocalLength != "" {
+		fmt.Printf("焦距:     %s mm\n", meta.FocalLength)
 	}
-	if expBias, err := exifData.Get(exif.ExposureBiasValue); err == nil {
-		fmt.Printf("曝光补偿: %s EV\n", formatExifValue(expBias))
+	if meta.ExposureBias != "" {
+		fmt.Printf("曝光补偿: %s EV\n", meta.ExposureBias)
 	}
 
 	fmt.Println()
 
 	// 图像信息
-	if width, err := exifData.Get(exif.PixelXDimension); err == nil {
-		if height, err := exifData.Get(exif.PixelYDimension); err == nil {
-			fmt.Printf("图像尺寸: %s x %s 像素\n", formatExifValue(width), formatExifValue(height))
+	if meta.PixelWidth != "" && meta.PixelHeight != "" {
+		fmt.Printf("图像尺寸: %s x %s 像素\n", meta.PixelWidth, meta.PixelHeight)
+	}
+	if meta.Orientation != "" {
+		if o, err := strconv.Atoi(meta.Orientation); err == nil {
+			fmt.Printf("方向:     %s\n", describeOrientation(o))
+		} else {
+			fmt.Printf("方向:     %s\n", meta.Orientation)
 		}
 	}
-	if orientation, err := exifData.Get(exif.Orientation); err == nil {
-		fmt.Printf("方向:     %s\n", formatExifValue(orientation))
-	}
 
 	// GPS 信息
-	lat, lon, err := exifData.LatLong()
-	if err == nil {
+	if meta.HasGPS {
 		fmt.Println()
-		fmt.Printf("📍 GPS 位置: %.6f, %.6f\n", lat, lon)
+		fmt.Printf("📍 GPS 位置: %.6f, %.6f\n", meta.Latitude, meta.Longitude)
 	}
 
 	// 软件信息
-	if software, err := exifData.Get(exif.Software); err == nil {
+	if meta.Software != "" {
 		fmt.Println()
-		fmt.Printf("编辑软件: %s\n", formatExifValue(software))
-	}
-
-	// 显示所有 EXIF 标签（可选，用于调试）
-	// fmt.Println()
-	// fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	// fmt.Printf("所有 EXIF 标签\n")
-	// fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	// exifData.Walk(func(name exif.FieldName, tag *tiff.Tag) error {
-	// 	fmt.Printf("%s: %s\n", name, tag.String())
-	// 	return nil
-	// })
+		fmt.Printf("编辑软件: %s\n", meta.Software)
+	}
 
 	return nil
 }
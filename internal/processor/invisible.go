@@ -0,0 +1,227 @@
+package processor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"math"
+	"math/rand"
+	"os"
+
+	"github.com/disintegration/imaging"
+)
+
+// 不可见水印（数字水印）
+//
+// 图像的亮度先缩放到固定的 invisibleSize×invisibleSize 的网格上，
+// 每个 8×8 块通过两个低频 DCT 系数的大小关系携带 1 位：(1,2) 大于 (2,1) 为 1，反之为 0。
+// 水印的修改量在网格上计算后放大回原图尺寸叠加到亮度上，因此只影响图像的低频部分，
+// 能经受 JPEG 重新压缩和缩放；检测时把图像缩放到同一网格上读取各块的位。
+//
+// 载荷编码为定长的数据帧：1 字节长度 + 补零到 MaxPayloadLength 字节的内容 + CRC32，
+// 每一位按固定的伪随机顺序重复写入多个块，检测时累加各块的系数差后判决，
+// CRC 校验通过才认为检测到水印。
+//
+// 网格总是对应整幅图像，裁剪、旋转或拼接会改变块与图像内容的对应关系，
+// 检测时无法重新对齐，因此不支持这类修改过的图像。
+//
+// 短边为 MinInvisibleSize 的图像经质量 75 的 JPEG 重新压缩或缩小到一半后仍能检测；
+// 缩小一半后再以质量 75 压缩时，原图短边需要至少 640 像素。
+
+const (
+	// MaxPayloadLength 不可见水印载荷的最大长度（字节）
+	MaxPayloadLength = 32
+
+	// MinInvisibleSize 嵌入不可见水印要求的图像短边最小像素数。
+	// 与嵌入网格的边长相同：更小的图像中 8×8 的块被缩小到不足 8 像素，经不起重新压缩和缩放
+	MinInvisibleSize = invisibleSize
+
+	invisibleSize     = 512 // 嵌入网格的边长
+	invisibleBlocks   = (invisibleSize / 8) * (invisibleSize / 8)
+	invisibleFrame    = 1 + MaxPayloadLength + 4 // 数据帧字节数
+	invisibleBits     = invisibleFrame * 8       // 数据帧位数
+	invisibleSeed     = 0x78706978               // 块顺序的伪随机种子（"xpix"）
+	invisibleStrength = 12.0                     // 两个系数之差的目标值（亮度 0-255）
+	invisiblePasses   = 4                        // 嵌入后重新测量并补强的次数
+)
+
+// invisibleOrder 数据帧第 i 位写入 invisibleOrder[j]（j % invisibleBits == i）号块
+var invisibleOrder = rand.New(rand.NewSource(invisibleSeed)).Perm(invisibleBlocks)
+
+// dctBasis 8×8 正交 DCT 的 (1,2) 与 (2,1) 基函数之差，系数差 = Σ 块 × dctBasis
+var dctBasis = func() (basis [8][8]float64) {
+	cosine := func(u, x int) float64 { return math.Cos(float64((2*x+1)*u) * math.Pi / 16) }
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			// 正交归一化系数 α(1)·α(2) = 1/2 · 1/2
+			basis[y][x] = (cosine(1, x)*cosine(2, y) - cosine(2, x)*cosine(1, y)) / 4
+		}
+	}
+	return basis
+}()
+
+// ValidatePayload 检查不可见水印的载荷
+func ValidatePayload(payload string) error {
+	switch {
+	case payload == "":
+		return fmt.Errorf("%w: 不可见水印的载荷不能为空", ErrInvalidOptions)
+	case len(payload) > MaxPayloadLength:
+		return fmt.Errorf("%w: 不可见水印的载荷最长 %d 字节，当前 %d 字节", ErrInvalidOptions, MaxPayloadLength, len(payload))
+	}
+	return nil
+}
+
+// embedPayload 在图像中嵌入不可见水印，返回新图像
+func embedPayload(img image.Image, payload string) (image.Image, error) {
+	if err := ValidatePayload(payload); err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	if min(bounds.Dx(), bounds.Dy()) < MinInvisibleSize {
+		return nil, fmt.Errorf("%w: 嵌入不可见水印要求图像短边至少 %d 像素", ErrInvalidOptions, MinInvisibleSize)
+	}
+
+	bits := frameBits(payload)
+	src := imaging.Clone(img)
+	residual := make([]float64, invisibleSize*invisibleSize)
+	dst := src
+	for pass := 0; pass < invisiblePasses; pass++ {
+		// 测量当前结果中各块的系数差，把不足目标值的块补足
+		grid := luminanceGrid(dst)
+		changed := false
+		for j, block := range invisibleOrder {
+			want := bits[j%invisibleBits]
+			d := blockDiff(grid, block)
+			if want*d >= invisibleStrength {
+				continue
+			}
+			addBasis(residual, block, want*(invisibleStrength-want*d))
+			changed = true
+		}
+		if !changed {
+			break
+		}
+		dst = applyResidual(src, residual)
+	}
+	return dst, nil
+}
+
+// DetectPayload 检测并返回图像中的不可见水印载荷，没有检测到时返回 ErrNoPayload。
+// 图像必须是完整的画面（可以缩放和重新压缩），裁剪或旋转过的图像无法检测
+func DetectPayload(img image.Image) (string, error) {
+	grid := luminanceGrid(img)
+	sums := make([]float64, invisibleBits)
+	for j, block := range invisibleOrder {
+		sums[j%invisibleBits] += blockDiff(grid, block)
+	}
+
+	frame := make([]byte, invisibleFrame)
+	for i, s := range sums {
+		if s > 0 {
+			frame[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	n := int(frame[0])
+	body := frame[:1+MaxPayloadLength]
+	if n == 0 || n > MaxPayloadLength || crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(frame[1+MaxPayloadLength:]) {
+		return "", ErrNoPayload
+	}
+	return string(frame[1 : 1+n]), nil
+}
+
+// DetectPayloadFile 检测图像文件中的不可见水印
+func DetectPayloadFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("无法打开图像: %w", err)
+	}
+	img, err := Decode(data, DefaultDecodeOptions())
+	if err != nil {
+		return "", fmt.Errorf("无法打开图像: %w", err)
+	}
+	return DetectPayload(img)
+}
+
+// frameBits 将载荷编码为数据帧，返回每一位对应的 ±1
+func frameBits(payload string) []float64 {
+	frame := make([]byte, invisibleFrame)
+	frame[0] = byte(len(payload))
+	copy(frame[1:], payload)
+	binary.BigEndian.PutUint32(frame[1+MaxPayloadLength:], crc32.ChecksumIEEE(frame[:1+MaxPayloadLength]))
+
+	bits := make([]float64, invisibleBits)
+	for i := range bits {
+		bits[i] = -1
+		if frame[i/8]&(0x80>>(i%8)) != 0 {
+			bits[i] = 1
+		}
+	}
+	return bits
+}
+
+// luminanceGrid 将图像缩放到嵌入网格并返回各点的亮度
+func luminanceGrid(img image.Image) []float64 {
+	small := imaging.Resize(img, invisibleSize, invisibleSize, imaging.Linear)
+	grid := make([]float64, invisibleSize*invisibleSize)
+	for i := range grid {
+		p := small.Pix[i*4 : i*4+3]
+		grid[i] = 0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])
+	}
+	return grid
+}
+
+// blockDiff 返回网格中第 block 块的 (1,2) 与 (2,1) 系数之差
+func blockDiff(grid []float64, block int) float64 {
+	ox, oy := block%(invisibleSize/8)*8, block/(invisibleSize/8)*8
+	d := 0.0
+	for y := 0; y < 8; y++ {
+		row := grid[(oy+y)*invisibleSize+ox:]
+		for x := 0; x < 8; x++ {
+			d += row[x] * dctBasis[y][x]
+		}
+	}
+	return d
+}
+
+// addBasis 使第 block 块的系数差增加 delta（两个系数各改变 delta/2）
+func addBasis(grid []float64, block int, delta float64) {
+	ox, oy := block%(invisibleSize/8)*8, block/(invisibleSize/8)*8
+	// dctBasis 是两个正交基之差，自身的范数平方为 2
+	scale := delta / 2
+	for y := 0; y < 8; y++ {
+		row := grid[(oy+y)*invisibleSize+ox:]
+		for x := 0; x < 8; x++ {
+			row[x] += scale * dctBasis[y][x]
+		}
+	}
+}
+
+// applyResidual 将网格上的亮度修改量双线性放大到原图尺寸，叠加到 R、G、B 通道
+func applyResidual(src *image.NRGBA, residual []float64) *image.NRGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(src.Rect)
+	copy(dst.Pix, src.Pix)
+
+	// 每个原图像素在网格上对应的坐标（像素中心对齐）
+	sample := func(i, n int) (int, int, float64) {
+		f := (float64(i)+0.5)*invisibleSize/float64(n) - 0.5
+		f = math.Max(0, math.Min(f, invisibleSize-1))
+		i0 := int(f)
+		return i0, min(i0+1, invisibleSize-1), f - float64(i0)
+	}
+	for y := 0; y < h; y++ {
+		y0, y1, fy := sample(y, h)
+		for x := 0; x < w; x++ {
+			x0, x1, fx := sample(x, w)
+			top := residual[y0*invisibleSize+x0]*(1-fx) + residual[y0*invisibleSize+x1]*fx
+			bottom := residual[y1*invisibleSize+x0]*(1-fx) + residual[y1*invisibleSize+x1]*fx
+			r := top*(1-fy) + bottom*fy
+			p := dst.Pix[y*dst.Stride+x*4:]
+			for c := 0; c < 3; c++ {
+				p[c] = uint8(math.Round(clamp(float64(p[c]) + r)))
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+const testPayload = "3f2a9c1e-7b44-4d0a-9e21-5c8f0a1b"
+
+// photoImage 返回带有渐变、色块和噪点的测试图像，近似照片的内容
+func photoImage(w, h int) *image.NRGBA {
+	r := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	type blob struct{ x, y, radius float64 }
+	blobs := make([]blob, 20)
+	for i := range blobs {
+		blobs[i] = blob{r.Float64() * float64(w), r.Float64() * float64(h), 20 + r.Float64()*float64(w)/4}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := 100 + 60*math.Sin(float64(x)/90) + 40*math.Cos(float64(y)/60)
+			for _, b := range blobs {
+				if math.Hypot(float64(x)-b.x, float64(y)-b.y) < b.radius {
+					v = (v + 200) / 2
+				}
+			}
+			v += r.NormFloat64() * 4
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(clamp(v)), G: uint8(clamp(v * 0.9)), B: uint8(clamp(v * 0.7)), A: 255})
+		}
+	}
+	return img
+}
+
+// jpegRoundTrip 以指定质量重新压缩图像
+func jpegRoundTrip(t *testing.T, img image.Image, quality int) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func embedTestPayload(t *testing.T, img image.Image) image.Image {
+	t.Helper()
+	op := WatermarkOperation{Options: WatermarkOptions{Invisible: true, Payload: testPayload}}
+	marked, err := op.Apply(context.Background(), img)
+	if err != nil {
+		t.Fatalf("嵌入失败: %v", err)
+	}
+	return marked
+}
+
+func TestInvisibleRoundTrip(t *testing.T) {
+	orig := photoImage(768, MinInvisibleSize)
+	marked := embedTestPayload(t, orig)
+
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"direct", marked},
+		{"jpeg-q75", jpegRoundTrip(t, marked, 75)},
+		{"resize-50%", imaging.Resize(marked, 384, 0, imaging.Lanczos)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := DetectPayload(tt.img)
+			if err != nil {
+				t.Fatalf("检测失败: %v", err)
+			}
+			if payload != testPayload {
+				t.Errorf("载荷为 %q，期望 %q", payload, testPayload)
+			}
+		})
+	}
+
+	if _, err := DetectPayload(jpegRoundTrip(t, orig, 75)); !errors.Is(err, ErrNoPayload) {
+		t.Errorf("未嵌入水印的图像应返回 ErrNoPayload，实际 %v", err)
+	}
+}
+
+func TestInvisibleMinSize(t *testing.T) {
+	op := WatermarkOperation{Options: WatermarkOptions{Invisible: true, Payload: testPayload}}
+	_, err := op.Apply(context.Background(), photoImage(MinInvisibleSize*2, MinInvisibleSize-1))
+	if !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("短边小于 MinInvisibleSize 时应返回 ErrInvalidOptions，实际 %v", err)
+	}
+}
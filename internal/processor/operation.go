@@ -0,0 +1,263 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xiaoheiwowo/xpix/internal/config"
+	"github.com/xiaoheiwowo/xpix/internal/metadata"
+)
+
+// Operation 在内存中处理图像的操作
+type Operation interface {
+	Apply(ctx context.Context, img image.Image) (image.Image, error)
+}
+
+// OperationFunc 将普通函数适配为 Operation
+type OperationFunc func(ctx context.Context, img image.Image) (image.Image, error)
+
+// Apply 调用函数本身
+func (f OperationFunc) Apply(ctx context.Context, img image.Image) (image.Image, error) {
+	return f(ctx, img)
+}
+
+// ParamType 参数类型
+type ParamType string
+
+const (
+	ParamInt    ParamType = "int"
+	ParamFloat  ParamType = "float"
+	ParamBool   ParamType = "bool"
+	ParamString ParamType = "string"
+)
+
+// ParamSpec 操作参数说明
+type ParamSpec struct {
+	Name        string    // 参数名
+	Aliases     []string  // 别名（如 w 之于 width）
+	Type        ParamType // 参数类型
+	Default     string    // 默认值
+	Description string    // 说明
+}
+
+// OperationSpec 注册到操作表中的操作
+type OperationSpec struct {
+	Name        string                            // 操作名称
+	Description string                            // 说明
+	Params      []ParamSpec                       // 参数列表
+	New         func(p Params) (Operation, error) // 根据已校验的参数创建操作
+}
+
+// registry 已注册的操作
+var registry = make(map[string]*OperationSpec)
+
+// Register 注册操作，名称重复时 panic
+func Register(spec OperationSpec) {
+	if spec.Name == "" || spec.New == nil {
+		panic("processor: 注册的操作缺少名称或构造函数")
+	}
+	if _, dup := registry[spec.Name]; dup {
+		panic("processor: 重复注册操作 " + spec.Name)
+	}
+	registry[spec.Name] = &spec
+}
+
+// Lookup 按名称查找操作
+func Lookup(name string) (*OperationSpec, bool) {
+	spec, ok := registry[name]
+	return spec, ok
+}
+
+// Operations 返回按名称排序的全部已注册操作
+func Operations() []*OperationSpec {
+	specs := make([]*OperationSpec, 0, len(registry))
+	for _, spec := range registry {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+// NewOperation 按名称和字符串参数创建操作，参数会按参数说明校验
+func NewOperation(name string, kv map[string]string) (Operation, error) {
+	spec, ok := Lookup(name)
+	if !ok {
+		names := make([]string, 0, len(registry))
+		for _, s := range Operations() {
+			names = append(names, s.Name)
+		}
+		return nil, fmt.Errorf("未知的操作 %q（可选: %s）", name, strings.Join(names, ", "))
+	}
+
+	p, err := spec.parse(kv)
+	if err != nil {
+		return nil, err
+	}
+	return spec.New(p)
+}
+
+// parse 按参数说明解析并校验参数
+func (s *OperationSpec) parse(kv map[string]string) (Params, error) {
+	p := Params{op: s.Name, values: make(map[string]interface{}, len(s.Params)), given: make(map[string]bool)}
+	used := make(map[string]bool, len(kv))
+
+	for _, param := range s.Params {
+		raw := param.Default
+		key := param.Name
+		for _, k := range append([]string{param.Name}, param.Aliases...) {
+			if v, ok := kv[k]; ok {
+				raw, key = v, k
+				used[k] = true
+				p.given[param.Name] = true
+				break
+			}
+		}
+
+		v, err := parseValue(param.Type, raw)
+		if err != nil {
+			return Params{}, &ParamError{Op: s.Name, Key: key, Msg: fmt.Sprintf("%s，得到 %q", typeHints[param.Type], raw)}
+		}
+		p.values[param.Name] = v
+	}
+
+	var unknown []string
+	for key := range kv {
+		if !used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return Params{}, &ParamError{Op: s.Name, Key: unknown[0], Msg: "无法识别"}
+	}
+	return p, nil
+}
+
+// typeHints 参数类型错误时的提示
+var typeHints = map[ParamType]string{
+	ParamInt:   "需要整数",
+	ParamFloat: "需要数字",
+	ParamBool:  "需要 true 或 false",
+}
+
+// parseValue 将字符串解析为指定类型
+func parseValue(t ParamType, raw string) (interface{}, error) {
+	switch t {
+	case ParamInt:
+		if raw == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(raw)
+	case ParamFloat:
+		if raw == "" {
+			return 0.0, nil
+		}
+		return strconv.ParseFloat(raw, 64)
+	case ParamBool:
+		if raw == "" {
+			return false, nil
+		}
+		return strconv.ParseBool(raw)
+	default:
+		return raw, nil
+	}
+}
+
+// Params 已校验的操作参数
+type Params struct {
+	op     string
+	values map[string]interface{}
+	given  map[string]bool // 显式指定（而非取默认值）的参数
+}
+
+// Int 读取整数参数
+func (p Params) Int(name string) int { return p.values[name].(int) }
+
+// Float 读取数字参数
+func (p Params) Float(name string) float64 { return p.values[name].(float64) }
+
+// Bool 读取布尔参数
+func (p Params) Bool(name string) bool { return p.values[name].(bool) }
+
+// String 读取字符串参数
+func (p Params) String(name string) string { return p.values[name].(string) }
+
+// Has 判断参数是否被显式指定
+func (p Params) Has(name string) bool { return p.given[name] }
+
+// Errorf 返回与具体参数无关的参数错误
+func (p Params) Errorf(format string, args ...interface{}) error {
+	return &ParamError{Op: p.op, Msg: fmt.Sprintf(format, args...)}
+}
+
+// ParamError 操作参数错误
+type ParamError struct {
+	Op  string // 操作名称
+	Key string // 出错的参数名，为空表示与具体参数无关
+	Msg string
+}
+
+func (e *ParamError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s: %s", e.Op, e.Msg)
+	}
+	return fmt.Sprintf("%s: 参数 %s %s", e.Op, e.Key, e.Msg)
+}
+
+// ProcessFile 打开图像，依次应用操作并按编码选项保存结果
+func ProcessFile(ctx context.Context, inputPath, outputPath string, dec DecodeOptions, enc EncodeOptions, ops ...Operation) error {
+	// 打开图像
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("无法打开图像: %w", err)
+	}
+	img, err := Decode(data, dec)
+	if err != nil {
+		return fmt.Errorf("无法打开图像: %w", err)
+	}
+
+	// 源文件信息供操作使用（如水印文字中的 EXIF 占位符）
+	src := Source{Path: inputPath}
+	if m, err := ReadMetadata(bytes.NewReader(data)); err == nil {
+		src.Metadata = m
+	}
+	ctx = WithSource(ctx, src)
+	if _, ok := ctx.Value(configKey{}).(*config.Config); !ok {
+		ctx = WithConfig(ctx, config.Get())
+	}
+
+	var meta *metadata.Metadata
+	if !enc.StripMetadata {
+		meta = metadata.Read(data)
+		resetOrientation(data, dec, meta)
+	}
+
+	// 依次应用操作
+	result := img
+	for _, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if result, err = op.Apply(ctx, result); err != nil {
+			return err
+		}
+	}
+
+	// 保存结果
+	outputPath, warnings, err := SaveImage(result, outputPath, enc, meta)
+	if err != nil {
+		return fmt.Errorf("无法保存图像: %w", err)
+	}
+	for _, w := range warnings {
+		fmt.Printf("⚠️  %s: %v\n", inputPath, w)
+	}
+
+	fmt.Printf("✅ 图像已保存至: %s\n", outputPath)
+	return nil
+}
@@ -0,0 +1,140 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// Step 流水线中的单个处理步骤
+type Step struct {
+	Op        string            // 操作名称
+	Params    map[string]string // 原始参数
+	Operation Operation         // 根据参数创建的操作
+}
+
+// ParseStep 解析 "op:key=value,key=value" 格式的步骤描述。
+// 参数值中包含逗号时可以用双引号包裹，例如 watermark:text="© Alice, 2025"
+func ParseStep(spec string) (Step, error) {
+	op, rest, _ := strings.Cut(spec, ":")
+	op = strings.TrimSpace(op)
+
+	kv, err := splitParams(rest)
+	if err != nil {
+		return Step{}, fmt.Errorf("步骤 %q: %w", spec, err)
+	}
+	return NewStep(op, kv)
+}
+
+// NewStep 根据操作名称和参数创建步骤，参数会在此时校验
+func NewStep(op string, kv map[string]string) (Step, error) {
+	operation, err := NewOperation(op, kv)
+	if err != nil {
+		return Step{}, err
+	}
+	return Step{Op: op, Params: kv, Operation: operation}, nil
+}
+
+// Apply 执行步骤中的操作
+func (s Step) Apply(ctx context.Context, img image.Image) (image.Image, error) {
+	return s.Operation.Apply(ctx, img)
+}
+
+// Pipeline 依次执行多个步骤，整个过程只解码和编码一次
+func Pipeline(inputPath, outputPath string, steps []Step) error {
+	return ProcessFile(context.Background(), inputPath, outputPath, DefaultDecodeOptions(), DefaultEncodeOptions(), StepsOperation(steps))
+}
+
+// StepsOperation 将多个步骤组合为一个操作，出错时标明是第几步
+func StepsOperation(steps []Step) Operation {
+	return OperationFunc(func(ctx context.Context, img image.Image) (image.Image, error) {
+		result := img
+		for i, step := range steps {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			var err error
+			result, err = step.Apply(ctx, result)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 步 (%s) 失败: %w", i+1, step.Op, err)
+			}
+		}
+		return result, nil
+	})
+}
+
+// splitParams 解析 key=value,key=value 形式的参数。
+//
+// 键和未加引号的值两侧的空白会被去掉；值中包含逗号或首尾空白时用双引号包裹整个值，
+// 引号内用 \" 表示双引号、\\ 表示反斜杠，其他反斜杠按原样保留（便于书写 Windows 路径）
+func splitParams(s string) (map[string]string, error) {
+	kv := make(map[string]string)
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return kv, nil
+	}
+
+	// 按引号外的逗号拆分，保留引号和转义，由 unquoteParam 处理
+	var fields []string
+	start, inQuote := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && inQuote && i+1 < len(s):
+			i++
+		case s[i] == '"':
+			inQuote = !inQuote
+		case s[i] == ',' && !inQuote:
+			fields = append(fields, s[start:i])
+			start = i + 1
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("%w: 引号未闭合", ErrInvalidOptions)
+	}
+	fields = append(fields, s[start:])
+
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%w: 参数 %q 格式错误，应为 key=value", ErrInvalidOptions, strings.TrimSpace(field))
+		}
+		if _, dup := kv[key]; dup {
+			return nil, fmt.Errorf("%w: 参数 %s 重复指定", ErrInvalidOptions, key)
+		}
+		v, err := unquoteParam(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%w: 参数 %s: %v", ErrInvalidOptions, key, err)
+		}
+		kv[key] = v
+	}
+	return kv, nil
+}
+
+// unquoteParam 去掉包裹整个值的双引号并处理转义，未加引号的值中不能出现双引号
+func unquoteParam(v string) (string, error) {
+	if !strings.HasPrefix(v, `"`) {
+		if strings.Contains(v, `"`) {
+			return "", fmt.Errorf("双引号只能包裹整个值，得到 %s", v)
+		}
+		return v, nil
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(v); i++ {
+		switch c := v[i]; {
+		case c == '\\' && i+1 < len(v) && (v[i+1] == '"' || v[i+1] == '\\'):
+			b.WriteByte(v[i+1])
+			i++
+		case c == '"':
+			if i != len(v)-1 {
+				return "", fmt.Errorf("右引号后不能再有内容，得到 %s", v)
+			}
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("引号未闭合")
+}
@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplitParams(t *testing.T) {
+	tests := []struct {
+		in   string
+		want map[string]string
+	}{
+		{"", map[string]string{}},
+		{"w=2000", map[string]string{"w": "2000"}},
+		{" contrast = 10 , saturation=5 ", map[string]string{"contrast": "10", "saturation": "5"}},
+		{`text="a, b",position=bottom-right`, map[string]string{"text": "a, b", "position": "bottom-right"}},
+		{`text=" padded "`, map[string]string{"text": " padded "}},
+		{"text=a=b", map[string]string{"text": "a=b"}},
+		{`text="x=1, y=2"`, map[string]string{"text": "x=1, y=2"}},
+		{`text="say \"hi\", bye"`, map[string]string{"text": `say "hi", bye`}},
+		{`image="C:\logos\a.png"`, map[string]string{"image": `C:\logos\a.png`}},
+		{`text="back\\slash"`, map[string]string{"text": `back\slash`}},
+		{`text=""`, map[string]string{"text": ""}},
+		{"text=", map[string]string{"text": ""}},
+		{"text=©,opacity=0.5", map[string]string{"text": "©", "opacity": "0.5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := splitParams(tt.in)
+			if err != nil {
+				t.Fatalf("splitParams 失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("结果为 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitParamsErrors(t *testing.T) {
+	for _, in := range []string{
+		`text="a, b`,
+		`text="a \"quoted\"`,
+		`text="a"b`,
+		`text=a"b"`,
+		"w=1,,h=2",
+		"w=1,h",
+		"=5",
+		"w=1,w=2",
+	} {
+		t.Run(in, func(t *testing.T) {
+			if _, err := splitParams(in); !errors.Is(err, ErrInvalidOptions) {
+				t.Errorf("期望 ErrInvalidOptions，实际 %v", err)
+			}
+		})
+	}
+}
+
+func TestParseStep(t *testing.T) {
+	step, err := ParseStep(`watermark:text="© Alice, 2025", position=bottom-right`)
+	if err != nil {
+		t.Fatalf("ParseStep 失败: %v", err)
+	}
+	want := map[string]string{"text": "© Alice, 2025", "position": "bottom-right"}
+	if step.Op != "watermark" || !reflect.DeepEqual(step.Params, want) {
+		t.Errorf("步骤为 %s %q，期望 watermark %q", step.Op, step.Params, want)
+	}
+
+	if step, err := ParseStep(" resize : w=800"); err != nil || step.Op != "resize" {
+		t.Errorf("操作名两侧的空白应被忽略，得到 %q、%v", step.Op, err)
+	}
+
+	for _, spec := range []string{
+		"blur:radius=2",
+		"resize:width=abc",
+		"resize:w=800,unknown=1",
+		`watermark:text="unterminated`,
+	} {
+		if _, err := ParseStep(spec); err == nil {
+			t.Errorf("%q 应返回错误", spec)
+		}
+	}
+	var pe *ParamError
+	if _, err := ParseStep("resize:w=abc"); !errors.As(err, &pe) || pe.Key != "w" {
+		t.Errorf("类型错误应返回参数 w 的 ParamError，实际 %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"image"
 
@@ -14,12 +15,46 @@ type ResizeOptions struct {
 	KeepRatio bool // 保持宽高比
 }
 
+// ResizeOperation 调整尺寸操作
+type ResizeOperation struct {
+	Options ResizeOptions
+}
+
+// Apply 调整图像尺寸
+func (op ResizeOperation) Apply(ctx context.Context, img image.Image) (image.Image, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return resizeImage(img, op.Options)
+}
+
+func init() {
+	Register(OperationSpec{
+		Name:        "resize",
+		Description: "调整图像尺寸",
+		Params: []ParamSpec{
+			{Name: "width", Aliases: []string{"w"}, Type: ParamInt, Default: "0", Description: "目标宽度"},
+			{Name: "height", Aliases: []string{"h"}, Type: ParamInt, Default: "0", Description: "目标高度"},
+			{Name: "keep-ratio", Aliases: []string{"keep_ratio", "k"}, Type: ParamBool, Default: "true", Description: "保持宽高比"},
+		},
+		New: func(p Params) (Operation, error) {
+			opts := ResizeOptions{Width: p.Int("width"), Height: p.Int("height"), KeepRatio: p.Bool("keep-ratio")}
+			if opts.Width <= 0 && opts.Height <= 0 {
+				return nil, p.Errorf("至少需要指定 width 或 height")
+			}
+			return ResizeOperation{Options: opts}, nil
+		},
+	})
+}
+
 // Resize 调整图像尺寸
 func Resize(inputPath, outputPath string, opts ResizeOptions) error {
-	// 打开图像
-	img, err := imaging.Open(inputPath)
-	if err != nil {
-		return fmt.Errorf("无法打开图像: %w", err)
+	return ProcessFile(context.Background(), inputPath, outputPath, DefaultDecodeOptions(), DefaultEncodeOptions(), ResizeOperation{Options: opts})
+}
+
+func resizeImage(img image.Image, opts ResizeOptions) (image.Image, error) {
+	if opts.Width < 0 || opts.Height < 0 || (opts.Width == 0 && opts.Height == 0) {
+		return nil, fmt.Errorf("%w: 至少需要指定一个正的宽度或高度", ErrInvalidOptions)
 	}
 
 	var result image.Image
@@ -39,12 +74,6 @@ func Resize(inputPath, outputPath string, opts ResizeOptions) error {
 		result = imaging.Resize(img, opts.Width, opts.Height, imaging.Lanczos)
 	}
 
-	// 保存结果
-	if err := imaging.Save(result, outputPath); err != nil {
-		return fmt.Errorf("无法保存图像: %w", err)
-	}
-
-	fmt.Printf("✅ 图像已保存至: %s\n", outputPath)
-	return nil
+	return result, nil
 }
 
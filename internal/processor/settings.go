@@ -0,0 +1,26 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/xiaoheiwowo/xpix/internal/config"
+)
+
+// builtinConfig 内置默认配置，context 中没有配置时使用，不读取配置文件
+var builtinConfig = config.DefaultConfig()
+
+type configKey struct{}
+
+// WithConfig 返回携带配置的 context，操作中零值的选项取该配置中的值。
+// ProcessFile 在 context 没有配置时携带 config.Get()，直接调用 Apply 时使用内置默认值
+func WithConfig(ctx context.Context, cfg *config.Config) context.Context {
+	return context.WithValue(ctx, configKey{}, cfg)
+}
+
+// configFromContext 返回 context 中的配置，没有时返回内置默认配置
+func configFromContext(ctx context.Context) *config.Config {
+	if cfg, ok := ctx.Value(configKey{}).(*config.Config); ok && cfg != nil {
+		return cfg
+	}
+	return builtinConfig
+}
@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/xiaoheiwowo/xpix/internal/config"
+)
+
+func TestConfigFromContext(t *testing.T) {
+	saved := config.GlobalConfig
+	defer func() { config.GlobalConfig = saved }()
+
+	// 全局配置（命令行加载的配置文件）不影响没有携带配置的 context
+	global := config.DefaultConfig()
+	global.Watermark.Blend = BlendMultiply
+	config.GlobalConfig = global
+
+	if got := (WatermarkOptions{}).blendMode(configFromContext(context.Background())); got != builtinConfig.Watermark.Blend {
+		t.Errorf("没有携带配置时应使用内置默认值 %q，实际 %q", builtinConfig.Watermark.Blend, got)
+	}
+	if got := (WatermarkOptions{}).blendMode(configFromContext(WithConfig(context.Background(), global))); got != BlendMultiply {
+		t.Errorf("应使用 context 中的配置 %q，实际 %q", BlendMultiply, got)
+	}
+}
+
+func TestFrameValidateConfig(t *testing.T) {
+	saved := config.GlobalConfig
+	defer func() { config.GlobalConfig = saved }()
+
+	global := config.DefaultConfig()
+	global.Frame.Layout = "bogus"
+	config.GlobalConfig = global
+
+	// Validate 与直接调用 Apply 一样使用内置默认配置，不受全局配置影响
+	if err := (FrameOptions{}).Validate(); err != nil {
+		t.Errorf("Validate 不应读取全局配置，实际 %v", err)
+	}
+	if err := (FrameOptions{}).ValidateWith(global); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("ValidateWith 应按给定配置校验，实际 %v", err)
+	}
+	if _, err := NewOperation("frame", map[string]string{}); err == nil {
+		t.Error("配方和 pipeline 步骤应按全局配置校验")
+	}
+}
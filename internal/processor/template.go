@@ -0,0 +1,232 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Source 正在处理的源文件，由 ProcessFile 通过 context 传给各个操作
+type Source struct {
+	Path     string         // 源文件路径
+	Metadata *ImageMetadata // 源文件的 EXIF 元数据，没有 EXIF 时为 nil
+}
+
+type sourceKey struct{}
+
+// WithSource 返回携带源文件信息的 context
+func WithSource(ctx context.Context, src Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, src)
+}
+
+// SourceFromContext 返回 context 中的源文件信息
+func SourceFromContext(ctx context.Context) (Source, bool) {
+	src, ok := ctx.Value(sourceKey{}).(Source)
+	return src, ok
+}
+
+// placeholder 文字模板中的占位符
+type placeholder struct {
+	description string
+	value       func(src Source, layout string) string
+}
+
+// placeholders 支持的占位符，EXIF 字段缺失时替换为空字符串
+var placeholders = map[string]placeholder{
+	"Make":         {"相机品牌", exifField(func(m *ImageMetadata) string { return m.Make })},
+	"Model":        {"相机型号", exifField(func(m *ImageMetadata) string { return m.Model })},
+	"Camera":       {"相机品牌和型号（型号已包含品牌时只取型号）", exifField(cameraName)},
+	"LensModel":    {"镜头型号", exifField(func(m *ImageMetadata) string { return m.LensModel })},
+	"FNumber":      {"光圈值，如 1.8", exifField(func(m *ImageMetadata) string { return formatFNumber(m.FNumber) })},
+	"ExposureTime": {"快门速度，如 1/250", exifField(func(m *ImageMetadata) string { return formatExposureTime(m.ExposureTime) })},
+	"ISO":          {"ISO 感光度", exifField(func(m *ImageMetadata) string { return m.ISO })},
+	"FocalLength":  {"焦距（毫米），如 35", exifField(func(m *ImageMetadata) string { return formatFocalLength(m.FocalLength) })},
+	"ExposureBias": {"曝光补偿（EV），如 +0.7", exifField(func(m *ImageMetadata) string { return formatExposureBias(m.ExposureBias) })},
+	"DateTime": {"拍摄时间，可指定 Go 时间格式，如 {DateTime:2006-01-02}", func(src Source, layout string) string {
+		if src.Metadata == nil || src.Metadata.DateTime.IsZero() {
+			return ""
+		}
+		if layout == "" {
+			layout = "2006-01-02 15:04:05"
+		}
+		return src.Metadata.DateTime.Format(layout)
+	}},
+	"Filename": {"源文件名", func(src Source, _ string) string {
+		if src.Path == "" {
+			return ""
+		}
+		return filepath.Base(src.Path)
+	}},
+}
+
+func exifField(get func(m *ImageMetadata) string) func(Source, string) string {
+	return func(src Source, _ string) string {
+		if src.Metadata == nil {
+			return ""
+		}
+		return get(src.Metadata)
+	}
+}
+
+// Placeholders 返回按名称排序的占位符及说明，用于帮助信息
+func Placeholders() [][2]string {
+	names := make([]string, 0, len(placeholders))
+	for name := range placeholders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([][2]string, len(names))
+	for i, name := range names {
+		list[i] = [2]string{name, placeholders[name].description}
+	}
+	return list
+}
+
+// ValidateTemplate 检查文字模板中的占位符是否都能识别
+func ValidateTemplate(text string) error {
+	_, err := ExpandTemplate(text, Source{})
+	return err
+}
+
+// ExpandTemplate 用源文件的 EXIF 元数据和文件名替换文字中的占位符。
+//
+// 占位符写作 {Name} 或 {Name:参数}，{{ 和 }} 表示字面量的花括号。
+// EXIF 中缺少的字段替换为空字符串，无法识别的占位符返回错误。
+func ExpandTemplate(text string, src Source) (string, error) {
+	out, _, err := expandTemplate(text, src)
+	return out, err
+}
+
+// expandLine 与 ExpandTemplate 相同，但当文字中的占位符全部为空时返回空字符串，
+// 用于省略照片没有相关 EXIF 信息的整行文字（如 "{FocalLength}mm f/{FNumber}"）
+func expandLine(text string, src Source) (string, error) {
+	out, empty, err := expandTemplate(text, src)
+	if empty {
+		return "", err
+	}
+	return out, err
+}
+
+// expandTemplate 替换占位符，empty 表示文字中有占位符且全部替换为空
+func expandTemplate(text string, src Source) (out string, empty bool, err error) {
+	if !strings.ContainsAny(text, "{}") {
+		return text, false, nil
+	}
+
+	total, filled := 0, 0
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case (c == '{' || c == '}') && i+1 < len(text) && text[i+1] == c:
+			b.WriteByte(c)
+			i++
+		case c == '{':
+			end := strings.IndexByte(text[i:], '}')
+			if end < 0 {
+				return "", false, fmt.Errorf("%w: 文字模板中的 { 没有对应的 }（字面量花括号请写作 {{）", ErrInvalidOptions)
+			}
+			name, arg, _ := strings.Cut(text[i+1:i+end], ":")
+			p, ok := placeholders[name]
+			if !ok {
+				names := make([]string, 0, len(placeholders))
+				for _, item := range Placeholders() {
+					names = append(names, item[0])
+				}
+				return "", false, fmt.Errorf("%w: 未知的占位符 {%s}（可选: %s）", ErrInvalidOptions, name, strings.Join(names, ", "))
+			}
+			v := p.value(src, arg)
+			total++
+			if v != "" {
+				filled++
+			}
+			b.WriteString(v)
+			i += end
+		case c == '}':
+			return "", false, fmt.Errorf("%w: 文字模板中的 } 没有对应的 {（字面量花括号请写作 }}）", ErrInvalidOptions)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), total > 0 && filled == 0, nil
+}
+
+// cameraName 返回相机品牌和型号，型号以品牌开头时（如 Canon 的 "Canon EOS R5"）只取型号
+func cameraName(m *ImageMetadata) string {
+	brand, model := strings.TrimSpace(m.Make), strings.TrimSpace(m.Model)
+	switch {
+	case model == "":
+		return brand
+	case brand == "" || strings.HasPrefix(strings.ToLower(model), strings.ToLower(brand)):
+		return model
+	}
+	return brand + " " + model
+}
+
+// parseRational 解析 EXIF 有理数（如 "18/10"）或普通数字
+func parseRational(s string) (float64, bool) {
+	num, den, isRat := strings.Cut(s, "/")
+	n, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil {
+		return 0, false
+	}
+	if !isRat {
+		return n, true
+	}
+	d, err := strconv.ParseFloat(strings.TrimSpace(den), 64)
+	if err != nil || d == 0 {
+		return 0, false
+	}
+	return n / d, true
+}
+
+// formatFNumber 光圈值保留一位小数，整数时省略小数（18/10 → 1.8，8/1 → 8）
+func formatFNumber(s string) string {
+	v, ok := parseRational(s)
+	if !ok {
+		return s
+	}
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
+// formatExposureTime 快门速度写作 1/N 或秒数（10/2500 → 1/250，4/10 → 0.4，25/10 → 2.5）
+func formatExposureTime(s string) string {
+	v, ok := parseRational(s)
+	if !ok || v <= 0 {
+		return s
+	}
+	if inv := 1 / v; v < 1 && (v <= 0.3 || math.Abs(inv-math.Round(inv)) < 0.05) {
+		return "1/" + strconv.FormatFloat(math.Round(inv), 'f', -1, 64)
+	}
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
+// formatFocalLength 焦距取整（350/10 → 35）
+func formatFocalLength(s string) string {
+	v, ok := parseRational(s)
+	if !ok {
+		return s
+	}
+	return strconv.FormatFloat(math.Round(v), 'f', -1, 64)
+}
+
+// formatExposureBias 曝光补偿保留一位小数并带符号（2/3 → +0.7，0/1 → 0）
+func formatExposureBias(s string) string {
+	v, ok := parseRational(s)
+	if !ok {
+		return s
+	}
+	v = math.Round(v*10) / 10
+	switch {
+	case v == 0:
+		return "0"
+	case v > 0:
+		return "+" + strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+}
@@ -0,0 +1,147 @@
+package processor
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// testSource 返回带常见 EXIF 字段的源文件
+func testSource() Source {
+	return Source{
+		Path: "/photos/DSC_0001.jpg",
+		Metadata: &ImageMetadata{
+			Make:         "Canon",
+			Model:        "Canon EOS R5",
+			LensModel:    "RF24-70mm F2.8 L IS USM",
+			DateTime:     time.Date(2024, 5, 1, 8, 30, 15, 0, time.UTC),
+			ISO:          "200",
+			FNumber:      "28/10",
+			ExposureTime: "10/2500",
+			FocalLength:  "350/10",
+			ExposureBias: "2/3",
+		},
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		src  Source
+		want string
+	}{
+		{"plain", "no placeholders", testSource(), "no placeholders"},
+		{"camera", "{Camera}", testSource(), "Canon EOS R5"},
+		{"info", "{FocalLength}mm f/{FNumber} {ExposureTime}s ISO{ISO} {ExposureBias}EV", testSource(), "35mm f/2.8 1/250s ISO200 +0.7EV"},
+		{"date-default", "{DateTime}", testSource(), "2024-05-01 08:30:15"},
+		{"date-layout", "{DateTime:2006.01.02 15:04}", testSource(), "2024.05.01 08:30"},
+		{"filename", "{Filename}", testSource(), "DSC_0001.jpg"},
+		{"escape", "{{Camera}} {{{Camera}}}", testSource(), "{Camera} {Canon EOS R5}"},
+		{"missing-exif", "[{Camera}]", Source{Path: "a.jpg"}, "[]"},
+		{"missing-date", "{DateTime:2006}", Source{Metadata: &ImageMetadata{}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandTemplate(tt.text, tt.src)
+			if err != nil {
+				t.Fatalf("ExpandTemplate 失败: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("结果为 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTemplateErrors(t *testing.T) {
+	for _, text := range []string{
+		"{Unknown}",
+		"{camera}",
+		"f/{FNumber",
+		"ISO}",
+		"{Camera}}",
+	} {
+		t.Run(text, func(t *testing.T) {
+			if _, err := ExpandTemplate(text, testSource()); !errors.Is(err, ErrInvalidOptions) {
+				t.Errorf("期望 ErrInvalidOptions，实际 %v", err)
+			}
+			if err := ValidateTemplate(text); err == nil {
+				t.Error("ValidateTemplate 应返回错误")
+			}
+		})
+	}
+}
+
+func TestExpandLine(t *testing.T) {
+	noExif := Source{Path: "a.jpg"}
+	tests := []struct {
+		name string
+		text string
+		src  Source
+		want string
+	}{
+		{"all-empty", "{FocalLength}mm f/{FNumber}", noExif, ""},
+		{"partly-filled", "{FocalLength}mm {Filename}", noExif, "mm a.jpg"},
+		{"no-placeholders", "© 2024", noExif, "© 2024"},
+		{"filled", "{FocalLength}mm", testSource(), "35mm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandLine(tt.text, tt.src)
+			if err != nil {
+				t.Fatalf("expandLine 失败: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("结果为 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := expandLine("{Unknown}", noExif); err == nil {
+		t.Error("未知的占位符应返回错误")
+	}
+}
+
+func TestFormatEXIFValues(t *testing.T) {
+	tests := []struct {
+		format func(string) string
+		in     string
+		want   string
+	}{
+		{formatExposureTime, "10/2500", "1/250"},
+		{formatExposureTime, "1/8000", "1/8000"},
+		{formatExposureTime, "1/3", "1/3"},
+		{formatExposureTime, "4/10", "0.4"},
+		{formatExposureTime, "25/10", "2.5"},
+		{formatExposureTime, "30/1", "30"},
+		{formatExposureTime, "abc", "abc"},
+		{formatFNumber, "18/10", "1.8"},
+		{formatFNumber, "8/1", "8"},
+		{formatFNumber, "5.6", "5.6"},
+		{formatFNumber, "1/0", "1/0"},
+		{formatFocalLength, "350/10", "35"},
+		{formatExposureBias, "2/3", "+0.7"},
+		{formatExposureBias, "-1/3", "-0.3"},
+		{formatExposureBias, "0/1", "0"},
+	}
+	for _, tt := range tests {
+		if got := tt.format(tt.in); got != tt.want {
+			t.Errorf("%q 格式化为 %q，期望 %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCameraName(t *testing.T) {
+	tests := []struct{ make, model, want string }{
+		{"Canon", "Canon EOS R5", "Canon EOS R5"},
+		{"SONY", "ILCE-7M4", "SONY ILCE-7M4"},
+		{"FUJIFILM ", "", "FUJIFILM"},
+		{"", "iPhone 15 Pro", "iPhone 15 Pro"},
+	}
+	for _, tt := range tests {
+		if got := cameraName(&ImageMetadata{Make: tt.make, Model: tt.model}); got != tt.want {
+			t.Errorf("%q %q 的相机名为 %q，期望 %q", tt.make, tt.model, got, tt.want)
+		}
+	}
+}
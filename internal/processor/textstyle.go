@@ -0,0 +1,271 @@
+package processor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/fogleman/gg"
+	"github.com/xiaoheiwowo/xpix/internal/config"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// 多行文字的对齐方式
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+)
+
+// TextStyle 文字水印的样式：描边、阴影、背景底板和排版
+type TextStyle struct {
+	StrokeWidth int    // 描边宽度（像素），0 表示不描边
+	StrokeColor string // 描边颜色 (#RRGGBB)
+
+	Shadow        bool    // 是否绘制阴影
+	ShadowOffsetX int     // 阴影的水平偏移（像素，向右为正）
+	ShadowOffsetY int     // 阴影的垂直偏移（像素，向下为正）
+	ShadowBlur    float64 // 阴影的模糊半径（高斯模糊的 sigma，像素）
+	ShadowColor   string  // 阴影颜色 (#RRGGBB)
+
+	Background        bool    // 是否绘制背景底板
+	BackgroundColor   string  // 底板颜色 (#RRGGBB)
+	BackgroundOpacity float64 // 底板透明度 (0-1)，与文字透明度无关
+	BackgroundPadding int     // 文字与底板边缘的距离（像素）
+	BackgroundRadius  int     // 底板圆角半径（像素）
+
+	Align         string  // 多行文字的对齐方式: left, center, right
+	LineSpacing   float64 // 行距（行高的倍数）
+	LetterSpacing float64 // 字间距（像素，可为负）
+}
+
+// DefaultTextStyle 返回配置文件中的文字样式
+func DefaultTextStyle() TextStyle {
+	return textStyleFrom(config.Get())
+}
+
+// textStyleFrom 返回配置中的文字样式
+func textStyleFrom(c *config.Config) TextStyle {
+	cfg := c.Watermark
+	return TextStyle{
+		StrokeWidth:       cfg.StrokeWidth,
+		StrokeColor:       cfg.StrokeColor,
+		Shadow:            cfg.Shadow,
+		ShadowOffsetX:     cfg.ShadowOffsetX,
+		ShadowOffsetY:     cfg.ShadowOffsetY,
+		ShadowBlur:        cfg.ShadowBlur,
+		ShadowColor:       cfg.ShadowColor,
+		Background:        cfg.Background,
+		BackgroundColor:   cfg.BackgroundColor,
+		BackgroundOpacity: cfg.BackgroundOpacity,
+		BackgroundPadding: cfg.BackgroundPadding,
+		BackgroundRadius:  cfg.BackgroundRadius,
+		Align:             cfg.Align,
+		LineSpacing:       cfg.LineSpacing,
+		LetterSpacing:     cfg.LetterSpacing,
+	}
+}
+
+// Validate 校验样式参数
+func (s TextStyle) Validate() error {
+	switch {
+	case s.StrokeWidth < 0:
+		return fmt.Errorf("%w: 描边宽度不能为负数", ErrInvalidOptions)
+	case s.ShadowBlur < 0:
+		return fmt.Errorf("%w: 阴影模糊半径不能为负数", ErrInvalidOptions)
+	case s.BackgroundOpacity < 0 || s.BackgroundOpacity > 1:
+		return fmt.Errorf("%w: 底板透明度必须在 0-1 之间", ErrInvalidOptions)
+	case s.BackgroundPadding < 0 || s.BackgroundRadius < 0:
+		return fmt.Errorf("%w: 底板边距和圆角半径不能为负数", ErrInvalidOptions)
+	case s.LineSpacing <= 0:
+		return fmt.Errorf("%w: 行距必须大于 0", ErrInvalidOptions)
+	}
+	switch s.Align {
+	case AlignLeft, AlignCenter, AlignRight:
+	default:
+		return fmt.Errorf("%w: 未知的对齐方式 %q（可选: left, center, right）", ErrInvalidOptions, s.Align)
+	}
+	return nil
+}
+
+// textStyle 返回生效的文字样式
+func (o WatermarkOptions) textStyle(cfg *config.Config) TextStyle {
+	if o.Style != nil {
+		return *o.Style
+	}
+	return textStyleFrom(cfg)
+}
+
+// splitLines 按换行符拆分文字，字面量 \n 也视为换行（便于在命令行和配方中书写）
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, `\n`, "\n")
+	return strings.Split(text, "\n")
+}
+
+// renderText 按样式将文字渲染到透明图像上。
+//
+// 图像四周为描边、阴影和字形外伸留出了对称的余量，block 为余量以内的文字块
+// （开启底板时为底板）所在的矩形，用于按可见内容对齐水印。
+// 文字、描边和阴影先以不透明的颜色叠加，再整体乘以 opacity，
+// 避免半透明文字透出下面的描边；底板使用自己的透明度。
+func renderText(face font.Face, text string, textColor string, opacity float64, style TextStyle) (stamp image.Image, block image.Rectangle) {
+	lines := splitLines(text)
+	metrics := face.Metrics()
+	ascent := metrics.Ascent.Ceil()
+	lineHeight := float64(metrics.Height) / 64
+	lineStep := lineHeight * style.LineSpacing
+	letterSpacing := fixed.Int26_6(math.Round(style.LetterSpacing * 64))
+
+	// 测量每一行的宽度
+	widths := make([]int, len(lines))
+	blockW := 0
+	for i, line := range lines {
+		widths[i] = measureLine(face, line, letterSpacing).Ceil()
+		blockW = max(blockW, widths[i])
+	}
+	blockH := int(math.Ceil(lineStep*float64(len(lines)-1))) + ascent + metrics.Descent.Ceil()
+
+	// 余量：字形外伸、描边和阴影
+	inset := int(math.Ceil(lineHeight/4)) + style.StrokeWidth
+	if style.Shadow {
+		inset += max(abs(style.ShadowOffsetX), abs(style.ShadowOffsetY)) + int(math.Ceil(3*style.ShadowBlur))
+	}
+	padding := 0
+	if style.Background {
+		padding = style.BackgroundPadding
+	}
+	width := blockW + 2*(inset+padding)
+	height := blockH + 2*(inset+padding)
+	bounds := image.Rect(0, 0, width, height)
+
+	// 文字遮罩
+	mask := image.NewAlpha(bounds)
+	d := &font.Drawer{Dst: mask, Src: image.Opaque, Face: face}
+	originX, originY := inset+padding, inset+padding
+	for i, line := range lines {
+		x := originX
+		switch style.Align {
+		case AlignCenter:
+			x += (blockW - widths[i]) / 2
+		case AlignRight:
+			x += blockW - widths[i]
+		}
+		y := originY + ascent + int(math.Round(lineStep*float64(i)))
+		d.Dot = fixed.P(x, y)
+		prev := rune(-1)
+		for _, r := range line {
+			if prev >= 0 {
+				d.Dot.X += face.Kern(prev, r) + letterSpacing
+			}
+			d.DrawString(string(r))
+			prev = r
+		}
+	}
+
+	// 阴影、描边和文字
+	layer := image.NewRGBA(bounds)
+	shape := mask
+	if style.StrokeWidth > 0 {
+		shape = dilate(mask, style.StrokeWidth)
+	}
+	if style.Shadow {
+		shadow := image.NewNRGBA(bounds)
+		draw.DrawMask(shadow, bounds.Add(image.Pt(style.ShadowOffsetX, style.ShadowOffsetY)),
+			image.NewUniform(parseColor(style.ShadowColor, 1)), image.Point{}, shape, image.Point{}, draw.Src)
+		var blurred image.Image = shadow
+		if style.ShadowBlur > 0 {
+			blurred = imaging.Blur(shadow, style.ShadowBlur)
+		}
+		draw.Draw(layer, bounds, blurred, image.Point{}, draw.Over)
+	}
+	if style.StrokeWidth > 0 {
+		draw.DrawMask(layer, bounds, image.NewUniform(parseColor(style.StrokeColor, 1)), image.Point{}, shape, image.Point{}, draw.Over)
+	}
+	draw.DrawMask(layer, bounds, image.NewUniform(parseColor(textColor, 1)), image.Point{}, mask, image.Point{}, draw.Over)
+
+	// 底板
+	dst := image.NewRGBA(bounds)
+	if style.Background {
+		dc := gg.NewContextForRGBA(dst)
+		dc.DrawRoundedRectangle(float64(inset), float64(inset), float64(blockW+2*padding), float64(blockH+2*padding), float64(style.BackgroundRadius))
+		c := parseColor(style.BackgroundColor, 1).(color.RGBA)
+		dc.SetColor(color.NRGBA{R: c.R, G: c.G, B: c.B, A: uint8(math.Round(style.BackgroundOpacity * 255))})
+		dc.Fill()
+	}
+
+	alpha := image.NewUniform(color.Alpha{A: uint8(math.Round(clamp(opacity * 255)))})
+	draw.DrawMask(dst, bounds, layer, image.Point{}, alpha, image.Point{}, draw.Over)
+	return dst, bounds.Inset(inset)
+}
+
+// measureLine 返回一行文字（含字间距和字距调整）的宽度
+func measureLine(face font.Face, line string, letterSpacing fixed.Int26_6) fixed.Int26_6 {
+	var w fixed.Int26_6
+	prev := rune(-1)
+	for _, r := range line {
+		if prev >= 0 {
+			w += face.Kern(prev, r) + letterSpacing
+		}
+		adv, _ := face.GlyphAdvance(r)
+		w += adv
+		prev = r
+	}
+	return max(w, 0)
+}
+
+// dilate 用半径为 radius 的圆盘对遮罩做膨胀运算，得到描边的轮廓。
+//
+// 先逐级求出各半宽下的水平方向最大值（半宽 h 的结果由 h-1 的结果左右各移一位求得），
+// 圆盘的每一行再取对应半宽的结果，复杂度为 O(像素数 × radius)。
+func dilate(mask *image.Alpha, radius int) *image.Alpha {
+	b := mask.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	rows := make([][]uint8, radius+1)
+	rows[0] = make([]uint8, w*h)
+	for y := 0; y < h; y++ {
+		copy(rows[0][y*w:(y+1)*w], mask.Pix[y*mask.Stride:])
+	}
+	for k := 1; k <= radius; k++ {
+		prev, cur := rows[k-1], make([]uint8, w*h)
+		for y := 0; y < h; y++ {
+			p, c := prev[y*w:(y+1)*w], cur[y*w:(y+1)*w]
+			for x := range c {
+				v := p[x]
+				if x > 0 {
+					v = max(v, p[x-1])
+				}
+				if x < w-1 {
+					v = max(v, p[x+1])
+				}
+				c[x] = v
+			}
+		}
+		rows[k] = cur
+	}
+
+	out := image.NewAlpha(b)
+	r2 := float64(radius*radius) + 0.5
+	for dy := -radius; dy <= radius; dy++ {
+		src := rows[int(math.Sqrt(r2-float64(dy*dy)))]
+		for y := max(0, -dy); y < min(h, h-dy); y++ {
+			s, o := src[(y+dy)*w:(y+dy+1)*w], out.Pix[y*out.Stride:y*out.Stride+w]
+			for x := range o {
+				o[x] = max(o[x], s[x])
+			}
+		}
+	}
+	return out
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
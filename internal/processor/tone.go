@@ -0,0 +1,193 @@
+package processor
+
+import (
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+// 高光、阴影、白色、黑色
+//
+// 高光和阴影按像素所在区域的平均亮度（基础层）决定调整量，基础层由引导滤波得到，
+// 在边缘处不会跨越明暗交界，因此调整后不会在物体边缘产生光晕；
+// 调整量加到基础层上，像素相对基础层的细节保持不变。
+// 白色和黑色按像素自身的亮度调整亮部和暗部的端点，相当于移动白场和黑场。
+
+const (
+	toneGridSize = 512  // 计算基础层的网格短边上限
+	toneEps      = 0.01 // 引导滤波的正则化参数，亮度方差大于它的区域视为边缘
+	toneRange    = 0.3  // 高光/阴影为 ±100 时基础层的最大变化量（亮度 0-1）
+	toneEndRange = 0.25 // 白色/黑色为 ±100 时端点的最大变化量
+	toneSpan     = 0.6  // 阴影只作用于基础层亮度低于它的区域，高光只作用于高于 1-toneSpan 的区域
+)
+
+// applyTone 应用高光、阴影、白色和黑色调整，取值均为 -100 到 100
+func applyTone(img image.Image, highlights, shadows, whites, blacks float64) image.Image {
+	dst := imaging.Clone(img)
+	w, h := dst.Rect.Dx(), dst.Rect.Dy()
+	if w == 0 || h == 0 {
+		return dst
+	}
+	highlights, shadows = highlights/100, shadows/100
+	whites, blacks = whites/100, blacks/100
+
+	var base func(x, y int, l float64) float64
+	if highlights != 0 || shadows != 0 {
+		base = baseLayer(dst)
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := dst.Pix[y*dst.Stride+x*4:]
+			l := luminance(p)
+			v := l
+			if base != nil {
+				b := base(x, y, l)
+				v += toneRange * (shadows*toneWeight(b) + highlights*toneWeight(1-b))
+			}
+			v = max(0, min(v, 1))
+			v += toneEndRange * (whites*smoothstep(0.5, 1, v) + blacks*(1-smoothstep(0, 0.5, v)))
+			v = max(0, min(v, 1))
+			setLuminance(p, l, v)
+		}
+	}
+	return dst
+}
+
+// toneWeight 阴影调整量随基础层亮度的权重：纯黑处为 0，在 toneSpan/3 处最大为 1，
+// 达到 toneSpan 后为 0，中间调和亮部不受影响；高光使用 toneWeight(1-b)
+func toneWeight(b float64) float64 {
+	u := b / toneSpan
+	if u >= 1 {
+		return 0
+	}
+	return u * (1 - u) * (1 - u) * 27 / 4
+}
+
+// smoothstep 在 [edge0, edge1] 内从 0 平滑过渡到 1
+func smoothstep(edge0, edge1, x float64) float64 {
+	t := max(0, min((x-edge0)/(edge1-edge0), 1))
+	return t * t * (3 - 2*t)
+}
+
+// luminance 返回 NRGBA 像素的亮度（0-1）
+func luminance(p []uint8) float64 {
+	return (0.299*float64(p[0]) + 0.587*float64(p[1]) + 0.114*float64(p[2])) / 255
+}
+
+// setLuminance 将亮度为 from 的像素调整到亮度 to，按比例缩放 R、G、B 以保持色相；
+// 接近纯黑的像素无法按比例缩放，改为各通道加上相同的量
+func setLuminance(p []uint8, from, to float64) {
+	if from == to {
+		return
+	}
+	for c := 0; c < 3; c++ {
+		v := float64(p[c])
+		if from > 1.0/255 {
+			v *= to / from
+		} else {
+			v += (to - from) * 255
+		}
+		p[c] = uint8(math.Round(clamp(v)))
+	}
+}
+
+// baseLayer 计算图像亮度的基础层，返回按像素坐标和亮度求基础层的函数。
+//
+// 使用快速引导滤波：在缩小到网格的亮度上求出线性系数 a、b，
+// 再双线性放大到原图尺寸，基础层 = a·亮度 + b。
+func baseLayer(img *image.NRGBA) func(x, y int, l float64) float64 {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	small := img
+	if short := min(w, h); short > toneGridSize {
+		small = imaging.Resize(img, w*toneGridSize/short, h*toneGridSize/short, imaging.Box)
+	}
+	gw, gh := small.Rect.Dx(), small.Rect.Dy()
+
+	grid := make([]float64, gw*gh)
+	for y := 0; y < gh; y++ {
+		for x := 0; x < gw; x++ {
+			grid[y*gw+x] = luminance(small.Pix[y*small.Stride+x*4:])
+		}
+	}
+	radius := max(2, min(gw, gh)/40)
+	a, b := guidedCoefficients(grid, grid, gw, gh, radius, toneEps)
+
+	sampleA, sampleB := upsampler(a, gw, gh, w, h), upsampler(b, gw, gh, w, h)
+	return func(x, y int, l float64) float64 {
+		return sampleA(x, y)*l + sampleB(x, y)
+	}
+}
+
+// guidedCoefficients 求引导滤波 q = a·guide + b 中按窗口平均后的系数 a、b，
+// radius 为方框窗口的半径，eps 越大结果越平滑
+func guidedCoefficients(guide, src []float64, w, h, radius int, eps float64) (a, b []float64) {
+	n := len(guide)
+	gs, gg := make([]float64, n), make([]float64, n)
+	for i := range guide {
+		gs[i] = guide[i] * src[i]
+		gg[i] = guide[i] * guide[i]
+	}
+	meanG := boxMean(guide, w, h, radius)
+	meanS := boxMean(src, w, h, radius)
+	meanGS := boxMean(gs, w, h, radius)
+	meanGG := boxMean(gg, w, h, radius)
+
+	a, b = make([]float64, n), make([]float64, n)
+	for i := range a {
+		variance := meanGG[i] - meanG[i]*meanG[i]
+		a[i] = (meanGS[i] - meanG[i]*meanS[i]) / (variance + eps)
+		b[i] = meanS[i] - a[i]*meanG[i]
+	}
+	return boxMean(a, w, h, radius), boxMean(b, w, h, radius)
+}
+
+// boxMean 返回以每个点为中心、半径为 radius 的方框内的平均值，边缘处只统计图像内的点
+func boxMean(src []float64, w, h, radius int) []float64 {
+	// 积分图多一行一列，sum[(y+1)*(w+1)+x+1] 为 [0,x]×[0,y] 的和
+	sum := make([]float64, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		row := 0.0
+		for x := 0; x < w; x++ {
+			row += src[y*w+x]
+			sum[(y+1)*(w+1)+x+1] = sum[y*(w+1)+x+1] + row
+		}
+	}
+
+	dst := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		y0, y1 := max(y-radius, 0), min(y+radius+1, h)
+		for x := 0; x < w; x++ {
+			x0, x1 := max(x-radius, 0), min(x+radius+1, w)
+			s := sum[y1*(w+1)+x1] - sum[y0*(w+1)+x1] - sum[y1*(w+1)+x0] + sum[y0*(w+1)+x0]
+			dst[y*w+x] = s / float64((x1-x0)*(y1-y0))
+		}
+	}
+	return dst
+}
+
+// upsampler 返回将 gw×gh 的网格双线性放大到 w×h 后按坐标取值的函数（像素中心对齐）
+func upsampler(grid []float64, gw, gh, w, h int) func(x, y int) float64 {
+	type tap struct {
+		i0, i1 int
+		f      float64
+	}
+	taps := func(n, gn int) []tap {
+		t := make([]tap, n)
+		for i := range t {
+			f := (float64(i)+0.5)*float64(gn)/float64(n) - 0.5
+			f = max(0, min(f, float64(gn-1)))
+			i0 := int(f)
+			t[i] = tap{i0, min(i0+1, gn-1), f - float64(i0)}
+		}
+		return t
+	}
+	xs, ys := taps(w, gw), taps(h, gh)
+	return func(x, y int) float64 {
+		tx, ty := xs[x], ys[y]
+		top := grid[ty.i0*gw+tx.i0]*(1-tx.f) + grid[ty.i0*gw+tx.i1]*tx.f
+		bottom := grid[ty.i1*gw+tx.i0]*(1-tx.f) + grid[ty.i1*gw+tx.i1]*tx.f
+		return top*(1-ty.f) + bottom*ty.f
+	}
+}
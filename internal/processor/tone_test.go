@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// toneLevel 对亮度为 v 的均匀灰色图像应用高光、阴影、白色、黑色调整，返回中心像素的值
+func toneLevel(v uint8, highlights, shadows, whites, blacks float64) int {
+	img := imaging.New(64, 64, color.NRGBA{R: v, G: v, B: v, A: 255})
+	return int(imaging.Clone(applyTone(img, highlights, shadows, whites, blacks)).NRGBAAt(32, 32).G)
+}
+
+func TestToneIdentity(t *testing.T) {
+	img := photoImage(200, 150)
+	if maxDelta, _ := compareImages(t, img, applyTone(img, 0, 0, 0, 0)); maxDelta != 0 {
+		t.Errorf("调整量均为 0 时像素发生变化，最大差值 %d", maxDelta)
+	}
+}
+
+func TestToneDirection(t *testing.T) {
+	tests := []struct {
+		name                                string
+		highlights, shadows, whites, blacks float64
+		changed, unchanged                  uint8 // 应明显变化和应基本不变的亮度
+		brighter                            bool
+	}{
+		{"highlights<0", -60, 0, 0, 0, 215, 30, false},
+		{"highlights>0", 60, 0, 0, 0, 190, 30, true},
+		{"shadows>0", 0, 60, 0, 0, 50, 240, true},
+		{"shadows<0", 0, -60, 0, 0, 70, 240, false},
+		{"whites>0", 0, 0, 60, 0, 220, 60, true},
+		{"blacks<0", 0, 0, 0, -60, 30, 200, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := toneLevel(tt.changed, tt.highlights, tt.shadows, tt.whites, tt.blacks)
+			if d := got - int(tt.changed); tt.brighter && d < 5 || !tt.brighter && d > -5 {
+				t.Errorf("亮度 %d 变为 %d，方向或幅度不正确", tt.changed, got)
+			}
+			if got := toneLevel(tt.unchanged, tt.highlights, tt.shadows, tt.whites, tt.blacks); absInt(got-int(tt.unchanged)) > 2 {
+				t.Errorf("亮度 %d 不应受影响，实际变为 %d", tt.unchanged, got)
+			}
+		})
+	}
+
+	// 纯黑和纯白在高光、阴影调整后保持不变
+	for _, v := range []uint8{0, 255} {
+		if got := toneLevel(v, -100, 100, 0, 0); got != int(v) {
+			t.Errorf("高光 -100、阴影 100 时 %d 变为 %d", v, got)
+		}
+	}
+}
+
+// TestToneEdgeAware 检查高光调整按区域亮度进行：亮区中的暗细节与亮区一同变化，暗区不受影响
+func TestToneEdgeAware(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 128, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 128; x++ {
+			v := uint8(40)
+			if x >= 64 {
+				v = 220
+			}
+			img.SetNRGBA(x, y, color.NRGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	dst := imaging.Clone(applyTone(img, -80, 0, 0, 0))
+	for _, x := range []int{8, 32, 56} {
+		if g := dst.NRGBAAt(x, 32).G; absInt(int(g)-40) > 2 {
+			t.Errorf("暗区 x=%d 变为 %d", x, g)
+		}
+	}
+	for _, x := range []int{72, 96, 120} {
+		if g := dst.NRGBAAt(x, 32).G; g > 210 {
+			t.Errorf("亮区 x=%d 为 %d，高光没有被压暗", x, g)
+		}
+	}
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
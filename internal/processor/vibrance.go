@@ -0,0 +1,41 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	skinHue     = 25.0 // 肤色的中心色相（度）
+	skinHueSpan = 30.0 // 肤色保护的色相半宽
+	skinProtect = 0.7  // 肤色处自然饱和度调整量减弱的比例
+)
+
+// applyVibrance 应用自然饱和度 (-100 到 100)。
+//
+// 与饱和度不同，调整量随颜色原有的饱和度增大而减小，已经很鲜艳的颜色几乎不变，
+// 不容易溢出；色相接近肤色的颜色调整量进一步减弱，避免人像肤色发红发橙
+func applyVibrance(img image.Image, vibrance float64) image.Image {
+	amount := max(-1, min(vibrance/100, 1))
+	return imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		r, g, b := float64(c.R), float64(c.G), float64(c.B)
+		hi, lo := max(r, g, b), min(r, g, b)
+		if hi == lo {
+			return c
+		}
+		sat := (hi - lo) / hi
+		h, _, _ := rgbToHSL(c.R, c.G, c.B)
+
+		// 与肤色中心的色相距离，在色环上取较短的一侧
+		d := math.Abs(math.Mod(h-skinHue+540, 360) - 180)
+		skin := max(0, 1-(d/skinHueSpan)*(d/skinHueSpan))
+		factor := 1 + amount*(1-sat)*(1-skinProtect*skin*skin)
+
+		y := 0.299*r + 0.587*g + 0.114*b
+		mix := func(v float64) uint8 { return uint8(math.Round(clamp(y + (v-y)*factor))) }
+		return color.NRGBA{R: mix(r), G: mix(g), B: mix(b), A: c.A}
+	})
+}
@@ -0,0 +1,41 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// saturation 返回 HSV 饱和度 (0-1)
+func saturation(c color.NRGBA) float64 {
+	hi, lo := max(c.R, c.G, c.B), min(c.R, c.G, c.B)
+	if hi == 0 {
+		return 0
+	}
+	return float64(hi-lo) / float64(hi)
+}
+
+func TestVibrance(t *testing.T) {
+	vibrance := func(c color.NRGBA) color.NRGBA {
+		return adjustPixel(c, func(img image.Image) image.Image { return applyVibrance(img, 50) })
+	}
+
+	skin := color.NRGBA{R: 224, G: 172, B: 140, A: 255}
+	got := vibrance(skin)
+	for i, d := range []int{int(got.R) - int(skin.R), int(got.G) - int(skin.G), int(got.B) - int(skin.B)} {
+		if d < -5 || d > 5 {
+			t.Errorf("肤色的第 %d 个通道变化了 %d，应基本不变（%v → %v）", i, d, skin, got)
+		}
+	}
+
+	muted := color.NRGBA{R: 120, G: 140, B: 110, A: 255}
+	got = vibrance(muted)
+	if saturation(got)-saturation(muted) < 0.05 {
+		t.Errorf("低饱和度的颜色应明显变鲜艳: %v → %v", muted, got)
+	}
+
+	gray := color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+	if got := vibrance(gray); got != gray {
+		t.Errorf("中性灰应保持不变: %v → %v", gray, got)
+	}
+}
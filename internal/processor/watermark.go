@@ -1,82 +1,381 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/color"
-	"os"
+	"image/draw"
+	"math"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/disintegration/imaging"
-	"github.com/fogleman/gg"
 	"github.com/xiaoheiwowo/xpix/internal/config"
+	"github.com/xiaoheiwowo/xpix/internal/fonts"
+	"golang.org/x/image/font"
 )
 
-// WatermarkOptions 水印选项
+// WatermarkOptions 水印选项，零值字段使用配置中的设置（见 WithConfig）
 type WatermarkOptions struct {
 	Text     string
-	Image    string
+	Image    string      // 图片水印的文件路径
+	Picture  image.Image // 已解码的图片水印，非 nil 时优先于 Image
 	Position string
 	Opacity  float64
+	Blend    string  // 混合模式: normal, multiply, screen, overlay, soft-light, difference
+	FontSize float64 // 字体大小（相对于图像宽度的比例）
+	Color    string  // 文字颜色 (#RRGGBB)
+
+	Margin        int     // 水印与图像边缘的距离（像素）
+	MarginPercent float64 // 边距（相对于图像短边的百分比），非零时优先于 Margin
+
+	Scale   float64 // 图片水印的宽度（相对于 ScaleBy 所指边长的比例）
+	ScaleBy string  // 图片水印大小的基准: width（图像宽度）, short（图像短边）
+
+	Font          string   // 字体文件路径或字体族名，为空时使用配置的字体（找不到时改用默认字体）
+	FallbackFonts []string // 后备字体，nil 表示使用配置中的设置
+
+	Style *TextStyle   // 文字样式，nil 表示使用配置中的设置
+	Tile  *TileOptions // 平铺设置，nil 表示使用配置中的设置
+
+	Invisible bool   // 嵌入不可见水印，可与文字或图片水印同时使用（在其之后嵌入）
+	Payload   string // 不可见水印的载荷，最长 MaxPayloadLength 字节
 }
 
-// Watermark 添加水印
-func Watermark(inputPath, outputPath string, opts WatermarkOptions) error {
-	// 打开图像
-	img, err := imaging.Open(inputPath)
-	if err != nil {
-		return fmt.Errorf("无法打开图像: %w", err)
+// 图片水印大小的基准
+const (
+	ScaleByWidth = "width" // 相对于图像宽度
+	ScaleByShort = "short" // 相对于图像短边
+)
+
+// TileOptions 平铺水印选项：水印旋转后按网格重复铺满整幅图像
+type TileOptions struct {
+	Enabled  bool    // 是否平铺
+	Angle    float64 // 旋转角度（度，逆时针为正）
+	SpacingX int     // 相邻水印之间的水平间距（像素）
+	SpacingY int     // 相邻水印之间的垂直间距（像素）
+	Stagger  float64 // 相邻行的水平错位（占水平步长的比例 0-1），0.5 为砖墙式排列
+}
+
+// Validate 检查平铺选项：间距为负数时水印互相重叠，超过水印尺寸时步长不大于 0，因此不允许负数
+func (t TileOptions) Validate() error {
+	if t.SpacingX < 0 || t.SpacingY < 0 {
+		return fmt.Errorf("%w: 平铺间距不能为负数", ErrInvalidOptions)
 	}
+	return nil
+}
 
-	var result image.Image
+// DefaultTileOptions 返回配置文件中的平铺设置
+func DefaultTileOptions() TileOptions {
+	return tileOptionsFrom(config.Get())
+}
 
-	// 根据类型处理水印
-	if opts.Text != "" {
-		result = addTextWatermark(img, opts)
-	} else if opts.Image != "" {
-		result, err = addImageWatermark(img, opts)
-		if err != nil {
+// tileOptionsFrom 返回配置中的平铺设置
+func tileOptionsFrom(c *config.Config) TileOptions {
+	cfg := c.Watermark
+	return TileOptions{
+		Enabled:  cfg.Tile,
+		Angle:    cfg.TileAngle,
+		SpacingX: cfg.TileSpacingX,
+		SpacingY: cfg.TileSpacingY,
+		Stagger:  cfg.TileStagger,
+	}
+}
+
+// hasImage 判断是否指定了图片水印
+func (o WatermarkOptions) hasImage() bool {
+	return o.Picture != nil || o.Image != ""
+}
+
+// tileOptions 返回生效的平铺设置
+func (o WatermarkOptions) tileOptions(cfg *config.Config) TileOptions {
+	if o.Tile != nil {
+		return *o.Tile
+	}
+	return tileOptionsFrom(cfg)
+}
+
+// margin 返回生效的边距（像素），零值使用配置中的边距
+func (o WatermarkOptions) margin(cfg *config.Config, width, height int) int {
+	switch {
+	case o.MarginPercent != 0:
+		return config.Length{Value: o.MarginPercent, Percent: true}.Pixels(width, height)
+	case o.Margin != 0:
+		return o.Margin
+	}
+	return cfg.Watermark.Margin.Pixels(width, height)
+}
+
+// blendMode 返回生效的混合模式
+func (o WatermarkOptions) blendMode(cfg *config.Config) string {
+	if o.Blend != "" {
+		return o.Blend
+	}
+	return cfg.Watermark.Blend
+}
+
+// SetMargin 按 config.ParseLength 的格式（如 "40"、"3%"）设置边距
+func (o *WatermarkOptions) SetMargin(s string) error {
+	l, err := config.ParseLength(s)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidOptions, err)
+	}
+	o.Margin, o.MarginPercent = 0, 0
+	if l.Percent {
+		o.MarginPercent = l.Value
+	} else {
+		o.Margin = int(math.Round(l.Value))
+	}
+	return nil
+}
+
+// Validate 检查水印选项：文字模板、文字样式、平铺间距、混合模式、图片水印的缩放设置和不可见水印的载荷
+func (o WatermarkOptions) Validate() error {
+	if o.Invisible {
+		if err := ValidatePayload(o.Payload); err != nil {
+			return err
+		}
+	}
+	if err := ValidateTemplate(o.Text); err != nil {
+		return err
+	}
+	if o.Style != nil {
+		if err := o.Style.Validate(); err != nil {
+			return err
+		}
+	}
+	if o.Tile != nil {
+		if err := o.Tile.Validate(); err != nil {
 			return err
 		}
-	} else {
-		return fmt.Errorf("请指定文字水印 (--text) 或图片水印 (--image)")
 	}
+	if err := ValidateBlendMode(o.Blend); err != nil {
+		return err
+	}
+	if o.Scale < 0 || o.Scale > 1 {
+		return fmt.Errorf("%w: 图片水印比例必须在 0-1 之间", ErrInvalidOptions)
+	}
+	switch o.ScaleBy {
+	case "", ScaleByWidth, ScaleByShort:
+		return nil
+	}
+	return fmt.Errorf("%w: 未知的缩放基准 %q（可选: width, short）", ErrInvalidOptions, o.ScaleBy)
+}
 
-	// 保存结果
-	if err := imaging.Save(result, outputPath); err != nil {
-		return fmt.Errorf("无法保存图像: %w", err)
+// WatermarkOperation 水印操作
+type WatermarkOperation struct {
+	Options WatermarkOptions
+}
+
+// Apply 为图像添加水印
+func (op WatermarkOperation) Apply(ctx context.Context, img image.Image) (image.Image, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
+	return watermarkImage(ctx, img, op.Options)
+}
 
-	fmt.Printf("✅ 图像已保存至: %s\n", outputPath)
-	return nil
+func init() {
+	Register(OperationSpec{
+		Name:        "watermark",
+		Description: "添加文字或图片水印",
+		Params: []ParamSpec{
+			{Name: "text", Aliases: []string{"t"}, Type: ParamString, Description: "文字水印内容"},
+			{Name: "image", Type: ParamString, Description: "图片水印路径"},
+			{Name: "position", Aliases: []string{"p"}, Type: ParamString, Description: "水印位置（默认取配置文件）"},
+			{Name: "opacity", Type: ParamFloat, Default: "0.5", Description: "透明度 (0-1)"},
+			{Name: "blend", Type: ParamString, Description: "混合模式 (normal, multiply, screen, overlay, soft-light, difference)"},
+			{Name: "margin", Type: ParamString, Description: "水印与图像边缘的距离，像素或短边的百分比，如 40、3%（默认取配置文件）"},
+			{Name: "scale", Type: ParamFloat, Description: "图片水印的宽度，相对于图像宽度或短边的比例 (0-1)"},
+			{Name: "scale-by", Type: ParamString, Description: "图片水印大小的基准 (width, short)"},
+			{Name: "font", Type: ParamString, Description: "字体文件路径或字体族名（默认取配置文件）"},
+			{Name: "stroke-width", Type: ParamInt, Description: "描边宽度（像素）"},
+			{Name: "stroke-color", Type: ParamString, Description: "描边颜色 (#RRGGBB)"},
+			{Name: "shadow", Type: ParamBool, Description: "绘制阴影（默认取配置文件）"},
+			{Name: "shadow-offset-x", Type: ParamInt, Description: "阴影的水平偏移（像素）"},
+			{Name: "shadow-offset-y", Type: ParamInt, Description: "阴影的垂直偏移（像素）"},
+			{Name: "shadow-blur", Type: ParamFloat, Description: "阴影的模糊半径（像素）"},
+			{Name: "shadow-color", Type: ParamString, Description: "阴影颜色 (#RRGGBB)"},
+			{Name: "background", Type: ParamBool, Description: "绘制背景底板（默认取配置文件）"},
+			{Name: "background-color", Type: ParamString, Description: "底板颜色 (#RRGGBB)"},
+			{Name: "background-opacity", Type: ParamFloat, Description: "底板透明度 (0-1)"},
+			{Name: "background-padding", Type: ParamInt, Description: "底板边距（像素）"},
+			{Name: "background-radius", Type: ParamInt, Description: "底板圆角半径（像素）"},
+			{Name: "align", Type: ParamString, Description: "多行文字的对齐方式 (left, center, right)"},
+			{Name: "line-spacing", Type: ParamFloat, Description: "行距（行高的倍数）"},
+			{Name: "letter-spacing", Type: ParamFloat, Description: "字间距（像素）"},
+			{Name: "tile", Type: ParamBool, Description: "平铺水印（默认取配置文件）"},
+			{Name: "tile-angle", Type: ParamFloat, Description: "平铺水印的旋转角度（度）"},
+			{Name: "tile-spacing-x", Type: ParamInt, Description: "平铺水印的水平间距（像素）"},
+			{Name: "tile-spacing-y", Type: ParamInt, Description: "平铺水印的垂直间距（像素）"},
+			{Name: "tile-stagger", Type: ParamFloat, Description: "相邻行的水平错位比例 (0-1)"},
+			{Name: "invisible", Type: ParamBool, Description: "嵌入不可见水印（指定 payload 时默认开启）"},
+			{Name: "payload", Type: ParamString, Description: "不可见水印的载荷（最长 32 字节）"},
+		},
+		New: func(p Params) (Operation, error) {
+			opts := WatermarkOptions{
+				Text:     p.String("text"),
+				Image:    p.String("image"),
+				Position: p.String("position"),
+				Opacity:  p.Float("opacity"),
+				Blend:    p.String("blend"),
+				Font:     p.String("font"),
+				Scale:    p.Float("scale"),
+				ScaleBy:  p.String("scale-by"),
+				Payload:  p.String("payload"),
+			}
+			opts.Invisible = p.Has("payload") && (!p.Has("invisible") || p.Bool("invisible"))
+			if opts.Text == "" && opts.Image == "" && !opts.Invisible {
+				return nil, p.Errorf("请指定 text、image 或 payload")
+			}
+			if p.Has("margin") {
+				if err := opts.SetMargin(p.String("margin")); err != nil {
+					return nil, p.Errorf("%v", err)
+				}
+			}
+			if style, ok := styleParams(p); ok {
+				opts.Style = &style
+			}
+			if err := opts.Validate(); err != nil {
+				return nil, p.Errorf("%v", err)
+			}
+			if p.Has("tile") || p.Has("tile-angle") || p.Has("tile-spacing-x") || p.Has("tile-spacing-y") || p.Has("tile-stagger") {
+				// 未指定的平铺参数取配置文件，指定了任一平铺参数即视为开启平铺
+				tile := DefaultTileOptions()
+				tile.Enabled = !p.Has("tile") || p.Bool("tile")
+				if p.Has("tile-angle") {
+					tile.Angle = p.Float("tile-angle")
+				}
+				if p.Has("tile-spacing-x") {
+					tile.SpacingX = p.Int("tile-spacing-x")
+				}
+				if p.Has("tile-spacing-y") {
+					tile.SpacingY = p.Int("tile-spacing-y")
+				}
+				if p.Has("tile-stagger") {
+					tile.Stagger = p.Float("tile-stagger")
+				}
+				if err := tile.Validate(); err != nil {
+					return nil, p.Errorf("%v", err)
+				}
+				opts.Tile = &tile
+			}
+			return WatermarkOperation{Options: opts}, nil
+		},
+	})
 }
 
-func addTextWatermark(img image.Image, opts WatermarkOptions) image.Image {
-	bounds := img.Bounds()
-	dc := gg.NewContext(bounds.Dx(), bounds.Dy())
-	dc.DrawImage(img, 0, 0)
+// styleParams 用步骤参数覆盖配置文件中的文字样式，没有指定任何样式参数时返回 false。
+// 指定任一阴影或底板参数即开启阴影或底板。
+func styleParams(p Params) (TextStyle, bool) {
+	style := DefaultTextStyle()
+	changed := false
+	str := func(name string, dst *string) {
+		if p.Has(name) {
+			*dst, changed = p.String(name), true
+		}
+	}
+	integer := func(name string, dst *int) {
+		if p.Has(name) {
+			*dst, changed = p.Int(name), true
+		}
+	}
+	float := func(name string, dst *float64) {
+		if p.Has(name) {
+			*dst, changed = p.Float(name), true
+		}
+	}
 
-	// 获取配置
-	cfg := config.Get()
+	integer("stroke-width", &style.StrokeWidth)
+	str("stroke-color", &style.StrokeColor)
+	if p.Has("shadow-offset-x") || p.Has("shadow-offset-y") || p.Has("shadow-blur") || p.Has("shadow-color") {
+		style.Shadow = true
+	}
+	if p.Has("shadow") {
+		style.Shadow, changed = p.Bool("shadow"), true
+	}
+	integer("shadow-offset-x", &style.ShadowOffsetX)
+	integer("shadow-offset-y", &style.ShadowOffsetY)
+	float("shadow-blur", &style.ShadowBlur)
+	str("shadow-color", &style.ShadowColor)
+	if p.Has("background-color") || p.Has("background-opacity") || p.Has("background-padding") || p.Has("background-radius") {
+		style.Background = true
+	}
+	if p.Has("background") {
+		style.Background, changed = p.Bool("background"), true
+	}
+	str("background-color", &style.BackgroundColor)
+	float("background-opacity", &style.BackgroundOpacity)
+	integer("background-padding", &style.BackgroundPadding)
+	integer("background-radius", &style.BackgroundRadius)
+	str("align", &style.Align)
+	float("line-spacing", &style.LineSpacing)
+	float("letter-spacing", &style.LetterSpacing)
+	return style, changed
+}
 
-	// 计算字体大小
-	fontSize := float64(bounds.Dx()) * cfg.Watermark.FontSize
+// Watermark 添加水印
+func Watermark(inputPath, outputPath string, opts WatermarkOptions) error {
+	return ProcessFile(context.Background(), inputPath, outputPath, DefaultDecodeOptions(), DefaultEncodeOptions(), WatermarkOperation{Options: opts})
+}
 
-	// 加载字体（使用固定字体路径）
-	home, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Printf("⚠️  无法获取用户目录: %v\n", err)
-		return img
+func watermarkImage(ctx context.Context, img image.Image, opts WatermarkOptions) (image.Image, error) {
+	if opts.Text == "" && !opts.hasImage() && !opts.Invisible {
+		return nil, ErrNoWatermark
 	}
 
-	fontPath := home + "/Library/Fonts/CaskaydiaMonoNerdFont-Regular.ttf"
+	// 根据类型处理可见水印
+	cfg := configFromContext(ctx)
+	if tile := opts.tileOptions(cfg); tile.Enabled {
+		// 配置文件中的平铺设置同样需要检查
+		if err := tile.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if opts.Text != "" {
+		// 替换文字中的 EXIF 占位符
+		src, _ := SourceFromContext(ctx)
+		text, err := ExpandTemplate(opts.Text, src)
+		if err != nil {
+			return nil, err
+		}
+		opts.Text = text
+		img, err = addTextWatermark(img, opts, cfg)
+		if err != nil {
+			return nil, err
+		}
+	} else if opts.hasImage() {
+		img, err = addImageWatermark(img, opts, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 不可见水印最后嵌入，避免被可见水印覆盖
+	if opts.Invisible {
+		return embedPayload(img, opts.Payload)
+	}
+	return img, nil
+}
 
-	if err := dc.LoadFontFace(fontPath, fontSize); err != nil {
-		fmt.Printf("⚠️  无法加载字体 %s: %v\n", fontPath, err)
-		fmt.Println("⚠️  请将字体文件放置到 ~/Library/Fonts/CaskaydiaMonoNerdFont-Regular.ttf")
-		return img
+func addTextWatermark(img image.Image, opts WatermarkOptions, cfg *config.Config) (image.Image, error) {
+	bounds := img.Bounds()
+
+	// 计算字体大小
+	relSize := opts.FontSize
+	if relSize == 0 {
+		relSize = cfg.Watermark.FontSize
 	}
+	fontSize := float64(bounds.Dx()) * relSize
+
+	face, err := loadFace(cfg, opts.Font, cfg.Watermark.Font, opts.FallbackFonts, fontSize)
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
 
 	// 使用配置的透明度（如果命令行未指定）
 	opacity := opts.Opacity
@@ -85,23 +384,91 @@ func addTextWatermark(img image.Image, opts WatermarkOptions) image.Image {
 	}
 
 	// 解析颜色
-	textColor := parseColor(cfg.Watermark.Color, opacity)
-	dc.SetColor(textColor)
+	colorStr := opts.Color
+	if colorStr == "" {
+		colorStr = cfg.Watermark.Color
+	}
+
+	// 按样式渲染文字（支持 Unicode、Emoji 和多行文字）
+	style := opts.textStyle(cfg)
+	if err := style.Validate(); err != nil {
+		return nil, err
+	}
+	stamp, block := renderText(face, opts.Text, colorStr, opacity, style)
+
+	if tile := opts.tileOptions(cfg); tile.Enabled {
+		return tileWatermark(img, stamp, tile, opts.blendMode(cfg)), nil
+	}
 
-	// 使用配置的位置和边距
+	// 使用配置的位置
 	position := opts.Position
 	if position == "" {
 		position = cfg.Watermark.Position
 	}
 
-	// 计算位置
-	margin := float64(cfg.Watermark.Margin)
-	x, y := calculateTextPosition(bounds.Dx(), bounds.Dy(), position, margin)
+	// 文字块（含底板，不含描边和阴影的余量）按边距对齐到图像边缘
+	pt := anchorPosition(bounds.Dx(), bounds.Dy(), block.Dx(), block.Dy(), position, opts.margin(cfg, bounds.Dx(), bounds.Dy()))
+	pt = pt.Sub(block.Min)
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	blendDraw(dst, stamp.Bounds().Add(pt), stamp, image.Point{}, opts.blendMode(cfg))
+	return dst, nil
+}
+
+// tileWatermark 将水印旋转后按网格铺满整幅图像，奇偶行按 Stagger 水平错开
+func tileWatermark(img, stamp image.Image, tile TileOptions, mode string) image.Image {
+	if tile.Angle != 0 {
+		stamp = imaging.Rotate(stamp, tile.Angle, color.Transparent)
+	} else if mode != BlendNormal {
+		// 预先转换一次，避免每次合成时转换
+		stamp = imaging.Clone(stamp)
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+
+	sw, sh := stamp.Bounds().Dx(), stamp.Bounds().Dy()
+	stepX := max(sw+tile.SpacingX, 1)
+	stepY := max(sh+tile.SpacingY, 1)
+	for row, y := 0, -sh/2; y < dst.Rect.Dy(); row, y = row+1, y+stepY {
+		offset := int(math.Mod(float64(row)*tile.Stagger, 1) * float64(stepX))
+		for x := offset - stepX; x < dst.Rect.Dx(); x += stepX {
+			r := image.Rect(x, y, x+sw, y+sh)
+			blendDraw(dst, r, stamp, stamp.Bounds().Min, mode)
+		}
+	}
+	return dst
+}
+
+// loadFace 加载指定大小（像素）的字体，缺少的字形从后备字体中查找。
+// spec 为显式指定的字体，找不到时返回 ErrFontLoad；为空时使用配置的字体 configured，
+// 配置的字体找不到时给出警告并改用默认字体。fallbacks 为 nil 时使用配置的后备字体。
+func loadFace(cfg *config.Config, spec, configured string, fallbacks []string, size float64) (font.Face, error) {
+	if fallbacks == nil {
+		fallbacks = cfg.Watermark.FallbackFonts
+	}
+	loc, warning, err := fonts.FindPrimary(spec, configured)
+	if warning != "" {
+		warnOnce(warning)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFontLoad, err)
+	}
+	face, err := fonts.NewFace(loc, fallbacks, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFontLoad, err)
+	}
+	return face, nil
+}
 
-	// 绘制文字（支持 Unicode 和 Emoji）
-	dc.DrawStringAnchored(opts.Text, x, y, 0.5, 0.5)
+// warned 已输出过的警告，批量处理时同一警告只输出一次
+var warned sync.Map
 
-	return dc.Image()
+func warnOnce(msg string) {
+	if _, seen := warned.LoadOrStore(msg, true); !seen {
+		fmt.Printf("⚠️  %s\n", msg)
+	}
 }
 
 // parseColor 解析颜色字符串（支持 #RRGGBB 格式）
@@ -132,89 +499,96 @@ func parseColor(colorStr string, opacity float64) color.Color {
 	return color.RGBA{R: r, G: g, B: b, A: uint8(opacity * 255)}
 }
 
-// calculateTextPosition 计算文字位置
-func calculateTextPosition(width, height int, position string, margin float64) (float64, float64) {
+// anchorPosition 返回 w×h 的水印在 width×height 图像中左上角的位置。
+// 水印的外边缘与对应的图像边缘相距 margin，center 时居中，未知位置按 bottom-center 处理。
+func anchorPosition(width, height, w, h int, position string, margin int) image.Point {
+	left, centerX, right := margin, (width-w)/2, width-w-margin
+	top, centerY, bottom := margin, (height-h)/2, height-h-margin
 	switch position {
 	case "top-left":
-		return margin, margin
+		return image.Pt(left, top)
+	case "top-center":
+		return image.Pt(centerX, top)
 	case "top-right":
-		return float64(width) - margin, margin
+		return image.Pt(right, top)
 	case "bottom-left":
-		return margin, float64(height) - margin
+		return image.Pt(left, bottom)
 	case "bottom-right":
-		return float64(width) - margin, float64(height) - margin
+		return image.Pt(right, bottom)
 	case "center":
-		return float64(width) / 2, float64(height) / 2
-	case "bottom-center":
-		return float64(width) / 2, float64(height) - margin
-	case "top-center":
-		return float64(width) / 2, margin
+		return image.Pt(centerX, centerY)
 	default:
-		return float64(width) / 2, float64(height) - margin
+		return image.Pt(centerX, bottom)
 	}
 }
 
-func addImageWatermark(img image.Image, opts WatermarkOptions) (image.Image, error) {
+func addImageWatermark(img image.Image, opts WatermarkOptions, cfg *config.Config) (image.Image, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
 	// 打开水印图像
-	watermark, err := imaging.Open(opts.Image)
-	if err != nil {
-		return nil, fmt.Errorf("无法打开水印图像: %w", err)
+	watermark := opts.Picture
+	if watermark == nil {
+		var err error
+		if watermark, err = imaging.Open(opts.Image, imaging.AutoOrientation(true)); err != nil {
+			return nil, fmt.Errorf("无法打开水印图像: %w", err)
+		}
 	}
 
 	bounds := img.Bounds()
 
-	// 调整水印大小（最大为原图的 1/5）
-	maxSize := bounds.Dx() / 5
-	if watermark.Bounds().Dx() > maxSize {
-		watermark = imaging.Resize(watermark, maxSize, 0, imaging.Lanczos)
+	// 按图像宽度或短边的比例缩放水印（放大或缩小），且不超出图像高度
+	scale := opts.Scale
+	if scale == 0 {
+		scale = cfg.Watermark.Scale
+	}
+	scaleBy := opts.ScaleBy
+	if scaleBy == "" {
+		scaleBy = cfg.Watermark.ScaleBy
+	}
+	base := bounds.Dx()
+	if scaleBy == ScaleByShort {
+		base = min(bounds.Dx(), bounds.Dy())
+	}
+	ww, wh := watermark.Bounds().Dx(), watermark.Bounds().Dy()
+	w := scale * float64(base)
+	w = min(w, float64(bounds.Dy())*float64(ww)/float64(wh))
+	if tw := max(int(math.Round(w)), 1); tw != ww {
+		watermark = imaging.Resize(watermark, tw, 0, imaging.Lanczos)
 	}
 
 	// 调整透明度
-	watermark = adjustOpacity(watermark, opts.Opacity)
-
-	// 计算位置
-	x, y := calculateImagePosition(bounds.Dx(), bounds.Dy(), watermark.Bounds().Dx(), watermark.Bounds().Dy(), opts.Position)
-
-	// 合成
-	result := imaging.Overlay(img, watermark, image.Pt(x, y), 1.0)
+	opacity := opts.Opacity
+	if opacity == 0 {
+		opacity = cfg.Watermark.Opacity
+	}
+	watermark = adjustOpacity(watermark, opacity)
 
-	return result, nil
-}
+	if tile := opts.tileOptions(cfg); tile.Enabled {
+		return tileWatermark(img, watermark, tile, opts.blendMode(cfg)), nil
+	}
 
-func calculateImagePosition(imgWidth, imgHeight, wmWidth, wmHeight int, position string) (int, int) {
-	margin := 20
-	switch position {
-	case "top-left":
-		return margin, margin
-	case "top-right":
-		return imgWidth - wmWidth - margin, margin
-	case "bottom-left":
-		return margin, imgHeight - wmHeight - margin
-	case "bottom-right":
-		return imgWidth - wmWidth - margin, imgHeight - wmHeight - margin
-	case "center":
-		return (imgWidth - wmWidth) / 2, (imgHeight - wmHeight) / 2
-	case "bottom-center":
-		return (imgWidth - wmWidth) / 2, imgHeight - wmHeight - margin
-	case "top-center":
-		return (imgWidth - wmWidth) / 2, margin
-	default:
-		return (imgWidth - wmWidth) / 2, imgHeight - wmHeight - margin
+	// 使用配置的位置
+	position := opts.Position
+	if position == "" {
+		position = cfg.Watermark.Position
 	}
+
+	// 计算位置并合成
+	pt := anchorPosition(bounds.Dx(), bounds.Dy(), watermark.Bounds().Dx(), watermark.Bounds().Dy(), position, opts.margin(cfg, bounds.Dx(), bounds.Dy()))
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	blendDraw(dst, watermark.Bounds().Add(pt), watermark, watermark.Bounds().Min, opts.blendMode(cfg))
+	return dst, nil
 }
 
 // adjustOpacity 调整图像透明度
 func adjustOpacity(img image.Image, opacity float64) image.Image {
-	bounds := img.Bounds()
-	dst := image.NewRGBA(bounds)
-
-	// 遍历每个像素，调整 alpha 通道
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
-			c.A = uint8(float64(c.A) * opacity)
-			dst.Set(x, y, c)
-		}
+	// 在非预乘 alpha 的图像上调整 alpha 通道，颜色保持不变
+	dst := imaging.Clone(img)
+	for i := 3; i < len(dst.Pix); i += 4 {
+		dst.Pix[i] = uint8(math.Round(float64(dst.Pix[i]) * opacity))
 	}
 
 	return dst
@@ -0,0 +1,123 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/xiaoheiwowo/xpix/internal/config"
+	"github.com/xiaoheiwowo/xpix/internal/fonts"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestWatermarkPicture(t *testing.T) {
+	img := imaging.New(200, 100, color.NRGBA{R: 128, G: 128, B: 128, A: 255})
+	mark := imaging.New(20, 20, color.NRGBA{R: 255, A: 255})
+
+	op := WatermarkOperation{Options: WatermarkOptions{Picture: mark, Position: "center", Opacity: 1, Scale: 0.1}}
+	got, err := op.Apply(context.Background(), img)
+	if err != nil {
+		t.Fatalf("添加图片水印失败: %v", err)
+	}
+	dst := imaging.Clone(got)
+	if c := dst.NRGBAAt(100, 50); c != (color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("图像中心应为水印的颜色，实际 %v", c)
+	}
+	if c := dst.NRGBAAt(5, 5); c != (color.NRGBA{R: 128, G: 128, B: 128, A: 255}) {
+		t.Errorf("水印以外的区域不应改变，实际 %v", c)
+	}
+
+	if _, err := (WatermarkOperation{}).Apply(context.Background(), img); !errors.Is(err, ErrNoWatermark) {
+		t.Errorf("没有指定水印时应返回 ErrNoWatermark，实际 %v", err)
+	}
+}
+
+func TestFrameLogoPicture(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	logo := imaging.New(40, 20, color.NRGBA{R: 255, A: 255})
+
+	// 没有 EXIF 时文字全部为空，底栏中只有 Logo
+	op := FrameOperation{Options: FrameOptions{Layout: FrameCenter, LogoPicture: logo, Color: "#FFFFFF"}}
+	got, err := op.Apply(context.Background(), img)
+	if err != nil {
+		t.Fatalf("添加相框失败: %v", err)
+	}
+	dst := imaging.Clone(got)
+	bar := dst.Rect.Dy() - img.Rect.Dy()
+	found := false
+	for y := dst.Rect.Dy() - bar; y < dst.Rect.Dy() && !found; y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			if c := dst.NRGBAAt(x, y); c.R > 200 && c.G < 50 && c.B < 50 {
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		t.Error("底栏中没有找到 Logo")
+	}
+}
+
+func TestTileSpacing(t *testing.T) {
+	img := imaging.New(100, 100, color.White)
+	mark := imaging.New(20, 20, color.Black)
+	for _, tile := range []TileOptions{
+		{Enabled: true, SpacingX: -1},
+		{Enabled: true, SpacingY: -25}, // 超过水印高度，步长为负数
+	} {
+		opts := WatermarkOptions{Picture: mark, Opacity: 1, Tile: &tile}
+		if err := opts.Validate(); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("间距 %d,%d: Validate 应返回 ErrInvalidOptions，实际 %v", tile.SpacingX, tile.SpacingY, err)
+		}
+		if _, err := (WatermarkOperation{Options: opts}).Apply(context.Background(), img); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("间距 %d,%d: Apply 应返回 ErrInvalidOptions，实际 %v", tile.SpacingX, tile.SpacingY, err)
+		}
+	}
+
+	// 配置文件中的负间距在平铺时同样被拒绝
+	cfg := config.DefaultConfig()
+	cfg.Watermark.Tile = true
+	cfg.Watermark.TileSpacingX = -10
+	ctx := WithConfig(context.Background(), cfg)
+	if _, err := (WatermarkOperation{Options: WatermarkOptions{Picture: mark}}).Apply(ctx, img); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("配置中的负间距应返回 ErrInvalidOptions，实际 %v", err)
+	}
+
+	tile := TileOptions{Enabled: true}
+	if _, err := (WatermarkOperation{Options: WatermarkOptions{Picture: mark, Opacity: 1, Tile: &tile}}).Apply(context.Background(), img); err != nil {
+		t.Errorf("间距为 0 时平铺失败: %v", err)
+	}
+}
+
+func TestWatermarkFont(t *testing.T) {
+	dir := t.TempDir()
+	regular := filepath.Join(dir, "goregular.ttf")
+	if err := os.WriteFile(regular, goregular.TTF, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	saved := fonts.DefaultFamilies
+	fonts.DefaultFamilies = []string{regular}
+	defer func() { fonts.DefaultFamilies = saved }()
+
+	cfg := config.DefaultConfig()
+	cfg.Watermark.Font = filepath.Join(dir, "configured.ttf")
+	cfg.Watermark.FallbackFonts = []string{}
+	ctx := WithConfig(context.Background(), cfg)
+	img := imaging.New(200, 100, color.NRGBA{R: 128, G: 128, B: 128, A: 255})
+
+	// 配置的字体找不到时改用默认字体
+	if _, err := (WatermarkOperation{Options: WatermarkOptions{Text: "xpix"}}).Apply(ctx, img); err != nil {
+		t.Errorf("配置的字体找不到时应改用默认字体，实际 %v", err)
+	}
+
+	// 显式指定的字体找不到时报错
+	op := WatermarkOperation{Options: WatermarkOptions{Text: "xpix", Font: filepath.Join(dir, "explicit.ttf")}}
+	if _, err := op.Apply(ctx, img); !errors.Is(err, ErrFontLoad) {
+		t.Errorf("显式指定的字体找不到时应返回 ErrFontLoad，实际 %v", err)
+	}
+}
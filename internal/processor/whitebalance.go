@@ -0,0 +1,187 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+// 白平衡：色温和色调
+//
+// 色温决定目标白点在普朗克轨迹上的位置，色调沿垂直于轨迹的方向（绿-洋红）移动白点。
+// 调整在线性 sRGB 中进行：转换到 XYZ 后用 Bradford 变换到 LMS 锥体响应空间，
+// 按 6500K 白点与目标白点之比缩放各分量（von Kries 色适应），再转换回 sRGB，
+// 原本中性的颜色变为目标白点的颜色，亮度保持不变。
+
+const (
+	referenceKelvin = 6500 // 不调整时的白点色温
+	tintRange       = 0.02 // 色调为 ±100 时白点在 CIE 1960 uv 上偏离轨迹的距离
+)
+
+// mat3 3×3 矩阵
+type mat3 [3][3]float64
+
+var (
+	// srgbToXYZ 线性 sRGB 到 XYZ（D65）
+	srgbToXYZ = mat3{
+		{0.4124564, 0.3575761, 0.1804375},
+		{0.2126729, 0.7151522, 0.0721750},
+		{0.0193339, 0.1191920, 0.9503041},
+	}
+	// xyzToSRGB XYZ（D65）到线性 sRGB
+	xyzToSRGB = mat3{
+		{3.2404542, -1.5371385, -0.4985314},
+		{-0.9692660, 1.8760108, 0.0415560},
+		{0.0556434, -0.2040259, 1.0572252},
+	}
+	// bradford XYZ 到 LMS 锥体响应
+	bradford = mat3{
+		{0.8951, 0.2664, -0.1614},
+		{-0.7502, 1.7135, 0.0367},
+		{0.0389, -0.0685, 1.0296},
+	}
+)
+
+func (m mat3) mul(n mat3) mat3 {
+	var r mat3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				r[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+	return r
+}
+
+func (m mat3) apply(v [3]float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+func (m mat3) inverse() mat3 {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	var r mat3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			// 伴随矩阵：r[i][j] 为 m[j][i] 的代数余子式
+			a, b := (j+1)%3, (j+2)%3
+			c, d := (i+1)%3, (i+2)%3
+			r[i][j] = (m[a][c]*m[b][d] - m[a][d]*m[b][c]) / det
+		}
+	}
+	return r
+}
+
+// whiteBalanceMatrix 返回线性 sRGB 上的白平衡矩阵，将 6500K 白点映射到指定色温和色调的白点
+func whiteBalanceMatrix(kelvin int, tint float64) mat3 {
+	if kelvin == 0 {
+		kelvin = referenceKelvin
+	}
+	src := bradford.apply(whitePoint(referenceKelvin, 0))
+	dst := bradford.apply(whitePoint(kelvin, tint))
+	var gain mat3
+	for i := range gain {
+		gain[i][i] = dst[i] / src[i]
+	}
+	return xyzToSRGB.mul(bradford.inverse()).mul(gain).mul(bradford).mul(srgbToXYZ)
+}
+
+// whitePoint 返回色温为 kelvin、色调为 tint (-100 到 100) 的白点 XYZ，Y = 1
+func whitePoint(kelvin int, tint float64) [3]float64 {
+	t := math.Max(1667, math.Min(float64(kelvin), 25000))
+	u, v := xyToUV(planckianXY(t))
+	if tint != 0 {
+		nu, nv := locusNormal(t)
+		d := math.Max(-1, math.Min(tint/100, 1)) * tintRange
+		u, v = u+nu*d, v+nv*d
+	}
+	x, y := uvToXY(u, v)
+	return [3]float64{x / y, 1, (1 - x - y) / y}
+}
+
+// locusNormal 返回普朗克轨迹在色温 t 处 CIE 1960 uv 上的单位法线，指向洋红（v 减小的一侧）
+func locusNormal(t float64) (float64, float64) {
+	u0, v0 := xyToUV(planckianXY(t))
+	u1, v1 := xyToUV(planckianXY(t + 1))
+	du, dv := u1-u0, v1-v0
+	n := math.Hypot(du, dv)
+	nu, nv := -dv/n, du/n
+	if nv > 0 {
+		nu, nv = -nu, -nv
+	}
+	return nu, nv
+}
+
+// planckianXY 返回色温为 t (1667-25000K) 的黑体的 CIE 1931 xy 色度坐标（Kang 等，2002）
+func planckianXY(t float64) (float64, float64) {
+	var x float64
+	if t <= 4000 {
+		x = -0.2661239e9/(t*t*t) - 0.2343589e6/(t*t) + 0.8776956e3/t + 0.179910
+	} else {
+		x = -3.0258469e9/(t*t*t) + 2.1070379e6/(t*t) + 0.2226347e3/t + 0.240390
+	}
+	var y float64
+	switch {
+	case t <= 2222:
+		y = -1.1063814*x*x*x - 1.34811020*x*x + 2.18555832*x - 0.20219683
+	case t <= 4000:
+		y = -0.9549476*x*x*x - 1.37418593*x*x + 2.09137015*x - 0.16748867
+	default:
+		y = 3.0817580*x*x*x - 5.87338670*x*x + 3.75112997*x - 0.37001483
+	}
+	return x, y
+}
+
+// xyToUV 将 CIE 1931 xy 转换为 CIE 1960 uv
+func xyToUV(x, y float64) (float64, float64) {
+	d := -2*x + 12*y + 3
+	return 4 * x / d, 6 * y / d
+}
+
+// uvToXY 将 CIE 1960 uv 转换为 CIE 1931 xy
+func uvToXY(u, v float64) (float64, float64) {
+	d := 2*u - 8*v + 4
+	return 3 * u / d, 2 * v / d
+}
+
+// srgbToLinear sRGB 编码值 (0-255) 到线性值 (0-1) 的查找表
+var srgbToLinear = func() (lut [256]float64) {
+	for i := range lut {
+		v := float64(i) / 255
+		if v <= 0.04045 {
+			lut[i] = v / 12.92
+		} else {
+			lut[i] = math.Pow((v+0.055)/1.055, 2.4)
+		}
+	}
+	return lut
+}()
+
+// linearToSRGB 将线性值 (0-1) 编码为 sRGB (0-255)，超出范围的值被截断
+func linearToSRGB(v float64) uint8 {
+	v = max(0, min(v, 1))
+	if v <= 0.0031308 {
+		v *= 12.92
+	} else {
+		v = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(v * 255))
+}
+
+// applyWhiteBalance 应用色温 (K) 和色调 (-100 到 100)。
+// 色温低于 6500K 时偏暖，高于 6500K 时偏冷；色调为正时偏洋红，为负时偏绿
+func applyWhiteBalance(img image.Image, kelvin int, tint float64) image.Image {
+	m := whiteBalanceMatrix(kelvin, tint)
+	return imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		rgb := m.apply([3]float64{srgbToLinear[c.R], srgbToLinear[c.G], srgbToLinear[c.B]})
+		return color.NRGBA{R: linearToSRGB(rgb[0]), G: linearToSRGB(rgb[1]), B: linearToSRGB(rgb[2]), A: c.A}
+	})
+}
@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// adjustPixel 对单个像素应用调整函数并返回结果
+func adjustPixel(c color.NRGBA, fn func(image.Image) image.Image) color.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, c)
+	return imaging.Clone(fn(img)).NRGBAAt(0, 0)
+}
+
+func TestWhiteBalanceIdentity(t *testing.T) {
+	for _, kelvin := range []int{0, referenceKelvin} {
+		m := whiteBalanceMatrix(kelvin, 0)
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				want := 0.0
+				if i == j {
+					want = 1
+				}
+				// sRGB 与 XYZ 互转的矩阵只有 7 位有效数字
+				if math.Abs(m[i][j]-want) > 1e-6 {
+					t.Fatalf("whiteBalanceMatrix(%d, 0) 不是单位矩阵: %v", kelvin, m)
+				}
+			}
+		}
+	}
+
+	// 转换到线性光再编码回 sRGB 后，所有像素保持不变
+	for v := 0; v < 256; v++ {
+		c := color.NRGBA{R: uint8(v), G: uint8(255 - v), B: uint8(v / 2), A: 255}
+		if got := adjustPixel(c, func(img image.Image) image.Image { return applyWhiteBalance(img, referenceKelvin, 0) }); got != c {
+			t.Fatalf("6500K、色调 0 时 %v 变为 %v", c, got)
+		}
+	}
+}
+
+func TestWhiteBalanceDirection(t *testing.T) {
+	gray := color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+	balance := func(kelvin int, tint float64) color.NRGBA {
+		return adjustPixel(gray, func(img image.Image) image.Image { return applyWhiteBalance(img, kelvin, tint) })
+	}
+
+	if c := balance(5000, 0); !(c.R > gray.R && c.B < gray.B) {
+		t.Errorf("5000K 应偏暖（R 增大、B 减小），实际 %v", c)
+	}
+	if c := balance(9000, 0); !(c.R < gray.R && c.B > gray.B) {
+		t.Errorf("9000K 应偏冷（R 减小、B 增大），实际 %v", c)
+	}
+	if c := balance(referenceKelvin, 50); !(c.G < c.R && c.G < c.B) {
+		t.Errorf("正色调应偏洋红（G 低于 R 和 B），实际 %v", c)
+	}
+	if c := balance(referenceKelvin, -50); !(c.G > c.R && c.G > c.B) {
+		t.Errorf("负色调应偏绿（G 高于 R 和 B），实际 %v", c)
+	}
+}
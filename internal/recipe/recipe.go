@@ -0,0 +1,242 @@
+package recipe
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xiaoheiwowo/xpix/internal/config"
+	"github.com/xiaoheiwowo/xpix/internal/processor"
+)
+
+// ProjectDir 项目本地的配方目录（相对于当前工作目录）
+const ProjectDir = ".xpix/recipes"
+
+// exts 支持的配方文件扩展名
+var exts = []string{".toml", ".yaml", ".yml"}
+
+// Recipe 处理配方：一组按顺序执行的操作及输出设置
+type Recipe struct {
+	Name        string           // 配方名称（文件名去掉扩展名）
+	Path        string           // 配方文件路径
+	Description string           // 配方说明
+	Steps       []processor.Step // 处理步骤
+	Output      OutputSettings   // 输出设置
+}
+
+// OutputSettings 配方输出设置
+type OutputSettings struct {
+	Suffix  string `toml:"suffix"`  // 输出文件名后缀，默认为 "_<配方名>"
+	Dir     string `toml:"dir"`     // 输出目录
+	Quality int    `toml:"quality"` // JPEG/WebP 质量 (1-100)，0 表示使用配置文件
+	Format  string `toml:"format"`  // 输出格式，空表示使用配置文件
+
+	Lossless        *bool  `toml:"lossless"`         // WebP 无损压缩，未设置表示使用配置文件
+	TIFFCompression string `toml:"tiff_compression"` // TIFF 压缩方式，空表示使用配置文件
+	StripMetadata   *bool  `toml:"strip_metadata"`   // 不保留元数据，未设置表示使用配置文件
+}
+
+// EncodeOptions 用配方的输出设置覆盖基础编码选项
+func (o OutputSettings) EncodeOptions(base processor.EncodeOptions) processor.EncodeOptions {
+	if o.Quality != 0 {
+		base.Quality = o.Quality
+	}
+	if o.Format != "" {
+		base.Format = o.Format
+	}
+	if o.Lossless != nil {
+		base.Lossless = *o.Lossless
+	}
+	if o.TIFFCompression != "" {
+		base.TIFFCompression = o.TIFFCompression
+	}
+	if o.StripMetadata != nil {
+		base.StripMetadata = *o.StripMetadata
+	}
+	return base
+}
+
+// validate 校验输出设置，返回出错的键名
+func (o OutputSettings) validate() (string, error) {
+	if o.Quality < 0 || o.Quality > 100 {
+		return "quality", fmt.Errorf("output.quality 必须在 1-100 之间，得到 %d", o.Quality)
+	}
+	if _, err := processor.NormalizeFormat(o.Format); err != nil {
+		return "format", fmt.Errorf("output.format: %w", err)
+	}
+	if _, err := processor.NormalizeTIFFCompression(o.TIFFCompression); err != nil {
+		return "tiff_compression", fmt.Errorf("output.tiff_compression: %w", err)
+	}
+	return "", nil
+}
+
+// Error 带行号的配方错误
+type Error struct {
+	Path string
+	Line int // 行号，0 表示未知
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+// Entry 搜索目录中发现的配方文件
+type Entry struct {
+	Name string
+	Path string
+}
+
+// rawStep 解析后尚未校验的步骤
+type rawStep struct {
+	line     int               // [[step]] 所在行
+	keyLines map[string]int    // 各参数所在行
+	values   map[string]string // 参数值（包含 op）
+}
+
+// Load 加载并校验配方文件
+func Load(path string) (*Recipe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取配方文件: %w", err)
+	}
+
+	r := &Recipe{
+		Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Path: path,
+	}
+
+	var raws []rawStep
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		raws, err = parseYAML(r, data)
+	default:
+		raws, err = parseTOML(r, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raws) == 0 {
+		return nil, &Error{Path: path, Msg: "配方中没有任何处理步骤"}
+	}
+
+	for _, raw := range raws {
+		step, err := buildStep(path, raw)
+		if err != nil {
+			return nil, err
+		}
+		r.Steps = append(r.Steps, step)
+	}
+
+	if r.Output.Suffix == "" {
+		r.Output.Suffix = "_" + r.Name
+	}
+	return r, nil
+}
+
+// buildStep 校验原始步骤并转换为处理步骤
+func buildStep(path string, raw rawStep) (processor.Step, error) {
+	op, ok := raw.values["op"]
+	if !ok {
+		return processor.Step{}, &Error{Path: path, Line: raw.line, Msg: "步骤缺少 op"}
+	}
+
+	kv := make(map[string]string, len(raw.values)-1)
+	for k, v := range raw.values {
+		if k != "op" {
+			kv[k] = v
+		}
+	}
+
+	step, err := processor.NewStep(op, kv)
+	if err != nil {
+		line := raw.line
+		var perr *processor.ParamError
+		if errors.As(err, &perr) && perr.Key != "" {
+			if l, ok := raw.keyLines[perr.Key]; ok {
+				line = l
+			}
+		} else if l, ok := raw.keyLines["op"]; ok {
+			line = l
+		}
+		return processor.Step{}, &Error{Path: path, Line: line, Msg: err.Error()}
+	}
+	return step, nil
+}
+
+// Dirs 返回配方搜索目录，项目目录优先于用户目录
+func Dirs() []string {
+	return []string{ProjectDir, UserDir()}
+}
+
+// UserDir 返回用户配方目录（~/.config/xpix/recipes）
+func UserDir() string {
+	return filepath.Join(filepath.Dir(config.GetDefaultConfigPath()), "recipes")
+}
+
+// List 列出所有搜索目录中的配方，同名配方以项目目录为准
+func List() ([]Entry, error) {
+	var entries []Entry
+	seen := make(map[string]bool)
+
+	for _, dir := range Dirs() {
+		files, err := os.ReadDir(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("无法读取配方目录 %s: %w", dir, err)
+		}
+
+		for _, f := range files {
+			if f.IsDir() || !isRecipeFile(f.Name()) {
+				continue
+			}
+			name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			entries = append(entries, Entry{Name: name, Path: filepath.Join(dir, f.Name())})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// Find 按文件路径或配方名称查找并加载配方
+func Find(nameOrPath string) (*Recipe, error) {
+	if info, err := os.Stat(nameOrPath); err == nil && !info.IsDir() {
+		return Load(nameOrPath)
+	}
+
+	for _, dir := range Dirs() {
+		for _, ext := range exts {
+			path := filepath.Join(dir, nameOrPath+ext)
+			if _, err := os.Stat(path); err == nil {
+				return Load(path)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("找不到配方 %q（搜索目录: %s）", nameOrPath, strings.Join(Dirs(), ", "))
+}
+
+// isRecipeFile 判断文件是否为配方文件
+func isRecipeFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package recipe
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadError 将内容写入临时配方文件并加载，返回配方错误
+func loadError(t *testing.T, name, content string) *Error {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(path)
+	var rerr *Error
+	if !errors.As(err, &rerr) {
+		t.Fatalf("期望 *Error，实际为 %v", err)
+	}
+	return rerr
+}
+
+func TestLoadTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+	}{
+		{"unknown key", "description = \"x\"\nfoo = 1\n\n[[step]]\nop = \"resize\"\nwidth = 10\n", 2},
+		{"unknown output key", "[[step]]\nop = \"resize\"\nwidth = 10\n\n[output]\n# 注释\nsufix = \"_x\"\n", 7},
+		{"unknown step param", "[[step]]\nop = \"resize\"\nwidth = 10\n\n[[step]]\nop = \"resize\"\nwidht = 10\n", 7},
+		{"unknown op", "[[step]]\nop = \"resize\"\nwidth = 10\n\n[[step]]\n  op = \"rotate\"\n", 6},
+		{"missing op", "[[step]]\nop = \"resize\"\nwidth = 10\n\n[[step]]\nwidth = 10\n", 5},
+		{"wrong param type", "[[step]]\nop = \"resize\"\n\"width\" = \"wide\"\n", 3},
+		{"non-scalar param", "[[step]]\nop = \"resize\"\nwidth = [1, 2]\n", 3},
+		{"wrong output type", "[[step]]\nop = \"resize\"\nwidth = 10\n\n[output]\nquality = \"high\"\n", 6},
+		{"invalid output value", "[output]\nquality = 500\n\n[[step]]\nop = \"resize\"\nwidth = 10\n", 2},
+		{"non-mapping step", "description = \"x\"\nstep = [1, 2]\n", 2},
+		{"malformed", "[[step]]\nop = \"resize\"\nwidth = \n", 3},
+		{"unterminated table", "[[step]\nop = \"resize\"\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loadError(t, "r.toml", tt.content)
+			if err.Line != tt.line {
+				t.Errorf("错误位于第 %d 行，期望第 %d 行: %v", err.Line, tt.line, err)
+			}
+		})
+	}
+}
+
+func TestLoadYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+	}{
+		{"unknown key", "description: x\nfoo: 1\nsteps:\n  - op: resize\n    width: 10\n", 2},
+		{"unknown output key", "steps:\n  - op: resize\n    width: 10\noutput:\n  sufix: _x\n", 5},
+		{"unknown step param", "steps:\n  - op: resize\n    width: 10\n  - op: resize\n    widht: 10\n", 5},
+		{"unknown op", "steps:\n  - op: resize\n    width: 10\n  - op: rotate\n", 4},
+		{"missing op", "steps:\n  - op: resize\n    width: 10\n  - width: 10\n", 4},
+		{"wrong param type", "steps:\n  - op: resize\n    width: wide\n", 3},
+		{"non-scalar param", "steps:\n  - op: resize\n    width:\n      - 1\n", 4},
+		{"wrong output type", "steps:\n  - op: resize\n    width: 10\noutput:\n  quality: high\n", 5},
+		{"invalid output value", "output:\n  suffix: _x\n  quality: 500\nsteps:\n  - op: resize\n    width: 10\n", 3},
+		{"non-mapping step", "steps:\n  - op: resize\n    width: 10\n  - resize\n", 4},
+		{"steps not a list", "description: x\nsteps: resize\n", 2},
+		{"malformed", "description: x\nsteps:\n  - op: resize\n    width: 10\n    height 20\n", 5},
+		{"top level not a mapping", "- op: resize\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := loadError(t, "r.yaml", tt.content)
+			if err.Line != tt.line {
+				t.Errorf("错误位于第 %d 行，期望第 %d 行: %v", err.Line, tt.line, err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for name, content := range map[string]string{
+		"ig.toml":     "description = \"竖图\"\n\n[[step]]\nop = \"resize\"\nwidth = 1080\n\n[[step]]\nop = \"adjust\"\ncontrast = 10\n\n[output]\nquality = 90\n",
+		"inline.toml": "description = \"竖图\"\nstep = [{op = \"resize\", width = 1080}, {op = \"adjust\", contrast = 10}]\n\n[output]\nquality = 90\n",
+		"ig.yaml":     "description: 竖图\nsteps:\n  - op: resize\n    width: 1080\n  - op: adjust\n    contrast: 10\noutput:\n  quality: 90\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			r, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Name != strings.TrimSuffix(name, filepath.Ext(name)) || r.Description != "竖图" || len(r.Steps) != 2 || r.Output.Quality != 90 || r.Output.Suffix != "_"+r.Name {
+				t.Errorf("解析结果不正确: %+v", r)
+			}
+		})
+	}
+}
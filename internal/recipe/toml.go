@@ -0,0 +1,148 @@
+package recipe
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlFile TOML 配方文件结构
+type tomlFile struct {
+	Description string                   `toml:"description"`
+	Step        []map[string]interface{} `toml:"step"`
+	Output      OutputSettings           `toml:"output"`
+}
+
+var (
+	tomlTableRe = regexp.MustCompile(`^\s*\[\s*([^\[\]]+?)\s*\]`)
+	tomlArrayRe = regexp.MustCompile(`^\s*\[\[\s*([^\[\]]+?)\s*\]\]`)
+	tomlKeyRe   = regexp.MustCompile(`^\s*"?([A-Za-z0-9_-]+)"?\s*=`)
+	// 类型不匹配等解码错误不是 toml.ParseError，行号只出现在错误信息中
+	tomlLineRe = regexp.MustCompile(`^toml: line (\d+)`)
+)
+
+// tomlIndex 记录 TOML 文件中各键所在的行号
+type tomlIndex struct {
+	steps []map[string]int // 每个 [[step]] 中参数所在行，"" 为表头所在行
+	keys  map[string]int   // 其他键所在行，键名形如 "output.suffix"
+}
+
+// parseTOML 解析 TOML 配方
+func parseTOML(r *Recipe, data []byte) ([]rawStep, error) {
+	var f tomlFile
+	md, err := toml.Decode(string(data), &f)
+	if err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			msg := perr.Message
+			if msg == "" {
+				msg = perr.Error()
+			}
+			// 出错的字符是换行符时 Position.Line 已指向下一行，按字节偏移计算行号
+			line := perr.Position.Line
+			if start := perr.Position.Start; start > 0 && start <= len(data) {
+				line = bytes.Count(data[:start], []byte("\n")) + 1
+			}
+			return nil, &Error{Path: r.Path, Line: line, Msg: msg}
+		}
+		line := 0
+		if m := tomlLineRe.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+		return nil, &Error{Path: r.Path, Line: line, Msg: err.Error()}
+	}
+
+	idx := indexTOML(data)
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		key := undecoded[0].String()
+		return nil, &Error{Path: r.Path, Line: idx.keys[key], Msg: fmt.Sprintf("未知配置项 %s", key)}
+	}
+
+	r.Description = f.Description
+	r.Output = f.Output
+	if key, err := r.Output.validate(); err != nil {
+		return nil, &Error{Path: r.Path, Line: idx.keys["output."+key], Msg: err.Error()}
+	}
+
+	raws := make([]rawStep, 0, len(f.Step))
+	if md.Type("step") == "Array" {
+		// step = [1, 2] 会被解码为空的映射而不报错，逐个检查内联数组的元素
+		var probe struct {
+			Step []interface{} `toml:"step"`
+		}
+		toml.Decode(string(data), &probe)
+		for _, item := range probe.Step {
+			if _, ok := item.(map[string]interface{}); !ok {
+				return nil, &Error{Path: r.Path, Line: idx.keys["step"], Msg: "步骤必须是表"}
+			}
+		}
+	}
+	for i, values := range f.Step {
+		raw := rawStep{values: make(map[string]string, len(values))}
+		if i < len(idx.steps) {
+			raw.line = idx.steps[i][""]
+			raw.keyLines = idx.steps[i]
+		}
+
+		for k, v := range values {
+			switch v.(type) {
+			case string, int64, float64, bool:
+				raw.values[k] = fmt.Sprint(v)
+			default:
+				return nil, &Error{Path: r.Path, Line: raw.keyLines[k], Msg: fmt.Sprintf("参数 %s 的值必须是字符串、数字或布尔值", k)}
+			}
+		}
+		raws = append(raws, raw)
+	}
+	return raws, nil
+}
+
+// indexTOML 扫描 TOML 文本，记录表头和键所在的行号
+func indexTOML(data []byte) tomlIndex {
+	idx := tomlIndex{keys: make(map[string]int)}
+	section := ""
+	var step map[string]int
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(text), "#") {
+			continue
+		}
+
+		if m := tomlArrayRe.FindStringSubmatch(text); m != nil {
+			section = m[1]
+			step = nil
+			if section == "step" {
+				step = map[string]int{"": line}
+				idx.steps = append(idx.steps, step)
+			}
+			continue
+		}
+		if m := tomlTableRe.FindStringSubmatch(text); m != nil {
+			section = m[1]
+			step = nil
+			idx.keys[section] = line
+			continue
+		}
+
+		m := tomlKeyRe.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		if step != nil {
+			step[m[1]] = line
+		} else if section == "" {
+			idx.keys[m[1]] = line
+		} else {
+			idx.keys[section+"."+m[1]] = line
+		}
+	}
+	return idx
+}
@@ -0,0 +1,134 @@
+package recipe
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlLineRe 语法错误的行号只出现在错误信息中，如 "yaml: line 3: ..."
+var yamlLineRe = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// parseYAML 解析 YAML 配方
+//
+//	description: Instagram 竖图
+//	steps:
+//	  - op: resize
+//	    width: 1080
+//	output:
+//	  suffix: _ig
+func parseYAML(r *Recipe, data []byte) ([]rawStep, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		e := &Error{Path: r.Path, Msg: err.Error()}
+		if m := yamlLineRe.FindStringSubmatch(e.Msg); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Msg = e.Msg[len(m[0]):]
+		}
+		return nil, e
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, &Error{Path: r.Path, Line: root.Line, Msg: "配方顶层必须是映射"}
+	}
+
+	var raws []rawStep
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "description":
+			if err := value.Decode(&r.Description); err != nil {
+				return nil, &Error{Path: r.Path, Line: value.Line, Msg: "description 必须是字符串"}
+			}
+		case "output":
+			if err := decodeYAMLOutput(r, value); err != nil {
+				return nil, err
+			}
+		case "steps":
+			if value.Kind != yaml.SequenceNode {
+				return nil, &Error{Path: r.Path, Line: value.Line, Msg: "steps 必须是列表"}
+			}
+			for _, item := range value.Content {
+				raw, err := yamlStep(r.Path, item)
+				if err != nil {
+					return nil, err
+				}
+				raws = append(raws, raw)
+			}
+		default:
+			return nil, &Error{Path: r.Path, Line: key.Line, Msg: fmt.Sprintf("未知配置项 %s", key.Value)}
+		}
+	}
+	return raws, nil
+}
+
+// yamlStep 将 YAML 映射节点转换为原始步骤
+func yamlStep(path string, node *yaml.Node) (rawStep, error) {
+	if node.Kind != yaml.MappingNode {
+		return rawStep{}, &Error{Path: path, Line: node.Line, Msg: "步骤必须是映射"}
+	}
+
+	raw := rawStep{
+		line:     node.Line,
+		keyLines: make(map[string]int),
+		values:   make(map[string]string),
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return rawStep{}, &Error{Path: path, Line: value.Line, Msg: fmt.Sprintf("参数 %s 的值必须是字符串、数字或布尔值", key.Value)}
+		}
+		raw.keyLines[key.Value] = key.Line
+		raw.values[key.Value] = value.Value
+	}
+	return raw, nil
+}
+
+// decodeYAMLOutput 解析输出设置
+func decodeYAMLOutput(r *Recipe, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return &Error{Path: r.Path, Line: node.Line, Msg: "output 必须是映射"}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		var target interface{}
+		switch key.Value {
+		case "suffix":
+			target = &r.Output.Suffix
+		case "dir":
+			target = &r.Output.Dir
+		case "format":
+			target = &r.Output.Format
+		case "quality":
+			target = &r.Output.Quality
+		case "lossless":
+			target = &r.Output.Lossless
+		case "tiff_compression":
+			target = &r.Output.TIFFCompression
+		case "strip_metadata":
+			target = &r.Output.StripMetadata
+		default:
+			return &Error{Path: r.Path, Line: key.Line, Msg: fmt.Sprintf("未知配置项 output.%s", key.Value)}
+		}
+		if err := value.Decode(target); err != nil {
+			return &Error{Path: r.Path, Line: value.Line, Msg: fmt.Sprintf("output.%s 的值类型错误", key.Value)}
+		}
+	}
+
+	if bad, err := r.Output.validate(); err != nil {
+		line := node.Line
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == bad {
+				line = node.Content[i].Line
+			}
+		}
+		return &Error{Path: r.Path, Line: line, Msg: err.Error()}
+	}
+	return nil
+}
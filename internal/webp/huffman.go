@@ -0,0 +1,275 @@
+package webp
+
+import "sort"
+
+const (
+	maxCodeLength           = 15
+	maxCodeLengthCodeLength = 7
+	numCodeLengthCodes      = 19
+)
+
+// codeLengthCodeOrder 码长码的码长写入顺序
+var codeLengthCodeOrder = [numCodeLengthCodes]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// bitWriter 按低位优先的顺序写入 VP8L 位流
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nBits uint
+}
+
+// write 写入 v 的低 n 位
+func (w *bitWriter) write(v uint32, n uint) {
+	w.acc |= uint64(v&(1<<n-1)) << w.nBits
+	w.nBits += n
+	for w.nBits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nBits -= 8
+	}
+}
+
+// bytes 补齐最后一个字节并返回全部数据
+func (w *bitWriter) bytes() []byte {
+	if w.nBits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nBits = 0, 0
+	}
+	return w.buf
+}
+
+// huffmanCode 规范前缀码，codes 已按位反转以便低位优先写入
+type huffmanCode struct {
+	codes   []uint32
+	lengths []uint8
+}
+
+func (h huffmanCode) write(bw *bitWriter, sym int) {
+	bw.write(h.codes[sym], uint(h.lengths[sym]))
+}
+
+// writeHuffmanCode 根据符号频率构造前缀码并写入码表，返回用于编码符号的前缀码
+func writeHuffmanCode(bw *bitWriter, freq []uint32) huffmanCode {
+	var used []int
+	for sym, f := range freq {
+		if f > 0 {
+			used = append(used, sym)
+		}
+	}
+	if len(used) == 0 {
+		used = []int{0}
+	}
+
+	code := huffmanCode{codes: make([]uint32, len(freq)), lengths: make([]uint8, len(freq))}
+
+	// 不超过两个且都小于 256 的符号使用简单码
+	if len(used) <= 2 && used[len(used)-1] < 256 {
+		bw.write(1, 1)
+		bw.write(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(used[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(used[0]), 8)
+		}
+		if len(used) == 2 {
+			bw.write(uint32(used[1]), 8)
+			code.codes[used[1]] = 1
+			code.lengths[used[0]] = 1
+			code.lengths[used[1]] = 1
+		}
+		return code
+	}
+
+	lengths := huffmanLengths(freq, maxCodeLength)
+	if len(used) == 1 {
+		// 只有一个符号时解码器不读取任何位
+		lengths[used[0]] = 1
+		bw.write(0, 1)
+		writeCodeLengths(bw, lengths)
+		return code
+	}
+
+	bw.write(0, 1)
+	writeCodeLengths(bw, lengths)
+	return canonicalCode(lengths)
+}
+
+// writeCodeLengths 用码长码写入各符号的码长
+func writeCodeLengths(bw *bitWriter, lengths []uint8) {
+	type token struct {
+		sym   int
+		extra uint32
+	}
+	var tokens []token
+	prev := uint8(8)
+	for i := 0; i < len(lengths); {
+		v := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == v {
+			run++
+		}
+		i += run
+
+		if v == 0 {
+			for run >= 11 {
+				r := min(run, 138)
+				tokens = append(tokens, token{18, uint32(r - 11)})
+				run -= r
+			}
+			if run >= 3 {
+				tokens = append(tokens, token{17, uint32(run - 3)})
+				run = 0
+			}
+		} else {
+			if v != prev {
+				tokens = append(tokens, token{int(v), 0})
+				prev = v
+				run--
+			}
+			for run >= 3 {
+				r := min(run, 6)
+				tokens = append(tokens, token{16, uint32(r - 3)})
+				run -= r
+			}
+		}
+		for ; run > 0; run-- {
+			tokens = append(tokens, token{int(v), 0})
+		}
+	}
+
+	freq := make([]uint32, numCodeLengthCodes)
+	for _, t := range tokens {
+		freq[t.sym]++
+	}
+	clLengths := huffmanLengths(freq, maxCodeLengthCodeLength)
+	used := 0
+	for _, l := range clLengths {
+		if l > 0 {
+			used++
+		}
+	}
+	var clCode huffmanCode
+	if used == 1 {
+		clCode = huffmanCode{codes: make([]uint32, numCodeLengthCodes), lengths: make([]uint8, numCodeLengthCodes)}
+		for sym, l := range clLengths {
+			if l > 0 {
+				clLengths[sym] = 1
+			}
+		}
+	} else {
+		clCode = canonicalCode(clLengths)
+	}
+
+	n := numCodeLengthCodes
+	for n > 4 && clLengths[codeLengthCodeOrder[n-1]] == 0 {
+		n--
+	}
+	bw.write(uint32(n-4), 4)
+	for i := 0; i < n; i++ {
+		bw.write(uint32(clLengths[codeLengthCodeOrder[i]]), 3)
+	}
+
+	bw.write(0, 1) // 不使用 max_symbol
+	extraBits := [3]uint{2, 3, 7}
+	for _, t := range tokens {
+		clCode.write(bw, t.sym)
+		if t.sym >= 16 {
+			bw.write(t.extra, extraBits[t.sym-16])
+		}
+	}
+}
+
+// canonicalCode 由码长生成规范前缀码
+func canonicalCode(lengths []uint8) huffmanCode {
+	var count [maxCodeLength + 1]uint32
+	for _, l := range lengths {
+		count[l]++
+	}
+	count[0] = 0
+	var next [maxCodeLength + 1]uint32
+	code := uint32(0)
+	for l := 1; l <= maxCodeLength; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+
+	h := huffmanCode{codes: make([]uint32, len(lengths)), lengths: lengths}
+	for sym, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		c := next[l]
+		next[l]++
+		var rev uint32
+		for i := uint8(0); i < l; i++ {
+			rev = rev<<1 | (c>>i)&1
+		}
+		h.codes[sym] = rev
+	}
+	return h
+}
+
+// huffmanLengths 计算长度不超过 limit 的霍夫曼码长。超出限制时抬高低频符号的
+// 权重后重新构造，直到满足限制。
+func huffmanLengths(freq []uint32, limit int) []uint8 {
+	lengths := make([]uint8, len(freq))
+
+	type node struct {
+		weight      uint64
+		sym         int // 叶子节点的符号，内部节点为 -1
+		left, right int
+	}
+	var leaves []int
+	for sym, f := range freq {
+		if f > 0 {
+			leaves = append(leaves, sym)
+		}
+	}
+	if len(leaves) <= 1 {
+		for _, sym := range leaves {
+			lengths[sym] = 1
+		}
+		return lengths
+	}
+
+	for floor := uint64(1); ; floor *= 2 {
+		nodes := make([]node, 0, 2*len(leaves))
+		for _, sym := range leaves {
+			nodes = append(nodes, node{weight: max(uint64(freq[sym]), floor), sym: sym})
+		}
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].weight < nodes[j].weight })
+
+		// 双队列法：叶子已排序，新建的内部节点按权重递增追加
+		li, qi := 0, len(nodes)
+		pick := func() int {
+			if li < len(leaves) && (qi >= len(nodes) || nodes[li].weight <= nodes[qi].weight) {
+				li++
+				return li - 1
+			}
+			qi++
+			return qi - 1
+		}
+		for len(nodes)-len(leaves) < len(leaves)-1 {
+			a, b := pick(), pick()
+			nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, sym: -1, left: a, right: b})
+		}
+
+		maxDepth := 0
+		var walk func(n, depth int)
+		walk = func(n, depth int) {
+			if nodes[n].sym >= 0 {
+				lengths[nodes[n].sym] = uint8(depth)
+				maxDepth = max(maxDepth, depth)
+				return
+			}
+			walk(nodes[n].left, depth+1)
+			walk(nodes[n].right, depth+1)
+		}
+		walk(len(nodes)-1, 0)
+		if maxDepth <= limit {
+			return lengths
+		}
+	}
+}
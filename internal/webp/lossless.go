@@ -0,0 +1,396 @@
+package webp
+
+import (
+	"fmt"
+	"image"
+)
+
+const (
+	maxLosslessSize = 1 << 14
+
+	transformPredictor     = 0
+	transformSubtractGreen = 2
+
+	predictorBits  = 4 // 预测变换的块大小为 16x16
+	numPredictors  = 14
+	numLiteral     = 256
+	numLengthCodes = 24
+	numDistCodes   = 40
+
+	minMatch  = 3
+	maxMatch  = 4096
+	hashBits  = 16
+	maxChain  = 32
+	maxWindow = 1<<20 - 120
+)
+
+// encodeLossless 将图像编码为完整的 VP8L 位流
+func encodeLossless(img *image.NRGBA) ([]byte, error) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if w > maxLosslessSize || h > maxLosslessSize {
+		return nil, fmt.Errorf("webp: 无损模式的图像尺寸不能超过 %dx%d，得到 %dx%d", maxLosslessSize, maxLosslessSize, w, h)
+	}
+
+	pix := make([]byte, 4*w*h)
+	for y := 0; y < h; y++ {
+		copy(pix[4*y*w:4*(y+1)*w], img.Pix[y*img.Stride:])
+	}
+
+	bw := &bitWriter{}
+	bw.write(0x2f, 8)
+	bw.write(uint32(w-1), 14)
+	bw.write(uint32(h-1), 14)
+	bw.write(btou(!img.Opaque()), 1)
+	bw.write(0, 3) // 版本号
+	encodeImageStream(bw, pix, w, h, true)
+	return bw.bytes(), nil
+}
+
+// encodeImageStream 写入变换和主图像数据（不含 VP8L 头部），pix 会被修改
+func encodeImageStream(bw *bitWriter, pix []byte, w, h int, subtractGreen bool) {
+	if subtractGreen {
+		bw.write(1, 1)
+		bw.write(transformSubtractGreen, 2)
+		for i := 0; i < len(pix); i += 4 {
+			pix[i+0] -= pix[i+1]
+			pix[i+2] -= pix[i+1]
+		}
+	}
+
+	bw.write(1, 1)
+	bw.write(transformPredictor, 2)
+	bw.write(predictorBits-2, 3)
+	residual, modes := applyPredictor(pix, w, h)
+	writeEntropyImage(bw, modes, subSize(w), false)
+
+	bw.write(0, 1) // 没有更多变换
+	writeEntropyImage(bw, residual, w, true)
+}
+
+// subSize 返回预测变换子图像在一个维度上的块数
+func subSize(n int) int {
+	return (n + 1<<predictorBits - 1) >> predictorBits
+}
+
+// applyPredictor 为每个块选择残差绝对值之和最小的预测模式，返回残差图像和模式子图像
+func applyPredictor(pix []byte, w, h int) (residual, modes []byte) {
+	tw, th := subSize(w), subSize(h)
+	modes = make([]byte, 4*tw*th)
+	for ty := 0; ty < th; ty++ {
+		for tx := 0; tx < tw; tx++ {
+			x0, y0 := tx<<predictorBits, ty<<predictorBits
+			x1, y1 := min(x0+1<<predictorBits, w), min(y0+1<<predictorBits, h)
+
+			best, bestCost := 0, -1
+			for mode := 0; mode < numPredictors; mode++ {
+				cost := 0
+				for y := y0; y < y1; y++ {
+					for x := x0; x < x1; x++ {
+						i := 4 * (y*w + x)
+						p := predict(pix, w, x, y, mode)
+						for c := 0; c < 4; c++ {
+							cost += absInt8(pix[i+c] - p[c])
+						}
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			i := 4 * (ty*tw + tx)
+			modes[i+1] = byte(best)
+			modes[i+3] = 0xff
+		}
+	}
+
+	residual = make([]byte, len(pix))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := 4 * (y*w + x)
+			mode := int(modes[4*((y>>predictorBits)*tw+x>>predictorBits)+1])
+			p := predict(pix, w, x, y, mode)
+			for c := 0; c < 4; c++ {
+				residual[i+c] = pix[i+c] - p[c]
+			}
+		}
+	}
+	return residual, modes
+}
+
+// predict 返回 (x, y) 处像素的预测值。首行固定使用左侧像素、首列固定使用上方像素，
+// 左上角像素预测为不透明黑色，与解码器的约定一致。
+func predict(pix []byte, w, x, y, mode int) [4]byte {
+	i := 4 * (y*w + x)
+	var p [4]byte
+	switch {
+	case x == 0 && y == 0:
+		return [4]byte{0, 0, 0, 0xff}
+	case y == 0:
+		copy(p[:], pix[i-4:i])
+		return p
+	case x == 0:
+		copy(p[:], pix[i-4*w:])
+		return p
+	}
+
+	l, t := pix[i-4:i], pix[i-4*w:i-4*w+4]
+	switch mode {
+	case 0:
+		p[3] = 0xff
+	case 1:
+		copy(p[:], l)
+	case 2:
+		copy(p[:], t)
+	case 3:
+		copy(p[:], pix[i-4*w+4:])
+	case 4:
+		copy(p[:], pix[i-4*w-4:])
+	case 5:
+		tr := pix[i-4*w+4:]
+		for c := range p {
+			p[c] = avg2(avg2(l[c], tr[c]), t[c])
+		}
+	case 6:
+		tl := pix[i-4*w-4:]
+		for c := range p {
+			p[c] = avg2(l[c], tl[c])
+		}
+	case 7:
+		for c := range p {
+			p[c] = avg2(l[c], t[c])
+		}
+	case 8:
+		tl := pix[i-4*w-4:]
+		for c := range p {
+			p[c] = avg2(tl[c], t[c])
+		}
+	case 9:
+		tr := pix[i-4*w+4:]
+		for c := range p {
+			p[c] = avg2(t[c], tr[c])
+		}
+	case 10:
+		tl, tr := pix[i-4*w-4:], pix[i-4*w+4:]
+		for c := range p {
+			p[c] = avg2(avg2(l[c], tl[c]), avg2(t[c], tr[c]))
+		}
+	case 11:
+		tl := pix[i-4*w-4:]
+		pl, pt := 0, 0
+		for c := 0; c < 4; c++ {
+			pl += absInt(int(tl[c]) - int(t[c]))
+			pt += absInt(int(tl[c]) - int(l[c]))
+		}
+		if pl < pt {
+			copy(p[:], l)
+		} else {
+			copy(p[:], t)
+		}
+	case 12:
+		tl := pix[i-4*w-4:]
+		for c := range p {
+			p[c] = clamp255(int(l[c]) + int(t[c]) - int(tl[c]))
+		}
+	case 13:
+		tl := pix[i-4*w-4:]
+		for c := range p {
+			a := int(avg2(l[c], t[c]))
+			p[c] = clamp255(a + (a-int(tl[c]))/2)
+		}
+	}
+	return p
+}
+
+// symbol 像素流中的一个符号：字面像素或反向引用
+type symbol struct {
+	pixel  [4]byte
+	length int // 大于 0 表示反向引用
+	dist   int // 距离码
+}
+
+// writeEntropyImage 写入熵编码图像，topLevel 表示主图像（需要写入元前缀码标志）
+func writeEntropyImage(bw *bitWriter, pix []byte, w int, topLevel bool) {
+	bw.write(0, 1) // 不使用颜色缓存
+	if topLevel {
+		bw.write(0, 1) // 整幅图像使用同一组前缀码
+	}
+
+	symbols := backwardRefs(pix, w)
+
+	var (
+		green = make([]uint32, numLiteral+numLengthCodes)
+		red   = make([]uint32, numLiteral)
+		blue  = make([]uint32, numLiteral)
+		alpha = make([]uint32, numLiteral)
+		dist  = make([]uint32, numDistCodes)
+	)
+	for _, s := range symbols {
+		if s.length > 0 {
+			code, _, _ := prefixEncode(s.length)
+			green[numLiteral+code]++
+			code, _, _ = prefixEncode(s.dist)
+			dist[code]++
+			continue
+		}
+		red[s.pixel[0]]++
+		green[s.pixel[1]]++
+		blue[s.pixel[2]]++
+		alpha[s.pixel[3]]++
+	}
+
+	gc := writeHuffmanCode(bw, green)
+	rc := writeHuffmanCode(bw, red)
+	bc := writeHuffmanCode(bw, blue)
+	ac := writeHuffmanCode(bw, alpha)
+	dc := writeHuffmanCode(bw, dist)
+
+	for _, s := range symbols {
+		if s.length > 0 {
+			code, n, extra := prefixEncode(s.length)
+			gc.write(bw, numLiteral+code)
+			bw.write(extra, n)
+			code, n, extra = prefixEncode(s.dist)
+			dc.write(bw, code)
+			bw.write(extra, n)
+			continue
+		}
+		gc.write(bw, int(s.pixel[1]))
+		rc.write(bw, int(s.pixel[0]))
+		bc.write(bw, int(s.pixel[2]))
+		ac.write(bw, int(s.pixel[3]))
+	}
+}
+
+// backwardRefs 使用哈希链贪心查找重复像素序列，生成符号流
+func backwardRefs(pix []byte, w int) []symbol {
+	n := len(pix) / 4
+	px := make([]uint32, n)
+	for i := range px {
+		px[i] = uint32(pix[4*i]) | uint32(pix[4*i+1])<<8 | uint32(pix[4*i+2])<<16 | uint32(pix[4*i+3])<<24
+	}
+
+	head := make([]int32, 1<<hashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, n)
+	insert := func(i int) {
+		if i+1 < n {
+			h := hashPair(px[i], px[i+1])
+			prev[i] = head[h]
+			head[h] = int32(i)
+		}
+	}
+
+	distCodes := planeCodes(w)
+	symbols := make([]symbol, 0, n/2)
+	for i := 0; i < n; {
+		bestLen, bestDist := 0, 0
+		try := func(j int) {
+			if j < 0 || j >= i || i-j > maxWindow {
+				return
+			}
+			l := 0
+			for l < maxMatch && i+l < n && px[j+l] == px[i+l] {
+				l++
+			}
+			if l > bestLen {
+				bestLen, bestDist = l, i-j
+			}
+		}
+		try(i - 1)
+		try(i - w)
+		if i+1 < n {
+			j := head[hashPair(px[i], px[i+1])]
+			for c := 0; j >= 0 && c < maxChain && bestLen < maxMatch; c++ {
+				try(int(j))
+				j = prev[j]
+			}
+		}
+
+		if bestLen >= minMatch {
+			code, ok := distCodes[bestDist]
+			if !ok {
+				code = bestDist + len(distanceMapTable)
+			}
+			symbols = append(symbols, symbol{length: bestLen, dist: code})
+			for k := 0; k < bestLen; k++ {
+				insert(i + k)
+			}
+			i += bestLen
+			continue
+		}
+
+		p := pix[4*i:]
+		symbols = append(symbols, symbol{pixel: [4]byte{p[0], p[1], p[2], p[3]}})
+		insert(i)
+		i++
+	}
+	return symbols
+}
+
+// planeCodes 返回图像宽度为 w 时各线性距离对应的最小二维距离码
+func planeCodes(w int) map[int]int {
+	codes := make(map[int]int, len(distanceMapTable))
+	for i, v := range distanceMapTable {
+		d := int(v>>4)*w + 8 - int(v&0xf)
+		if d < 1 {
+			continue
+		}
+		if _, ok := codes[d]; !ok {
+			codes[d] = i + 1
+		}
+	}
+	return codes
+}
+
+func hashPair(a, b uint32) uint32 {
+	return ((a * 0x1e35a7bd) ^ (b * 0x9e3779b1)) >> (32 - hashBits) & (1<<hashBits - 1)
+}
+
+// prefixEncode 将长度或距离值（>= 1）编码为前缀码、额外位数和额外位的值
+func prefixEncode(v int) (code int, nBits uint, extra uint32) {
+	v--
+	if v < 4 {
+		return v, 0, 0
+	}
+	hi := 0
+	for t := v; t > 1; t >>= 1 {
+		hi++
+	}
+	second := (v >> (hi - 1)) & 1
+	nBits = uint(hi - 1)
+	return 2*hi + second, nBits, uint32(v) & (1<<nBits - 1)
+}
+
+func avg2(a, b byte) byte {
+	return byte((int(a) + int(b)) / 2)
+}
+
+func absInt8(b byte) int {
+	return absInt(int(int8(b)))
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func clamp255(x int) byte {
+	if x < 0 {
+		return 0
+	}
+	if x > 255 {
+		return 255
+	}
+	return byte(x)
+}
+
+func btou(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
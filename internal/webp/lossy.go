@@ -0,0 +1,716 @@
+package webp
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+const maxLossySize = 1<<14 - 1
+
+var errEmptyImage = errors.New("webp: 图像为空")
+
+// 帧内预测模式
+const (
+	predDC = iota
+	predTM
+	predVE
+	predHE
+	numPredModes
+)
+
+// quantMatrix 一类系数的量化参数，下标 0 为 DC，1 为 AC
+type quantMatrix struct {
+	q    [2]int
+	bias [2]int // 取整偏置，单位 1/256
+}
+
+// quantize 量化一个系数，返回带符号的量化级别
+func (m *quantMatrix) quantize(c, i int) int {
+	q := m.q[i]
+	level := (absInt(c)*256 + q*m.bias[i]) / (q * 256)
+	level = min(level, 2047)
+	if c < 0 {
+		return -level
+	}
+	return level
+}
+
+// mbInfo 宏块头信息，写入第一分区
+type mbInfo struct {
+	yMode, uvMode int
+	skip          bool
+}
+
+// vp8Encoder 有损编码器状态
+type vp8Encoder struct {
+	mbw, mbh int
+
+	// 源平面和重建平面，尺寸按宏块对齐
+	y, u, v    []byte
+	ry, ru, rv []byte
+
+	y1, y2, uv quantMatrix
+	qi         int
+
+	parts []*boolEncoder
+	mbs   []mbInfo
+
+	// 上方和左侧宏块各 4x4 块是否有非零系数
+	upY, upC     [][4]uint8
+	upY2         []uint8
+	leftY, leftC [4]uint8
+	leftY2       uint8
+}
+
+// encodeLossy 将图像编码为 VP8 关键帧
+func encodeLossy(img *image.NRGBA, quality int) ([]byte, error) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	if w > maxLossySize || h > maxLossySize {
+		return nil, fmt.Errorf("webp: 有损模式的图像尺寸不能超过 %dx%d，得到 %dx%d", maxLossySize, maxLossySize, w, h)
+	}
+
+	e := newVP8Encoder(img, quality)
+	for mby := 0; mby < e.mbh; mby++ {
+		e.leftY, e.leftC, e.leftY2 = [4]uint8{}, [4]uint8{}, 0
+		for mbx := 0; mbx < e.mbw; mbx++ {
+			e.encodeMacroblock(mbx, mby)
+		}
+	}
+
+	fp := e.firstPartition()
+	if len(fp) >= 1<<19 {
+		return nil, errors.New("webp: 图像过大，第一分区超出限制")
+	}
+
+	tag := uint32(len(fp))<<5 | 1<<4 // 关键帧、版本 0、显示
+	out := []byte{byte(tag), byte(tag >> 8), byte(tag >> 16), 0x9d, 0x01, 0x2a,
+		byte(w), byte(w >> 8), byte(h), byte(h >> 8)}
+	out = append(out, fp...)
+
+	parts := make([][]byte, len(e.parts))
+	for i, p := range e.parts {
+		parts[i] = p.bytes()
+		if len(parts[i]) >= 1<<24 {
+			return nil, errors.New("webp: 图像过大，系数分区超出限制")
+		}
+	}
+	for _, p := range parts[:len(parts)-1] {
+		out = append(out, byte(len(p)), byte(len(p)>>8), byte(len(p)>>16))
+	}
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out, nil
+}
+
+// newVP8Encoder 转换色彩空间并初始化量化参数
+func newVP8Encoder(img *image.NRGBA, quality int) *vp8Encoder {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	e := &vp8Encoder{mbw: (w + 15) / 16, mbh: (h + 15) / 16}
+
+	// 质量 100 对应量化索引 0，质量 1 对应 127
+	e.qi = (100 - quality) * 127 / 99
+	dc, ac := int(dequantTableDC[e.qi]), int(dequantTableAC[e.qi])
+	e.y1 = quantMatrix{q: [2]int{dc, ac}, bias: [2]int{96, 110}}
+	e.y2 = quantMatrix{q: [2]int{dc * 2, max(ac*155/100, 8)}, bias: [2]int{96, 108}}
+	e.uv = quantMatrix{q: [2]int{int(dequantTableDC[min(e.qi, 117)]), ac}, bias: [2]int{110, 115}}
+
+	// 有效像素以外的区域复制边缘像素，色度按 2x2 平均。
+	// VP8 规定为有限范围 (16-235) 的 BT.601，与 libwebp 和浏览器的解码一致
+	yw, cw := 16*e.mbw, 8*e.mbw
+	e.y = make([]byte, yw*16*e.mbh)
+	e.u = make([]byte, cw*8*e.mbh)
+	e.v = make([]byte, cw*8*e.mbh)
+	at := func(x, y int) (int, int, int) {
+		x, y = min(x, w-1), min(y, h-1)
+		p := img.Pix[y*img.Stride+4*x:]
+		return int(p[0]), int(p[1]), int(p[2])
+	}
+	for y := 0; y < 16*e.mbh; y++ {
+		for x := 0; x < yw; x++ {
+			r, g, b := at(x, y)
+			e.y[y*yw+x] = byte((16839*r + 33059*g + 6420*b + 16<<16 + 1<<15) >> 16)
+		}
+	}
+	for y := 0; y < 8*e.mbh; y++ {
+		for x := 0; x < cw; x++ {
+			var r, g, b int
+			for _, d := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				pr, pg, pb := at(2*x+d[0], 2*y+d[1])
+				r, g, b = r+pr, g+pg, b+pb
+			}
+			e.u[y*cw+x] = clamp255((-9719*r - 19081*g + 28800*b + 128<<18 + 1<<17) >> 18)
+			e.v[y*cw+x] = clamp255((28800*r - 24116*g - 4684*b + 128<<18 + 1<<17) >> 18)
+		}
+	}
+	e.ry = make([]byte, len(e.y))
+	e.ru = make([]byte, len(e.u))
+	e.rv = make([]byte, len(e.v))
+
+	// 大图把系数分散到多个分区，避免单个分区超过 16MB
+	nParts := 1
+	if e.mbw*e.mbh > 16384 {
+		nParts = 4
+	}
+	for i := 0; i < nParts; i++ {
+		e.parts = append(e.parts, newBoolEncoder())
+	}
+	e.mbs = make([]mbInfo, 0, e.mbw*e.mbh)
+	e.upY = make([][4]uint8, e.mbw)
+	e.upC = make([][4]uint8, e.mbw)
+	e.upY2 = make([]uint8, e.mbw)
+	return e
+}
+
+// encodeMacroblock 选择预测模式，量化残差并写入系数，同时按解码器的方式重建宏块
+func (e *vp8Encoder) encodeMacroblock(mbx, mby int) {
+	yw, cw := 16*e.mbw, 8*e.mbw
+
+	// 亮度：16x16 预测 + Y2 块
+	top, left, tl := edges(e.ry, yw, 16, mbx, mby)
+	yMode, yPred := bestPrediction(e.y, yw, 16, mbx, mby, top, left, tl)
+
+	var coeffs [16][16]int
+	var dcs [16]int
+	for n := 0; n < 16; n++ {
+		var res [16]int
+		x0, y0 := 16*mbx+4*(n%4), 16*mby+4*(n/4)
+		for j := 0; j < 4; j++ {
+			for i := 0; i < 4; i++ {
+				res[j*4+i] = int(e.y[(y0+j)*yw+x0+i]) - int(yPred[(4*(n/4)+j)*16+4*(n%4)+i])
+			}
+		}
+		fdct(&res, &coeffs[n])
+		dcs[n] = coeffs[n][0]
+	}
+
+	var y2Levels [16]int
+	var y2Deq [16]int
+	wht := fwht(&dcs)
+	for i := 0; i < 16; i++ {
+		z := zigzag[i]
+		y2Levels[i] = e.y2.quantize(wht[z], btoi(z > 0))
+		y2Deq[z] = y2Levels[i] * e.y2.q[btoi(z > 0)]
+	}
+	dcRec := iwht(&y2Deq)
+
+	var yLevels [16][16]int
+	nonZero := hasNonZero(y2Levels[:])
+	for n := 0; n < 16; n++ {
+		var deq [16]int
+		deq[0] = dcRec[n]
+		for i := 1; i < 16; i++ {
+			z := zigzag[i]
+			yLevels[n][i] = e.y1.quantize(coeffs[n][z], 1)
+			deq[z] = yLevels[n][i] * e.y1.q[1]
+		}
+		nonZero = nonZero || hasNonZero(yLevels[n][:])
+		idct(&deq, yPred[(4*(n/4))*16+4*(n%4):], 16, e.ry[(16*mby+4*(n/4))*yw+16*mbx+4*(n%4):], yw)
+	}
+
+	// 色度：U、V 共用一种 8x8 预测模式
+	uTop, uLeft, uTL := edges(e.ru, cw, 8, mbx, mby)
+	vTop, vLeft, vTL := edges(e.rv, cw, 8, mbx, mby)
+	uvMode, uPred, vPred := bestChromaPrediction(e, mbx, mby, uTop, uLeft, uTL, vTop, vLeft, vTL)
+
+	var cLevels [8][16]int
+	for c, plane := range [2]struct{ src, rec, pred []byte }{{e.u, e.ru, uPred}, {e.v, e.rv, vPred}} {
+		for n := 0; n < 4; n++ {
+			var res, coef, deq [16]int
+			x0, y0 := 8*mbx+4*(n%2), 8*mby+4*(n/2)
+			for j := 0; j < 4; j++ {
+				for i := 0; i < 4; i++ {
+					res[j*4+i] = int(plane.src[(y0+j)*cw+x0+i]) - int(plane.pred[(4*(n/2)+j)*8+4*(n%2)+i])
+				}
+			}
+			fdct(&res, &coef)
+			levels := &cLevels[4*c+n]
+			for i := 0; i < 16; i++ {
+				z := zigzag[i]
+				levels[i] = e.uv.quantize(coef[z], btoi(z > 0))
+				deq[z] = levels[i] * e.uv.q[btoi(z > 0)]
+			}
+			nonZero = nonZero || hasNonZero(levels[:])
+			idct(&deq, plane.pred[(4*(n/2))*8+4*(n%2):], 8, plane.rec[y0*cw+x0:], cw)
+		}
+	}
+
+	e.mbs = append(e.mbs, mbInfo{yMode: yMode, uvMode: uvMode, skip: !nonZero})
+	if !nonZero {
+		e.leftY, e.leftC, e.leftY2 = [4]uint8{}, [4]uint8{}, 0
+		e.upY[mbx], e.upC[mbx], e.upY2[mbx] = [4]uint8{}, [4]uint8{}, 0
+		return
+	}
+
+	// 系数写入顺序与解码器一致：Y2、16 个 Y 块、4 个 U 块、4 个 V 块
+	p := e.parts[mby&(len(e.parts)-1)]
+	nz := putCoeffs(p, planeY2, int(e.leftY2+e.upY2[mbx]), &y2Levels, 0)
+	e.leftY2, e.upY2[mbx] = nz, nz
+
+	unz := e.upY[mbx]
+	for y := 0; y < 4; y++ {
+		lnz := e.leftY[y]
+		for x := 0; x < 4; x++ {
+			lnz = putCoeffs(p, planeY1WithY2, int(lnz+unz[x]), &yLevels[4*y+x], 1)
+			unz[x] = lnz
+		}
+		e.leftY[y] = lnz
+	}
+	e.upY[mbx] = unz
+
+	unz = e.upC[mbx]
+	for c := 0; c < 4; c += 2 {
+		for y := 0; y < 2; y++ {
+			lnz := e.leftC[y+c]
+			for x := 0; x < 2; x++ {
+				lnz = putCoeffs(p, planeUV, int(lnz+unz[x+c]), &cLevels[2*c+2*y+x], 0)
+				unz[x+c] = lnz
+			}
+			e.leftC[y+c] = lnz
+		}
+	}
+	e.upC[mbx] = unz
+}
+
+// firstPartition 写入帧头和所有宏块的预测模式
+func (e *vp8Encoder) firstPartition() []byte {
+	fp := newBoolEncoder()
+	fp.putLiteral(0, 1) // 色彩空间
+	fp.putLiteral(0, 1) // 像素值截断
+	fp.putLiteral(0, 1) // 不分段
+
+	// 普通环路滤波，强度随量化步长增大
+	fp.putLiteral(0, 1)
+	fp.putLiteral(uint32(min(e.qi*2/5, 63)), 6)
+	fp.putLiteral(0, 3) // 锐度
+	fp.putLiteral(0, 1) // 不使用滤波增量
+
+	log2Parts := uint32(0)
+	for 1<<log2Parts < len(e.parts) {
+		log2Parts++
+	}
+	fp.putLiteral(log2Parts, 2)
+
+	fp.putLiteral(uint32(e.qi), 7)
+	for i := 0; i < 5; i++ {
+		fp.putLiteral(0, 1) // 各类量化增量均为 0
+	}
+	fp.putLiteral(0, 1) // refresh_entropy_probs
+
+	// 不更新系数概率，使用默认值
+	for i := range tokenProbUpdateProb {
+		for j := range tokenProbUpdateProb[i] {
+			for k := range tokenProbUpdateProb[i][j] {
+				for _, p := range tokenProbUpdateProb[i][j][k] {
+					fp.putBit(p, false)
+				}
+			}
+		}
+	}
+
+	skipped := 0
+	for _, mb := range e.mbs {
+		if mb.skip {
+			skipped++
+		}
+	}
+	useSkip := skipped > 0
+	skipProb := uint8(0)
+	fp.putLiteral(btou(useSkip), 1)
+	if useSkip {
+		skipProb = uint8(min(max(255*(len(e.mbs)-skipped)/len(e.mbs), 1), 254))
+		fp.putLiteral(uint32(skipProb), 8)
+	}
+
+	for _, mb := range e.mbs {
+		if useSkip {
+			fp.putBit(skipProb, mb.skip)
+		}
+		fp.putBit(145, true) // 16x16 亮度预测
+		switch mb.yMode {
+		case predDC:
+			fp.putBit(156, false)
+			fp.putBit(163, false)
+		case predVE:
+			fp.putBit(156, false)
+			fp.putBit(163, true)
+		case predHE:
+			fp.putBit(156, true)
+			fp.putBit(128, false)
+		case predTM:
+			fp.putBit(156, true)
+			fp.putBit(128, true)
+		}
+		switch mb.uvMode {
+		case predDC:
+			fp.putBit(142, false)
+		case predVE:
+			fp.putBit(142, true)
+			fp.putBit(114, false)
+		case predHE:
+			fp.putBit(142, true)
+			fp.putBit(114, true)
+			fp.putBit(183, false)
+		case predTM:
+			fp.putBit(142, true)
+			fp.putBit(114, true)
+			fp.putBit(183, true)
+		}
+	}
+	return fp.bytes()
+}
+
+// putCoeffs 写入一个 4x4 块的量化系数（按 zigzag 顺序），返回是否有非零系数
+func putCoeffs(w *boolEncoder, plane, ctx int, levels *[16]int, first int) uint8 {
+	probs := &defaultTokenProb[plane]
+	last := -1
+	for i := 15; i >= first; i-- {
+		if levels[i] != 0 {
+			last = i
+			break
+		}
+	}
+
+	p := &probs[bands[first]][ctx]
+	if last < 0 {
+		w.putBit(p[0], false)
+		return 0
+	}
+	w.putBit(p[0], true)
+
+	for i := first; i <= last; i++ {
+		v := absInt(levels[i])
+		if v == 0 {
+			w.putBit(p[1], false)
+			p = &probs[bands[i+1]][0]
+			continue
+		}
+		w.putBit(p[1], true)
+		if v == 1 {
+			w.putBit(p[2], false)
+			p = &probs[bands[i+1]][1]
+		} else {
+			w.putBit(p[2], true)
+			putLevel(w, p, v)
+			p = &probs[bands[i+1]][2]
+		}
+		w.putBit(128, levels[i] < 0)
+		if i == 15 {
+			break
+		}
+		w.putBit(p[0], i < last)
+	}
+	return 1
+}
+
+// putLevel 写入大于 1 的系数绝对值（RFC 6386 第 13.2 节的令牌树）
+func putLevel(w *boolEncoder, p *[nProb]uint8, v int) {
+	switch {
+	case v <= 4:
+		w.putBit(p[3], false)
+		if v == 2 {
+			w.putBit(p[4], false)
+		} else {
+			w.putBit(p[4], true)
+			w.putBit(p[5], v == 4)
+		}
+	case v <= 10:
+		w.putBit(p[3], true)
+		w.putBit(p[6], false)
+		if v <= 6 {
+			w.putBit(p[7], false)
+			w.putBit(159, v == 6)
+		} else {
+			w.putBit(p[7], true)
+			w.putBit(165, (v-7)&2 != 0)
+			w.putBit(145, (v-7)&1 != 0)
+		}
+	default:
+		w.putBit(p[3], true)
+		w.putBit(p[6], true)
+		cat := 0
+		for cat < 3 && v >= 3+(8<<(cat+1)) {
+			cat++
+		}
+		w.putBit(p[8], cat >= 2)
+		w.putBit(p[9+cat/2], cat&1 == 1)
+		extra := v - (3 + 8<<cat)
+		tab := catProbs[cat]
+		for i, prob := range tab {
+			w.putBit(prob, extra>>(len(tab)-1-i)&1 == 1)
+		}
+	}
+}
+
+// edges 返回宏块上方一行、左侧一列和左上角的重建像素，图像边界外按规范取 127 或 129
+func edges(plane []byte, stride, size, mbx, mby int) (top, left []int, topLeft int) {
+	top, left = make([]int, size), make([]int, size)
+	for i := 0; i < size; i++ {
+		if mby == 0 {
+			top[i] = 127
+		} else {
+			top[i] = int(plane[(size*mby-1)*stride+size*mbx+i])
+		}
+		if mbx == 0 {
+			left[i] = 129
+		} else {
+			left[i] = int(plane[(size*mby+i)*stride+size*mbx-1])
+		}
+	}
+	switch {
+	case mby == 0:
+		topLeft = 127
+	case mbx == 0:
+		topLeft = 129
+	default:
+		topLeft = int(plane[(size*mby-1)*stride+size*mbx-1])
+	}
+	return top, left, topLeft
+}
+
+// predictBlock 生成 size x size 的预测块
+func predictBlock(mode, size, mbx, mby int, top, left []int, topLeft int) []byte {
+	pred := make([]byte, size*size)
+	switch mode {
+	case predDC:
+		shift := 3
+		if size == 16 {
+			shift = 4
+		}
+		var dc int
+		switch {
+		case mbx == 0 && mby == 0:
+			dc = 128
+		case mby == 0:
+			dc = (sum(left) + size/2) >> shift
+		case mbx == 0:
+			dc = (sum(top) + size/2) >> shift
+		default:
+			dc = (sum(top) + sum(left) + size) >> (shift + 1)
+		}
+		for i := range pred {
+			pred[i] = byte(dc)
+		}
+	case predTM:
+		for j := 0; j < size; j++ {
+			for i := 0; i < size; i++ {
+				pred[j*size+i] = clamp255(left[j] + top[i] - topLeft)
+			}
+		}
+	case predVE:
+		for j := 0; j < size; j++ {
+			for i := 0; i < size; i++ {
+				pred[j*size+i] = byte(top[i])
+			}
+		}
+	case predHE:
+		for j := 0; j < size; j++ {
+			for i := 0; i < size; i++ {
+				pred[j*size+i] = byte(left[j])
+			}
+		}
+	}
+	return pred
+}
+
+// bestPrediction 返回与源图像误差平方和最小的预测模式及预测块
+func bestPrediction(src []byte, stride, size, mbx, mby int, top, left []int, topLeft int) (int, []byte) {
+	bestMode, bestCost := 0, -1
+	var bestPred []byte
+	for mode := 0; mode < numPredModes; mode++ {
+		pred := predictBlock(mode, size, mbx, mby, top, left, topLeft)
+		cost := sse(src, stride, size, mbx, mby, pred)
+		if bestCost < 0 || cost < bestCost {
+			bestMode, bestPred, bestCost = mode, pred, cost
+		}
+	}
+	return bestMode, bestPred
+}
+
+// bestChromaPrediction 为 U、V 选择共同的预测模式
+func bestChromaPrediction(e *vp8Encoder, mbx, mby int, uTop, uLeft []int, uTL int, vTop, vLeft []int, vTL int) (int, []byte, []byte) {
+	cw := 8 * e.mbw
+	bestMode, bestCost := 0, -1
+	var bestU, bestV []byte
+	for mode := 0; mode < numPredModes; mode++ {
+		u := predictBlock(mode, 8, mbx, mby, uTop, uLeft, uTL)
+		v := predictBlock(mode, 8, mbx, mby, vTop, vLeft, vTL)
+		cost := sse(e.u, cw, 8, mbx, mby, u) + sse(e.v, cw, 8, mbx, mby, v)
+		if bestCost < 0 || cost < bestCost {
+			bestMode, bestCost, bestU, bestV = mode, cost, u, v
+		}
+	}
+	return bestMode, bestU, bestV
+}
+
+// sse 计算预测块与源图像对应区域的误差平方和
+func sse(src []byte, stride, size, mbx, mby int, pred []byte) int {
+	total := 0
+	for j := 0; j < size; j++ {
+		row := src[(size*mby+j)*stride+size*mbx:]
+		for i := 0; i < size; i++ {
+			d := int(row[i]) - int(pred[j*size+i])
+			total += d * d
+		}
+	}
+	return total
+}
+
+// fdct 4x4 正向 DCT，与解码器的反变换配对（缩放与 libwebp 一致）
+func fdct(in, out *[16]int) {
+	var tmp [16]int
+	for i := 0; i < 4; i++ {
+		d0, d1, d2, d3 := in[i*4], in[i*4+1], in[i*4+2], in[i*4+3]
+		a0, a1, a2, a3 := d0+d3, d1+d2, d1-d2, d0-d3
+		tmp[i*4+0] = (a0 + a1) * 8
+		tmp[i*4+1] = (a2*2217 + a3*5352 + 1812) >> 9
+		tmp[i*4+2] = (a0 - a1) * 8
+		tmp[i*4+3] = (a3*2217 - a2*5352 + 937) >> 9
+	}
+	for i := 0; i < 4; i++ {
+		a0, a1 := tmp[i]+tmp[12+i], tmp[4+i]+tmp[8+i]
+		a2, a3 := tmp[4+i]-tmp[8+i], tmp[i]-tmp[12+i]
+		out[i] = (a0 + a1 + 7) >> 4
+		out[4+i] = (a2*2217+a3*5352+12000)>>16 + btoi(a3 != 0)
+		out[8+i] = (a0 - a1 + 7) >> 4
+		out[12+i] = (a3*2217 - a2*5352 + 51000) >> 16
+	}
+}
+
+// idct 4x4 反向 DCT，结果与预测值相加后写入 dst（RFC 6386 第 14.3 节）
+func idct(in *[16]int, pred []byte, predStride int, dst []byte, dstStride int) {
+	const c1, c2 = 85627, 35468
+	var m [4][4]int
+	for i := 0; i < 4; i++ {
+		a := in[i] + in[8+i]
+		b := in[i] - in[8+i]
+		c := (in[4+i]*c2)>>16 - (in[12+i]*c1)>>16
+		d := (in[4+i]*c1)>>16 + (in[12+i]*c2)>>16
+		m[i] = [4]int{a + d, b + c, b - c, a - d}
+	}
+	for j := 0; j < 4; j++ {
+		dc := m[0][j] + 4
+		a := dc + m[2][j]
+		b := dc - m[2][j]
+		c := (m[1][j]*c2)>>16 - (m[3][j]*c1)>>16
+		d := (m[1][j]*c1)>>16 + (m[3][j]*c2)>>16
+		row := [4]int{(a + d) >> 3, (b + c) >> 3, (b - c) >> 3, (a - d) >> 3}
+		for i, r := range row {
+			dst[j*dstStride+i] = clamp255(int(pred[j*predStride+i]) + r)
+		}
+	}
+}
+
+// fwht 对 16 个 DC 系数做正向 Walsh-Hadamard 变换
+func fwht(in *[16]int) [16]int {
+	var tmp, out [16]int
+	for i := 0; i < 4; i++ {
+		a0, a1 := in[i*4]+in[i*4+3], in[i*4+1]+in[i*4+2]
+		a2, a3 := in[i*4+1]-in[i*4+2], in[i*4]-in[i*4+3]
+		tmp[i*4+0], tmp[i*4+1], tmp[i*4+2], tmp[i*4+3] = a0+a1, a3+a2, a0-a1, a3-a2
+	}
+	for i := 0; i < 4; i++ {
+		a0, a1 := tmp[i]+tmp[12+i], tmp[4+i]+tmp[8+i]
+		a2, a3 := tmp[4+i]-tmp[8+i], tmp[i]-tmp[12+i]
+		out[i], out[4+i], out[8+i], out[12+i] = (a0+a1)>>1, (a3+a2)>>1, (a0-a1)>>1, (a3-a2)>>1
+	}
+	return out
+}
+
+// iwht 反向 Walsh-Hadamard 变换，返回各 4x4 块的 DC 系数（RFC 6386 第 14.3 节）
+func iwht(in *[16]int) [16]int {
+	var m, out [16]int
+	for i := 0; i < 4; i++ {
+		a0, a1 := in[i]+in[12+i], in[4+i]+in[8+i]
+		a2, a3 := in[4+i]-in[8+i], in[i]-in[12+i]
+		m[i], m[8+i], m[4+i], m[12+i] = a0+a1, a0-a1, a3+a2, a3-a2
+	}
+	for i := 0; i < 4; i++ {
+		dc := m[i*4] + 3
+		a0, a1 := dc+m[i*4+3], m[i*4+1]+m[i*4+2]
+		a2, a3 := m[i*4+1]-m[i*4+2], dc-m[i*4+3]
+		out[i*4+0], out[i*4+1], out[i*4+2], out[i*4+3] = (a0+a1)>>3, (a3+a2)>>3, (a0-a1)>>3, (a3-a2)>>3
+	}
+	return out
+}
+
+// boolEncoder VP8 布尔熵编码器（RFC 6386 第 7.3 节）
+type boolEncoder struct {
+	buf      []byte
+	rng      uint32
+	bottom   uint32
+	bitCount int
+}
+
+func newBoolEncoder() *boolEncoder {
+	return &boolEncoder{rng: 255, bitCount: 24}
+}
+
+// putBit 以 prob/256 为取 0 的概率写入一位
+func (e *boolEncoder) putBit(prob uint8, bit bool) {
+	split := 1 + (e.rng-1)*uint32(prob)>>8
+	if bit {
+		e.bottom += split
+		e.rng -= split
+	} else {
+		e.rng = split
+	}
+	for e.rng < 128 {
+		e.rng <<= 1
+		if e.bottom&(1<<31) != 0 {
+			i := len(e.buf) - 1
+			for ; e.buf[i] == 0xff; i-- {
+				e.buf[i] = 0
+			}
+			e.buf[i]++
+		}
+		e.bottom <<= 1
+		e.bitCount--
+		if e.bitCount == 0 {
+			e.buf = append(e.buf, byte(e.bottom>>24))
+			e.bottom &= 1<<24 - 1
+			e.bitCount = 8
+		}
+	}
+}
+
+// putLiteral 以均匀概率写入 v 的低 n 位，高位在前
+func (e *boolEncoder) putLiteral(v uint32, n int) {
+	for n > 0 {
+		n--
+		e.putBit(128, v>>n&1 == 1)
+	}
+}
+
+// bytes 刷新编码器并返回全部数据
+func (e *boolEncoder) bytes() []byte {
+	for i := 0; i < 32; i++ {
+		e.putBit(128, false)
+	}
+	return e.buf
+}
+
+func hasNonZero(levels []int) bool {
+	for _, l := range levels {
+		if l != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+// Package webp 实现 WebP 编码。
+//
+// 标准库和 golang.org/x/image 只提供 WebP 解码器，这里按 RFC 6386（VP8）
+// 和 WebP 无损格式规范实现一个精简的纯 Go 编码器：
+//   - 有损模式：16x16 帧内预测（DC/V/H/TM）+ 默认系数概率，不做率失真优化；
+//   - 无损模式：减绿变换、预测变换和 LZ77 反向引用；
+//   - 带透明通道的有损图像使用 VP8X + ALPH 块，alpha 通道无损压缩。
+package webp
+
+import (
+	"encoding/binary"
+	"image"
+	"io"
+
+	"github.com/disintegration/imaging"
+)
+
+// DefaultQuality 有损模式的默认质量
+const DefaultQuality = 75
+
+// Options 编码选项
+type Options struct {
+	Lossless bool // 无损模式，忽略 Quality
+	Quality  int  // 有损质量 (1-100)，0 表示默认值
+}
+
+// Encode 将图像编码为 WebP 写入 w，opts 为 nil 时使用默认质量的有损模式
+func Encode(w io.Writer, img image.Image, opts *Options) error {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Quality <= 0 {
+		o.Quality = DefaultQuality
+	}
+	if o.Quality > 100 {
+		o.Quality = 100
+	}
+
+	nrgba := imaging.Clone(img)
+	if nrgba.Rect.Empty() {
+		return errEmptyImage
+	}
+
+	var chunks []chunk
+	if o.Lossless {
+		data, err := encodeLossless(nrgba)
+		if err != nil {
+			return err
+		}
+		chunks = append(chunks, chunk{"VP8L", data})
+	} else {
+		data, err := encodeLossy(nrgba, o.Quality)
+		if err != nil {
+			return err
+		}
+		if nrgba.Opaque() {
+			chunks = append(chunks, chunk{"VP8 ", data})
+		} else {
+			chunks = append(chunks,
+				chunk{"VP8X", extendedHeader(nrgba.Rect.Dx(), nrgba.Rect.Dy())},
+				chunk{"ALPH", encodeAlpha(nrgba)},
+				chunk{"VP8 ", data},
+			)
+		}
+	}
+	return writeRIFF(w, chunks)
+}
+
+// chunk RIFF 数据块
+type chunk struct {
+	fourCC string
+	data   []byte
+}
+
+// writeRIFF 写入 RIFF/WEBP 容器，奇数长度的块补一个填充字节
+func writeRIFF(w io.Writer, chunks []chunk) error {
+	size := 4 // "WEBP"
+	for _, c := range chunks {
+		size += 8 + len(c.data) + len(c.data)&1
+	}
+
+	buf := make([]byte, 0, 8+size)
+	buf = append(buf, "RIFF"...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(size))
+	buf = append(buf, "WEBP"...)
+	for _, c := range chunks {
+		buf = append(buf, c.fourCC...)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(c.data)))
+		buf = append(buf, c.data...)
+		if len(c.data)&1 == 1 {
+			buf = append(buf, 0)
+		}
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// extendedHeader 返回只设置了 alpha 标志的 VP8X 块内容
+func extendedHeader(width, height int) []byte {
+	const alphaFlag = 1 << 4
+	b := make([]byte, 10)
+	b[0] = alphaFlag
+	putUint24(b[4:], uint32(width-1))
+	putUint24(b[7:], uint32(height-1))
+	return b
+}
+
+// encodeAlpha 返回 ALPH 块内容：alpha 值存放在无损位流的绿色通道中，不做滤波
+func encodeAlpha(img *image.NRGBA) []byte {
+	const compressionLossless = 1
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	pix := make([]byte, 4*w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := 4 * (y*w + x)
+			pix[i+1] = img.Pix[y*img.Stride+4*x+3]
+			pix[i+3] = 0xff
+		}
+	}
+
+	bw := &bitWriter{}
+	encodeImageStream(bw, pix, w, h, false)
+	return append([]byte{compressionLossless}, bw.bytes()...)
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
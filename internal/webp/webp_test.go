@@ -0,0 +1,137 @@
+package webp
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	xwebp "golang.org/x/image/webp"
+)
+
+// 覆盖 1 像素、奇数尺寸和不是宏块整数倍的尺寸
+var testSizes = []image.Point{{1, 1}, {3, 5}, {17, 9}, {33, 31}, {64, 48}}
+
+// noiseImage 返回颜色随机、可带随机透明度的测试图像，用于检验无损位流的每个像素
+func noiseImage(w, h int, alpha bool) *image.NRGBA {
+	rng := rand.New(rand.NewSource(int64(w*1000 + h)))
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = uint8(rng.Intn(256))
+		img.Pix[i+1] = uint8(rng.Intn(256))
+		img.Pix[i+2] = uint8(rng.Intn(256))
+		img.Pix[i+3] = 255
+		if alpha {
+			img.Pix[i+3] = uint8(rng.Intn(256))
+		}
+	}
+	// 加入重复的行，使 LZ77 反向引用和颜色缓存也被用到
+	if h > 2 {
+		copy(img.Pix[img.Stride*(h-1):], img.Pix[:img.Stride])
+	}
+	return img
+}
+
+// encodeDecode 编码后用 x/image/webp 解码，并检查头部记录的尺寸
+func encodeDecode(t *testing.T, img image.Image, opts *Options) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, opts); err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	cfg, err := xwebp.DecodeConfig(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("无法读取头部: %v", err)
+	}
+	if size := img.Bounds().Size(); cfg.Width != size.X || cfg.Height != size.Y {
+		t.Fatalf("头部尺寸为 %dx%d，期望 %dx%d", cfg.Width, cfg.Height, size.X, size.Y)
+	}
+	got, err := xwebp.Decode(&buf)
+	if err != nil {
+		t.Fatalf("解码失败: %v", err)
+	}
+	if got.Bounds().Size() != img.Bounds().Size() {
+		t.Fatalf("解码尺寸为 %v，期望 %v", got.Bounds().Size(), img.Bounds().Size())
+	}
+	return got
+}
+
+func TestLosslessRoundTrip(t *testing.T) {
+	for _, size := range testSizes {
+		for _, alpha := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%dx%d-alpha=%t", size.X, size.Y, alpha), func(t *testing.T) {
+				img := noiseImage(size.X, size.Y, alpha)
+				got, ok := encodeDecode(t, img, &Options{Lossless: true}).(*image.NRGBA)
+				if !ok {
+					t.Fatal("无损图像应解码为 *image.NRGBA")
+				}
+				for i := range img.Pix {
+					if img.Pix[i] != got.Pix[i] {
+						t.Fatalf("第 %d 个像素第 %d 通道为 %d，期望 %d", i/4, i%4, got.Pix[i], img.Pix[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestLossyAlpha(t *testing.T) {
+	for _, size := range testSizes {
+		t.Run(fmt.Sprintf("%dx%d", size.X, size.Y), func(t *testing.T) {
+			img := noiseImage(size.X, size.Y, true)
+			got, ok := encodeDecode(t, img, nil).(*image.NYCbCrA)
+			if !ok {
+				t.Fatal("带透明通道的有损图像应解码为 *image.NYCbCrA")
+			}
+			// alpha 通道无损压缩
+			for y := 0; y < size.Y; y++ {
+				for x := 0; x < size.X; x++ {
+					if a, want := got.A[got.AOffset(x, y)], img.NRGBAAt(x, y).A; a != want {
+						t.Fatalf("(%d,%d) 的 alpha 为 %d，期望 %d", x, y, a, want)
+					}
+				}
+			}
+		})
+	}
+
+	opaque := noiseImage(17, 9, false)
+	if _, ok := encodeDecode(t, opaque, nil).(*image.YCbCr); !ok {
+		t.Error("不透明的有损图像应解码为 *image.YCbCr（不带 ALPH 块）")
+	}
+}
+
+// TestLossyLimitedRange 检查 VP8 平面使用有限范围 (16-235) 的 BT.601 系数，
+// 与 libwebp 和浏览器的解码一致
+func TestLossyLimitedRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		c         color.NRGBA
+		y, cb, cr int
+	}{
+		{"black", color.NRGBA{0, 0, 0, 255}, 16, 128, 128},
+		{"white", color.NRGBA{255, 255, 255, 255}, 235, 128, 128},
+		{"gray", color.NRGBA{128, 128, 128, 255}, 126, 128, 128},
+		{"red", color.NRGBA{255, 0, 0, 255}, 81, 90, 240},
+		{"blue", color.NRGBA{0, 0, 255, 255}, 41, 240, 110},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewNRGBA(image.Rect(0, 0, 19, 13))
+			for i := 0; i < len(img.Pix); i += 4 {
+				copy(img.Pix[i:], []uint8{tt.c.R, tt.c.G, tt.c.B, tt.c.A})
+			}
+			got := encodeDecode(t, img, &Options{Quality: 100}).(*image.YCbCr)
+			for y := 0; y < 13; y++ {
+				for x := 0; x < 19; x++ {
+					yy := int(got.Y[got.YOffset(x, y)])
+					cb, cr := int(got.Cb[got.COffset(x, y)]), int(got.Cr[got.COffset(x, y)])
+					if absInt(yy-tt.y) > 1 || absInt(cb-tt.cb) > 1 || absInt(cr-tt.cr) > 1 {
+						t.Fatalf("(%d,%d) 为 Y=%d Cb=%d Cr=%d，期望 %d %d %d", x, y, yy, cb, cr, tt.y, tt.cb, tt.cr)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+// Package xpix 提供可嵌入其他 Go 程序的图像处理 API。
+//
+// 所有函数都作用于内存中的 image.Image 或 io.Reader/io.Writer，
+// 不会向标准输出打印任何内容，也不会读取 xpix 的配置文件或其全局设置：
+// 选项中未设置的字段使用内置默认值，与没有配置文件时的 xpix 命令相同。
+// AdjustOperation.Report 默认为 nil，自动调整估计出的参数可通过它获取。
+//
+//	img, err := xpix.Decode(r)
+//	if err != nil {
+//		return err
+//	}
+//	img, err = xpix.Resize(img, xpix.ResizeOptions{Width: 2048, KeepRatio: true})
+//	if err != nil {
+//		return err
+//	}
+//	img, err = xpix.Adjust(img, xpix.AdjustOptions{Contrast: 10, Gamma: 1.0})
+//	if err != nil {
+//		return err
+//	}
+//	return xpix.Encode(w, img, xpix.EncodeOptions{Format: xpix.JPEG, Quality: 90})
+package xpix
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/disintegration/imaging"
+	"github.com/xiaoheiwowo/xpix/internal/processor"
+)
+
+// 错误值，可使用 errors.Is 判断
+var (
+	// ErrInvalidOptions 选项无效，例如裁剪区域为空或与图像不相交、缩放宽高均为 0
+	ErrInvalidOptions = processor.ErrInvalidOptions
+	// ErrNoWatermark WatermarkOptions 中既没有文字或图片（Image 或 Picture），也没有开启不可见水印
+	ErrNoWatermark = processor.ErrNoWatermark
+	// ErrNoPayload 图像中没有检测到不可见水印
+	ErrNoPayload = processor.ErrNoPayload
+	// ErrFontLoad 无法加载文字水印所需的字体
+	ErrFontLoad = processor.ErrFontLoad
+	// ErrNoMetadata 图像中没有可读取的 EXIF 元数据
+	ErrNoMetadata = processor.ErrNoMetadata
+	// ErrUnsupportedFormat 不支持的编码格式
+	ErrUnsupportedFormat = processor.ErrUnsupportedFormat
+)
+
+type (
+	// AdjustOptions 调色选项。Gamma 为 0 或 1 时不调整，Temperature 为 0 或 6500 时不调整。
+	AdjustOptions = processor.AdjustOptions
+	// Levels 色阶，设置到 AdjustOptions.Levels
+	Levels = processor.Levels
+	// Curve 色调曲线，设置到 AdjustOptions.Curves
+	Curve = processor.Curve
+	// CurvePoint 色调曲线的控制点，取值 0-255
+	CurvePoint = processor.CurvePoint
+	// HSLAdjustment 单个色相范围的 HSL 调整，设置到 AdjustOptions.HSL
+	HSLAdjustment = processor.HSLAdjustment
+	// AutoAdjustment 自动白平衡和自动色调估计出的参数
+	AutoAdjustment = processor.AutoAdjustment
+	// CropOptions 裁剪选项，Width 和 Height 必须大于 0
+	CropOptions = processor.CropOptions
+	// ResizeOptions 缩放选项，Width 和 Height 至少有一个大于 0
+	ResizeOptions = processor.ResizeOptions
+	// WatermarkOptions 水印选项，Text、图片（文件路径 Image 或已解码的 Picture）和 Invisible 至少指定一个
+	WatermarkOptions = processor.WatermarkOptions
+	// TextStyle 文字水印样式，设置到 WatermarkOptions.Style
+	TextStyle = processor.TextStyle
+	// TileOptions 平铺水印选项，设置到 WatermarkOptions.Tile
+	TileOptions = processor.TileOptions
+	// FrameOptions 相机信息相框选项，Logo 可以是文件路径 Logo 或已解码的 LogoPicture
+	FrameOptions = processor.FrameOptions
+	// Metadata 图像 EXIF 元数据
+	Metadata = processor.ImageMetadata
+	// Source 源文件信息，用于替换水印文字中的 EXIF 占位符
+	Source = processor.Source
+
+	// Operation 可组合的图像处理操作
+	Operation = processor.Operation
+	// AdjustOperation 调色操作
+	AdjustOperation = processor.AdjustOperation
+	// CropOperation 裁剪操作
+	CropOperation = processor.CropOperation
+	// ResizeOperation 缩放操作
+	ResizeOperation = processor.ResizeOperation
+	// WatermarkOperation 水印操作
+	WatermarkOperation = processor.WatermarkOperation
+	// FrameOperation 相框操作
+	FrameOperation = processor.FrameOperation
+)
+
+// Format 编码格式
+type Format string
+
+// 支持的编码格式
+const (
+	JPEG Format = processor.FormatJPEG
+	PNG  Format = processor.FormatPNG
+	GIF  Format = processor.FormatGIF
+	TIFF Format = processor.FormatTIFF
+	BMP  Format = processor.FormatBMP
+	WebP Format = processor.FormatWebP
+)
+
+// TIFF 压缩方式
+const (
+	TIFFDeflate = processor.TIFFDeflate
+	TIFFNone    = processor.TIFFNone
+)
+
+// 相框布局
+const (
+	FrameClassic = processor.FrameClassic
+	FrameCenter  = processor.FrameCenter
+	FrameMinimal = processor.FrameMinimal
+)
+
+// 水印的混合模式，设置到 WatermarkOptions.Blend
+const (
+	BlendNormal     = processor.BlendNormal
+	BlendMultiply   = processor.BlendMultiply
+	BlendScreen     = processor.BlendScreen
+	BlendOverlay    = processor.BlendOverlay
+	BlendSoftLight  = processor.BlendSoftLight
+	BlendDifference = processor.BlendDifference
+)
+
+// 色阶和曲线作用的通道
+const (
+	ChannelRGB   = processor.ChannelRGB
+	ChannelRed   = processor.ChannelRed
+	ChannelGreen = processor.ChannelGreen
+	ChannelBlue  = processor.ChannelBlue
+)
+
+// 自动白平衡的估计方法，设置到 AdjustOptions.AutoWB
+const (
+	AutoWBGrayWorld  = processor.AutoWBGrayWorld
+	AutoWBWhitePatch = processor.AutoWBWhitePatch
+	AutoWBPercentile = processor.AutoWBPercentile
+)
+
+// MaxExposure AdjustOptions.Exposure 的最大档数（EV）
+const MaxExposure = processor.MaxExposure
+
+// MaxPayloadLength 不可见水印载荷的最大长度（字节）
+const MaxPayloadLength = processor.MaxPayloadLength
+
+// 图片水印大小的基准，设置到 WatermarkOptions.ScaleBy
+const (
+	ScaleByWidth = processor.ScaleByWidth
+	ScaleByShort = processor.ScaleByShort
+)
+
+// EncodeOptions 编码选项
+type EncodeOptions struct {
+	Format          Format // 输出格式，默认 JPEG
+	Quality         int    // JPEG 和有损 WebP 的质量 (1-100)，0 表示 95
+	Lossless        bool   // WebP 使用无损压缩
+	TIFFCompression string // TIFF 压缩方式: TIFFDeflate（默认）或 TIFFNone
+}
+
+// Adjust 调整图像的亮度、对比度、饱和度等，返回新图像
+func Adjust(img image.Image, opts AdjustOptions) (image.Image, error) {
+	return AdjustOperation{Options: opts}.Apply(context.Background(), img)
+}
+
+// ParseLevels 解析 "[通道:]黑场,白场[,Gamma[,输出黑场,输出白场]]" 格式的色阶，多条以分号分隔
+func ParseLevels(s string) ([]Levels, error) {
+	return processor.ParseLevels(s)
+}
+
+// ParseCurves 解析 "[通道:]x,y x,y ..." 格式的色调曲线，多条以分号分隔
+func ParseCurves(s string) ([]Curve, error) {
+	return processor.ParseCurves(s)
+}
+
+// ParseHSL 解析 "范围:h=色相,s=饱和度,l=明度" 格式的 HSL 调整，多个范围以分号分隔
+func ParseHSL(s string) ([]HSLAdjustment, error) {
+	return processor.ParseHSL(s)
+}
+
+// ParseCurveFile 解析曲线文件，每行一条 "levels ..."、"curve ..." 或 "hsl ..."，# 开头为注释。
+// 返回的 AdjustOptions 只设置了 Levels、Curves 和 HSL
+func ParseCurveFile(r io.Reader) (AdjustOptions, error) {
+	return processor.ParseCurveFile(r)
+}
+
+// EstimateWhiteBalance 估计校正图像色偏所需的色温和色调，可设置到 AdjustOptions.Temperature 和 Tint
+func EstimateWhiteBalance(img image.Image, method string) (int, float64, error) {
+	return processor.EstimateWhiteBalance(img, method)
+}
+
+// EstimateTone 根据亮度直方图估计自动色调的色阶，可设置到 AdjustOptions.Levels
+func EstimateTone(img image.Image) Levels {
+	return processor.EstimateTone(img)
+}
+
+// Crop 裁剪图像，返回新图像
+func Crop(img image.Image, opts CropOptions) (image.Image, error) {
+	return CropOperation{Options: opts}.Apply(context.Background(), img)
+}
+
+// Resize 调整图像尺寸，返回新图像
+func Resize(img image.Image, opts ResizeOptions) (image.Image, error) {
+	return ResizeOperation{Options: opts}.Apply(context.Background(), img)
+}
+
+// Watermark 为图像添加文字或图片水印，返回新图像
+func Watermark(img image.Image, opts WatermarkOptions) (image.Image, error) {
+	return WatermarkOperation{Options: opts}.Apply(context.Background(), img)
+}
+
+// DetectPayload 检测图像中由 WatermarkOptions.Invisible 嵌入的不可见水印，返回其载荷。
+// 没有检测到或校验失败时返回 ErrNoPayload。图像可以经过缩放和重新压缩，但不能裁剪或旋转
+func DetectPayload(img image.Image) (string, error) {
+	return processor.DetectPayload(img)
+}
+
+// Frame 为图像加上边框和显示相机信息的底栏，返回新图像。
+// 文字中的 EXIF 占位符使用 ctx 中由 WithSource 传入的源文件信息
+func Frame(ctx context.Context, img image.Image, opts FrameOptions) (image.Image, error) {
+	return FrameOperation{Options: opts}.Apply(ctx, img)
+}
+
+// WithSource 返回携带源文件信息的 context，传给 Apply 后水印文字中的
+// {Make}、{DateTime:2006-01-02}、{Filename} 等占位符按该文件替换
+func WithSource(ctx context.Context, src Source) context.Context {
+	return processor.WithSource(ctx, src)
+}
+
+// Apply 依次应用多个操作，ctx 取消时在两个操作之间返回 ctx.Err()
+func Apply(ctx context.Context, img image.Image, ops ...Operation) (image.Image, error) {
+	result := img
+	for _, op := range ops {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var err error
+		if result, err = op.Apply(ctx, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Decode 解码图像，支持 JPEG、PNG、GIF、TIFF、BMP、WebP
+func Decode(r io.Reader) (image.Image, error) {
+	img, err := imaging.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("无法解码图像: %w", err)
+	}
+	return img, nil
+}
+
+// Encode 按指定格式编码图像，格式不受支持时返回 ErrUnsupportedFormat，
+// 质量超出范围时返回 ErrInvalidOptions
+func Encode(w io.Writer, img image.Image, opts EncodeOptions) error {
+	format := opts.Format
+	if format == "" || format == processor.FormatAuto {
+		format = JPEG
+	}
+	return processor.Encode(w, img, "", processor.EncodeOptions{
+		Format:          string(format),
+		Quality:         opts.Quality,
+		Lossless:        opts.Lossless,
+		TIFFCompression: opts.TIFFCompression,
+	})
+}
+
+// ReadMetadata 读取图像的 EXIF 元数据，没有 EXIF 时返回 ErrNoMetadata
+func ReadMetadata(r io.Reader) (*Metadata, error) {
+	return processor.ReadMetadata(r)
+}